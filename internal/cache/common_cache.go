@@ -40,6 +40,9 @@ type CommonCache interface {
 
 	GetOrderMartExchangeRate(ctx context.Context, key string) (*model.OrderMartExchangeRate, error)
 	SetOrderMartExchangeRateBatch(ctx context.Context, dataList []*model.OrderMartExchangeRate) bool
+
+	GetSlsHiddenFeeResultsWithLocal(ctx context.Context, keys []string, localExpire time.Duration) (vals [][]*model.CalcHiddenFeeResult, founds []bool, err error)
+	SetSlsHiddenFeeResultsWithLocal(ctx context.Context, kvs map[string][]*model.CalcHiddenFeeResult, localExpire time.Duration, remoteExpire time.Duration) error
 }
 
 type CommonCacheImpl struct {
@@ -137,6 +140,67 @@ func (c *CommonCacheImpl) SetInt32sWithLocal(ctx context.Context, kvs map[string
 	return nil
 }
 
+// GetSlsHiddenFeeResultsWithLocal gets from local cache first, values only found in redis are set into local cache
+func (c *CommonCacheImpl) GetSlsHiddenFeeResultsWithLocal(ctx context.Context, keys []string, localExpire time.Duration) (vals [][]*model.CalcHiddenFeeResult, founds []bool, err error) {
+	vals = make([][]*model.CalcHiddenFeeResult, len(keys))
+	founds = make([]bool, len(keys))
+	receivers := make(map[string]interface{})
+	for _, key := range keys {
+		receivers[key] = new([]*model.CalcHiddenFeeResult)
+	}
+	err = c.localCache.GetMany(ctx, receivers)
+	if err == nil {
+		for i, key := range keys {
+			if v, ok := receivers[key].(*[]*model.CalcHiddenFeeResult); ok && v != nil && *v != nil {
+				founds[i] = true
+				vals[i] = *v
+			}
+		}
+	}
+
+	localMissKeys := make([]string, 0)
+	originalIndexes := make([]int, 0)
+	for i, found := range founds {
+		if !found {
+			localMissKeys = append(localMissKeys, keys[i])
+			originalIndexes = append(originalIndexes, i)
+		}
+	}
+	if len(localMissKeys) == 0 {
+		return vals, founds, nil
+	}
+
+	remoteReceivers := make(map[string]interface{})
+	for _, key := range localMissKeys {
+		remoteReceivers[key] = new([]*model.CalcHiddenFeeResult)
+	}
+	err = c.remoteStore.GetMany(ctx, remoteReceivers)
+	if err != nil {
+		return vals, founds, err
+	}
+	remoteHits := make(map[string]interface{})
+	for i, key := range localMissKeys {
+		if v, ok := remoteReceivers[key].(*[]*model.CalcHiddenFeeResult); ok && v != nil && *v != nil {
+			founds[originalIndexes[i]] = true
+			vals[originalIndexes[i]] = *v
+			remoteHits[key] = *v
+		}
+	}
+	if len(remoteHits) > 0 {
+		_ = c.localCache.SetMany(ctx, remoteHits, localExpire)
+	}
+	return vals, founds, nil
+}
+
+func (c *CommonCacheImpl) SetSlsHiddenFeeResultsWithLocal(ctx context.Context, kvs map[string][]*model.CalcHiddenFeeResult, localExpire time.Duration, remoteExpire time.Duration) error {
+	storeKvs := make(map[string]interface{})
+	for k, v := range kvs {
+		storeKvs[k] = v
+	}
+	_ = c.localCache.SetMany(ctx, storeKvs, localExpire)
+	return c.remoteStore.SetMany(ctx, storeKvs, remoteExpire)
+}
+
 func (c *CommonCacheImpl) GetString(ctx context.Context, key string) (string, error) {
 	var val string
 	err := c.Get(ctx, key, &val)
@@ -11,6 +11,10 @@ import (
 
 type CbscPriceConfig struct {
 	CbscPriceFeeConfigMap map[string]*CBSCPriceFeeConfig `json:"cbsc_price_fee_config_map"` // merchant Region -> CBSCPriceFeeConfig
+
+	// aggregation of hidden fees across enabled channels, max if not configured
+	HiddenFeeAggregationConfigMap         map[string]*CbscHiddenFeeAggregationConfig            `json:"hidden_fee_aggregation_config_map"`          // mpsku region -> config
+	MerchantHiddenFeeAggregationConfigMap map[uint64]map[string]*CbscHiddenFeeAggregationConfig `json:"merchant_hidden_fee_aggregation_config_map"` // merchant id -> mpsku region -> config, takes priority over region
 }
 
 type CBSCPriceFeeConfig struct {
@@ -21,6 +25,22 @@ type CBSCPriceFeeConfig struct {
 	ServiceFeeRateMin  uint64 `json:"service_fee_rate_min"`
 }
 
+const (
+	HiddenFeeAggregationMax                = "max"
+	HiddenFeeAggregationMin                = "min"
+	HiddenFeeAggregationShopDefaultChannel = "shop_default_channel"
+	HiddenFeeAggregationWeightedAverage    = "weighted_average"
+	HiddenFeeAggregationPinnedChannel      = "pinned_channel"
+)
+
+// CbscHiddenFeeAggregationConfig strategy to aggregate hidden fees of enabled channels into one hidden fee,
+// max is used if the strategy can not be applied, e.g. the pinned channel is not enabled
+type CbscHiddenFeeAggregationConfig struct {
+	Strategy        string             `json:"strategy"`          // max, min, shop_default_channel, weighted_average or pinned_channel
+	PinnedChannelId uint32             `json:"pinned_channel_id"` // pinned_channel only
+	ChannelWeights  map[uint32]float64 `json:"channel_weights"`   // weighted_average only, channel id -> share, channels not listed are ignored
+}
+
 func onCbscPriceConfigUpdate(e uniconfig.Event) {
 	rawNewConfig, err := e.New()
 	if err != nil {
@@ -53,3 +73,15 @@ func GetCbscPriceFeeConfig(merchantRegion string) *CBSCPriceFeeConfig {
 	}
 	return confVal.CbscPriceConfig.CbscPriceFeeConfigMap[merchantRegion]
 }
+
+// GetCbscHiddenFeeAggregationConfig returns the hidden fee aggregation of merchant in mpsku region, merchant config takes
+// priority over region config, nil if neither is configured
+func GetCbscHiddenFeeAggregationConfig(merchantId uint64, mpskuRegion string) *CbscHiddenFeeAggregationConfig {
+	if confVal == nil || confVal.CbscPriceConfig == nil {
+		return nil
+	}
+	if cfg, ok := confVal.CbscPriceConfig.MerchantHiddenFeeAggregationConfigMap[merchantId][mpskuRegion]; ok && cfg != nil {
+		return cfg
+	}
+	return confVal.CbscPriceConfig.HiddenFeeAggregationConfigMap[mpskuRegion]
+}
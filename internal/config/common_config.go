@@ -62,6 +62,15 @@ type CommonConfig struct {
 	OrderMartExchangeRateRefreshSeconds int32 `json:"order_mart_exchange_rate_refresh_seconds"`
 	OrderMartExchangeRateRetrySeconds   int32 `json:"order_mart_exchange_rate_retry_seconds"`
 
+	SlsHiddenFeeCache *SlsHiddenFeeCacheConfig `json:"sls_hidden_fee_cache"`
+
+	// keyed by caller of convert_currency, callers not configured have no fallback
+	ExchangeRateFallback map[string]*ExchangeRateFallbackConfig `json:"exchange_rate_fallback"`
+
+	// a refreshed cb sip or order mart rate which moves more than the percent from the previous rate is quarantined
+	// until approved, e.g. 20 for 20%. 0 disables the detection
+	ExchangeRateJumpPercent float64 `json:"exchange_rate_jump_percent"`
+
 	// precision based on region or currency
 	PricePrecisionMap map[string]int32 `json:"price_precision"`
 
@@ -97,6 +106,26 @@ type ItemMarginLimit struct {
 	MaxAItemMargin int32 `json:"max_a_item_margin"` //exclusive
 }
 
+// SlsHiddenFeeCacheConfig cache of SLS BatchCalcHiddenFee results, keyed by the query
+type SlsHiddenFeeCacheConfig struct {
+	Enable              bool            `json:"enable"`
+	DisabledRegions     map[string]bool `json:"disabled_regions"`
+	LocalExpireSeconds  int32           `json:"local_expire_seconds"`
+	RemoteExpireSeconds int32           `json:"remote_expire_seconds"`
+	// opt-in approximation, sku weight in the cache key is rounded up to a multiple of the bucket so that near weights
+	// share the fees of the first weight sent to SLS. SLS is always called with the actual weight, and the weight in
+	// the key is exact if the region is not configured
+	WeightBucketGram map[string]float64 `json:"weight_bucket_gram"`
+}
+
+// ExchangeRateFallbackConfig sources tried in order when the requested exchange rate source fails
+type ExchangeRateFallbackConfig struct {
+	Sources []uint32 `json:"sources"` // refer to pb.Constant_ExchangeRateSource
+	// max relative difference between the rate of fallback source and the last known rate of requested source, e.g.
+	// 0.02 for 2%
+	Tolerance float64 `json:"tolerance"`
+}
+
 var defaultCBShopMarginLimit = &ShopMarginLimit{
 	MinAShopMargin: -1,
 	MaxAShopMargin: 3,
@@ -234,6 +263,15 @@ func applyDefaultValForCommonConfig() {
 		commonCfg.OrderMartExchangeRateRetrySeconds = defaultOrderMartExchangeRateRetrySeconds
 	}
 
+	if commonCfg.SlsHiddenFeeCache != nil {
+		if commonCfg.SlsHiddenFeeCache.LocalExpireSeconds <= 0 {
+			commonCfg.SlsHiddenFeeCache.LocalExpireSeconds = expireTime1Minute
+		}
+		if commonCfg.SlsHiddenFeeCache.RemoteExpireSeconds <= 0 {
+			commonCfg.SlsHiddenFeeCache.RemoteExpireSeconds = expireTime10Minutes
+		}
+	}
+
 	if commonCfg.CBShopMarginLimit == nil {
 		commonCfg.CBShopMarginLimit = defaultCBShopMarginLimit
 	}
@@ -250,6 +288,38 @@ func GetCommonConfig() *CommonConfig {
 	return confVal.CommonCfg
 }
 
+// GetSlsHiddenFeeCacheConfig returns nil if SLS hidden fee cache is not enabled for region
+func GetSlsHiddenFeeCacheConfig(region string) *SlsHiddenFeeCacheConfig {
+	if GetCommonConfig() == nil || GetCommonConfig().SlsHiddenFeeCache == nil {
+		return nil
+	}
+	cacheCfg := GetCommonConfig().SlsHiddenFeeCache
+	if !cacheCfg.Enable || cacheCfg.DisabledRegions[strings.ToUpper(region)] {
+		return nil
+	}
+	return cacheCfg
+}
+
+// GetExchangeRateFallbackConfig returns nil if caller has no fallback
+func GetExchangeRateFallbackConfig(caller string) *ExchangeRateFallbackConfig {
+	if GetCommonConfig() == nil || caller == "" {
+		return nil
+	}
+	fallbackCfg := GetCommonConfig().ExchangeRateFallback[caller]
+	if fallbackCfg == nil || len(fallbackCfg.Sources) == 0 {
+		return nil
+	}
+	return fallbackCfg
+}
+
+// GetExchangeRateJumpPercent returns 0 if exchange rate jump detection is disabled
+func GetExchangeRateJumpPercent() float64 {
+	if GetCommonConfig() == nil {
+		return 0
+	}
+	return GetCommonConfig().ExchangeRateJumpPercent
+}
+
 func GetCmdIgnoreReqRespInLogListConfig() []CmdIgnoreReqRespInLog {
 	if GetCommonConfig() == nil {
 		return nil
@@ -101,6 +101,8 @@ func getAndCastSpexConfigs() {
 
 	rawSIPMigrationConfig := getConfig(KeySIPMigrationConfig)
 	confVal.SIPMigrationCfg, _ = rawSIPMigrationConfig.(*SIPMigrationConfig)
+	dropUnknownFormulaVersions(confVal.SIPMigrationCfg)
+	flagUnknownPriceGuardrailModes(confVal.SIPMigrationCfg)
 
 	rawMerchantConfigLocalCache := getConfig(KeyMerchantConfigLocalCache)
 	confVal.LocalCacheForMerchantConfig, _ = rawMerchantConfigLocalCache.(*cache.InMemoryCacheConfig)
@@ -9,6 +9,7 @@ import (
 
 	"git.garena.com/shopee/core-server/core-logic/cerr"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
@@ -120,92 +121,106 @@ func (*rateTableParse) ParseAdjustment(value string) (int64, error) {
 	return calcutil.ToDBPrice(adjustment), nil
 }
 
-func buildHpfnConfigMap(confs []map[string]string) (map[string][]*sip_db.HpfnConfig, bool) {
-	regions := []string{"PH", "TH", "SG", "MY", "VN", "ID", "TW", "BR"}
-	for _, region := range regions {
-		find := false
-		for _, conf := range confs {
-			c := conf["country"]
-			if c == region {
-				find = true
-			}
-		}
-		if !find {
-			logging.GetLogger(context.Background()).Error(fmt.Sprintf("region=%s not config", region))
-			return nil, false
-		}
-	}
+// regions which must have a default rate table
+var defaultRateTableRegions = []string{"PH", "TH", "SG", "MY", "VN", "ID", "TW", "BR"}
 
+// buildHpfnConfigMap parses default rate table by region, the row id is the index in config. a region with any bad row
+// is dropped as a whole, so its weights never fall into a wrong row, other regions are still returned
+func buildHpfnConfigMap(confs []map[string]string) (map[string][]*sip_db.HpfnConfig, []*model.RateTableViolation) {
+	violations := make([]*model.RateTableViolation, 0)
+	badRegions := map[string]bool{}
 	ret := map[string][]*sip_db.HpfnConfig{}
-	for _, conf := range confs {
+	for i, conf := range confs {
 		region := conf["country"]
-
-		weightRange, err := RateTableParse.ParseWeightRange(conf["weight_range"])
-		if err != nil {
-			logging.GetLogger(context.Background()).Error(err.Error())
-			return nil, false
-		}
-		startPrice, err := RateTableParse.ParseStartPrice(conf["start_price"])
-		if err != nil {
-			logging.GetLogger(context.Background()).Error(err.Error())
-			return nil, false
-		}
-		startWeight, err := RateTableParse.ParseStartWeight(conf["start_weight"])
-		if err != nil {
-			logging.GetLogger(context.Background()).Error(err.Error())
-			return nil, false
-		}
-		roundSize, err := RateTableParse.ParseRoundSize(conf["round_size"])
-		if err != nil {
-			logging.GetLogger(context.Background()).Error(err.Error())
-			return nil, false
-		}
-		price, err := RateTableParse.ParsePrice(conf["price"])
-		if err != nil {
-			logging.GetLogger(context.Background()).Error(err.Error())
-			return nil, false
-		}
-		weightStep, err := RateTableParse.ParseWeightStep(conf["weight_step"])
+		rateTable, err := parseHpfnConfigRow(conf)
 		if err != nil {
-			logging.GetLogger(context.Background()).Error(err.Error())
-			return nil, false
+			violations = append(violations, &model.RateTableViolation{
+				Source:  pb.Constant_RATE_TABLE_SOURCE_DEFAULT_RATE_TABLE,
+				Type:    pb.Constant_RATE_TABLE_VIOLATION_INVALID_ROW,
+				HpfnKey: region,
+				Region:  region,
+				RowId:   int64(i),
+				Detail:  err.Error(),
+			})
+			badRegions[region] = true
+			continue
 		}
-		adjustment, err := RateTableParse.ParseAdjustment(conf["adjustment"])
-		if err != nil {
-			logging.GetLogger(context.Background()).Error(err.Error())
-			return nil, false
-		}
-
-		rateTable := &sip_db.HpfnConfig{
-			WeightRange: weightRange,
-			StartPrice:  startPrice,
-			StartWeight: startWeight,
-			RoundSize:   roundSize,
-			Price:       price,
-			WeightStep:  weightStep,
-			Adjustment:  adjustment,
+		rateTable.Id = int64(i)
+		rateTable.HpfnKey = region
+		ret[region] = append(ret[region], rateTable)
+	}
+	for region := range badRegions {
+		delete(ret, region)
+	}
+
+	for _, region := range defaultRateTableRegions {
+		if _, ok := ret[region]; !ok && !badRegions[region] {
+			violations = append(violations, &model.RateTableViolation{
+				Source:  pb.Constant_RATE_TABLE_SOURCE_DEFAULT_RATE_TABLE,
+				Type:    pb.Constant_RATE_TABLE_VIOLATION_MISSING_REGION,
+				HpfnKey: region,
+				Region:  region,
+				Detail:  fmt.Sprintf("region=%s not config", region),
+			})
 		}
-		oldTable := ret[region]
-		ret[region] = append(oldTable, rateTable)
 	}
-	newRet := map[string][]*sip_db.HpfnConfig{}
-	for pRegion, xs := range ret {
+
+	for _, xs := range ret {
 		sort.Slice(xs, func(i, j int) bool {
 			return xs[i].WeightRange < xs[j].WeightRange
 		})
-		newRet[pRegion] = xs
 	}
-	return newRet, true
+	return ret, violations
 }
 
+func parseHpfnConfigRow(conf map[string]string) (*sip_db.HpfnConfig, error) {
+	weightRange, err := RateTableParse.ParseWeightRange(conf["weight_range"])
+	if err != nil {
+		return nil, err
+	}
+	startPrice, err := RateTableParse.ParseStartPrice(conf["start_price"])
+	if err != nil {
+		return nil, err
+	}
+	startWeight, err := RateTableParse.ParseStartWeight(conf["start_weight"])
+	if err != nil {
+		return nil, err
+	}
+	roundSize, err := RateTableParse.ParseRoundSize(conf["round_size"])
+	if err != nil {
+		return nil, err
+	}
+	price, err := RateTableParse.ParsePrice(conf["price"])
+	if err != nil {
+		return nil, err
+	}
+	weightStep, err := RateTableParse.ParseWeightStep(conf["weight_step"])
+	if err != nil {
+		return nil, err
+	}
+	adjustment, err := RateTableParse.ParseAdjustment(conf["adjustment"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &sip_db.HpfnConfig{
+		WeightRange: weightRange,
+		StartPrice:  startPrice,
+		StartWeight: startWeight,
+		RoundSize:   roundSize,
+		Price:       price,
+		WeightStep:  weightStep,
+		Adjustment:  adjustment,
+	}, nil
+}
+
+// GetHpfnConfigMapFromConfig default rate tables by region, regions with bad rows are left out and reported by
+// ValidateDefaultRateTable
 func GetHpfnConfigMapFromConfig() (map[string][]*sip_db.HpfnConfig, error) {
 	cfg, err := config.GetDefaultRateTableForCbHiddenPrice()
 	if err != nil {
 		return nil, err
 	}
-	res, ok := buildHpfnConfigMap(cfg)
-	if !ok {
-		return nil, cerr.New(fmt.Sprintf("failed to build hpfn config map, cfg=%v", cfg), uint32(pb.Constant_ERROR_PARAMS))
-	}
+	res, _ := buildHpfnConfigMap(cfg)
 	return res, nil
 }
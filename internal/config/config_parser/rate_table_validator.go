@@ -0,0 +1,180 @@
+package config_parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
+)
+
+// ValidateDefaultRateTable lints DefaultRateTableForCbHiddenPrice, including rows which can not be parsed and regions
+// which are not configured
+func ValidateDefaultRateTable() ([]*model.RateTableViolation, error) {
+	cfg, err := config.GetDefaultRateTableForCbHiddenPrice()
+	if err != nil {
+		return nil, err
+	}
+	tables, violations := buildHpfnConfigMap(cfg)
+	return append(violations, ValidateRateTables(pb.Constant_RATE_TABLE_SOURCE_DEFAULT_RATE_TABLE, tables)...), nil
+}
+
+// ValidateRateTables lints rate tables by key, rows of each key must be sorted by weight range
+func ValidateRateTables(source pb.Constant_RateTableSource, tables map[string][]*sip_db.HpfnConfig) []*model.RateTableViolation {
+	keys := make([]string, 0, len(tables))
+	for key := range tables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	violations := make([]*model.RateTableViolation, 0)
+	for _, key := range keys {
+		violations = append(violations, validateRateTableRows(source, key, tables[key])...)
+	}
+	return violations
+}
+
+func validateRateTableRows(source pb.Constant_RateTableSource, key string, rows []*sip_db.HpfnConfig) []*model.RateTableViolation {
+	violations := make([]*model.RateTableViolation, 0)
+	if len(rows) == 0 {
+		return violations
+	}
+	region := ""
+	if source == pb.Constant_RATE_TABLE_SOURCE_DEFAULT_RATE_TABLE {
+		region = key
+	}
+
+	// rows with zero weight step can not be calculated, monotonicity is only checked between calculable rows
+	var prev *sip_db.HpfnConfig
+	for i, row := range rows {
+		addViolation := func(violationType pb.Constant_RateTableViolationType, detail string) {
+			violations = append(violations, &model.RateTableViolation{
+				Source:      source,
+				Type:        violationType,
+				HpfnKey:     key,
+				Region:      region,
+				RowId:       row.Id,
+				WeightRange: row.WeightRange,
+				Detail:      detail,
+			})
+		}
+
+		roundSize, weightStep := calcutil.HiddenPriceWeightUnits(row.RoundSize, row.WeightStep)
+		if weightStep <= 0 {
+			addViolation(pb.Constant_RATE_TABLE_VIOLATION_ZERO_WEIGHT_STEP,
+				fmt.Sprintf("weight_step=%v gram is 0 on round_size=%v", calcutil.DbWeightToGram(row.WeightStep), row.RoundSize))
+		} else if weightStep%roundSize != 0 {
+			addViolation(pb.Constant_RATE_TABLE_VIOLATION_INCONSISTENT_ROUND_SIZE,
+				fmt.Sprintf("weight_step=%v gram is not a multiple of round unit of round_size=%v", calcutil.DbWeightToGram(row.WeightStep), row.RoundSize))
+		}
+		if row.RoundSize != rows[0].RoundSize {
+			addViolation(pb.Constant_RATE_TABLE_VIOLATION_INCONSISTENT_ROUND_SIZE,
+				fmt.Sprintf("round_size=%v differs from round_size=%v of the first row", row.RoundSize, rows[0].RoundSize))
+		}
+		if row.Price < 0 {
+			addViolation(pb.Constant_RATE_TABLE_VIOLATION_NON_MONOTONIC_PRICE,
+				fmt.Sprintf("price=%v of each weight step is negative", calcutil.ToRealPrice(row.Price)))
+		}
+		if i > 0 {
+			last := rows[i-1]
+			if row.WeightRange == last.WeightRange {
+				addViolation(pb.Constant_RATE_TABLE_VIOLATION_OVERLAPPING_WEIGHT_RANGE,
+					fmt.Sprintf("weight_range=%v is same as row id=%v", RateTableParse.FormatWeightRange(row.WeightRange), last.Id))
+			} else if row.StartWeight > last.WeightRange {
+				addViolation(pb.Constant_RATE_TABLE_VIOLATION_MISSING_WEIGHT_RANGE,
+					fmt.Sprintf("start_weight=%v is above weight_range=%v of row id=%v", calcutil.DbWeightToGram(row.StartWeight),
+						RateTableParse.FormatWeightRange(last.WeightRange), last.Id))
+			}
+		}
+		if i == len(rows)-1 && row.WeightRange != Inf {
+			addViolation(pb.Constant_RATE_TABLE_VIOLATION_MISSING_WEIGHT_RANGE,
+				fmt.Sprintf("weight above weight_range=%v is not covered", RateTableParse.FormatWeightRange(row.WeightRange)))
+		}
+
+		if weightStep <= 0 {
+			continue
+		}
+		if prev != nil && prev.WeightRange != Inf {
+			boundary := calcutil.DbWeightToGram(prev.WeightRange)
+			prevPrice := calcutil.CalcHiddenPriceByHiddenPriceConf(boundary, hpfnConfigToHiddenPriceConf(prev))
+			price := calcutil.CalcHiddenPriceByHiddenPriceConf(boundary, hpfnConfigToHiddenPriceConf(row))
+			if price < prevPrice {
+				addViolation(pb.Constant_RATE_TABLE_VIOLATION_NON_MONOTONIC_PRICE,
+					fmt.Sprintf("hidden price=%v above weight=%v gram is below hidden price=%v of row id=%v", price, boundary, prevPrice, prev.Id))
+			}
+		}
+		prev = row
+	}
+	return violations
+}
+
+func hpfnConfigToHiddenPriceConf(row *sip_db.HpfnConfig) *model.HiddenPriceConf {
+	return &model.HiddenPriceConf{
+		StartPrice:  row.StartPrice,
+		StartWeight: row.StartWeight,
+		RoundSize:   row.RoundSize,
+		Price:       row.Price,
+		WeightStep:  row.WeightStep,
+		Adjustment:  row.Adjustment,
+		HpfnKey:     row.HpfnKey,
+		WeightRange: row.WeightRange,
+	}
+}
+
+// ValidateRateTableCfg lints rate_table_cfg json of P shop, P item or region rate table config, every key it references
+// must be in hpfnKeys. empty cfg means not configured and is valid
+func ValidateRateTableCfg(source pb.Constant_RateTableSource, ownerId uint64, region string, cfg string, hpfnKeys map[string][]*sip_db.HpfnConfig) []*model.RateTableViolation {
+	if len(cfg) == 0 {
+		return nil
+	}
+	rateTableCfg := &model.RateTableCfg{}
+	if err := json.Unmarshal([]byte(cfg), rateTableCfg); err != nil {
+		return []*model.RateTableViolation{{
+			Source:  source,
+			Type:    pb.Constant_RATE_TABLE_VIOLATION_INVALID_ROW,
+			Region:  region,
+			OwnerId: ownerId,
+			Detail:  fmt.Sprintf("unmarshal rate_table_cfg=%q failed, err=%v", cfg, err),
+		}}
+	}
+	return ValidateRateTableCfgKeys(source, ownerId, region, rateTableCfg, hpfnKeys)
+}
+
+// ValidateRateTableCfgKeys same as ValidateRateTableCfg on parsed cfg
+func ValidateRateTableCfgKeys(source pb.Constant_RateTableSource, ownerId uint64, region string, cfg *model.RateTableCfg, hpfnKeys map[string][]*sip_db.HpfnConfig) []*model.RateTableViolation {
+	violations := make([]*model.RateTableViolation, 0)
+	if cfg == nil {
+		return violations
+	}
+	checkKey := func(key string, detail string) {
+		if len(key) == 0 {
+			return
+		}
+		if _, ok := hpfnKeys[key]; ok {
+			return
+		}
+		violations = append(violations, &model.RateTableViolation{
+			Source:  source,
+			Type:    pb.Constant_RATE_TABLE_VIOLATION_UNKNOWN_HPFN_KEY,
+			HpfnKey: key,
+			Region:  region,
+			OwnerId: ownerId,
+			Detail:  detail,
+		})
+	}
+
+	checkKey(cfg.MstRateKey, "mst_rate_key not found in hpfn_config_tab")
+	aRegions := make([]string, 0, len(cfg.AffiRateKey))
+	for aRegion := range cfg.AffiRateKey {
+		aRegions = append(aRegions, aRegion)
+	}
+	sort.Strings(aRegions)
+	for _, aRegion := range aRegions {
+		checkKey(cfg.AffiRateKey[aRegion], fmt.Sprintf("affi_rate_key of a_region=%s not found in hpfn_config_tab", aRegion))
+	}
+	return violations
+}
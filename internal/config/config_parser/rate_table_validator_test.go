@@ -0,0 +1,68 @@
+package config_parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
+)
+
+func newTestRateTableRow(id int64, weightRange int64, startPrice float64, startWeight float64, price float64, weightStep float64) *sip_db.HpfnConfig {
+	return &sip_db.HpfnConfig{
+		Id:          id,
+		WeightRange: weightRange,
+		StartPrice:  calcutil.ToDBPrice(startPrice),
+		StartWeight: calcutil.GramToDbWeight(startWeight),
+		Price:       calcutil.ToDBPrice(price),
+		WeightStep:  calcutil.GramToDbWeight(weightStep),
+	}
+}
+
+func violationTypes(violations []*model.RateTableViolation) []pb.Constant_RateTableViolationType {
+	types := make([]pb.Constant_RateTableViolationType, 0, len(violations))
+	for _, v := range violations {
+		types = append(types, v.Type)
+	}
+	return types
+}
+
+func TestValidateRateTables(t *testing.T) {
+	tables := map[string][]*sip_db.HpfnConfig{
+		"valid": {
+			newTestRateTableRow(1, calcutil.GramToDbWeight(500), 1, 0, 0.5, 100),
+			newTestRateTableRow(2, Inf, 3.5, 500, 0.5, 100),
+		},
+		"broken": {
+			newTestRateTableRow(3, calcutil.GramToDbWeight(500), 5, 0, 0.5, 0.5),
+			newTestRateTableRow(4, calcutil.GramToDbWeight(1000), 1, 600, 0.5, 100),
+			newTestRateTableRow(5, calcutil.GramToDbWeight(2000), 1, 1000, 0.5, 100),
+		},
+	}
+	violations := ValidateRateTables(pb.Constant_RATE_TABLE_SOURCE_HPFN_CONFIG_TAB, tables)
+	assert.Equal(t, []pb.Constant_RateTableViolationType{
+		pb.Constant_RATE_TABLE_VIOLATION_ZERO_WEIGHT_STEP,
+		pb.Constant_RATE_TABLE_VIOLATION_MISSING_WEIGHT_RANGE,
+		pb.Constant_RATE_TABLE_VIOLATION_MISSING_WEIGHT_RANGE,
+		pb.Constant_RATE_TABLE_VIOLATION_NON_MONOTONIC_PRICE,
+	}, violationTypes(violations))
+	for _, v := range violations {
+		assert.Equal(t, "broken", v.HpfnKey)
+	}
+}
+
+func TestValidateRateTableCfg(t *testing.T) {
+	hpfnKeys := map[string][]*sip_db.HpfnConfig{"known": nil}
+	assert.Empty(t, ValidateRateTableCfg(pb.Constant_RATE_TABLE_SOURCE_MST_SHOP_RATE_TABLE_CFG, 1, "TW", "", hpfnKeys))
+
+	violations := ValidateRateTableCfg(pb.Constant_RATE_TABLE_SOURCE_MST_SHOP_RATE_TABLE_CFG, 1, "TW",
+		`{"mst_rate_key":"known","affi_rate_key":{"MY":"unknown","SG":"known"}}`, hpfnKeys)
+	assert.Equal(t, []pb.Constant_RateTableViolationType{pb.Constant_RATE_TABLE_VIOLATION_UNKNOWN_HPFN_KEY}, violationTypes(violations))
+	assert.Equal(t, "unknown", violations[0].HpfnKey)
+
+	violations = ValidateRateTableCfg(pb.Constant_RATE_TABLE_SOURCE_MST_ITEM_RATE_TABLE_CFG, 2, "", "{", hpfnKeys)
+	assert.Equal(t, []pb.Constant_RateTableViolationType{pb.Constant_RATE_TABLE_VIOLATION_INVALID_ROW}, violationTypes(violations))
+}
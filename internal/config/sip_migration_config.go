@@ -23,6 +23,78 @@ type SIPMigrationConfig struct {
 	HandlingFeeConfig                *int64                         `json:"handling_fee_config"`
 
 	RegionList []string `json:"region_list"`
+
+	// A regions whose CB SIP and local SIP prices are calculated with decimal arithmetic,
+	// other regions keep float64 so results can be compared during rollout
+	DecimalPriceCalcRegions []string `json:"decimal_price_calc_regions"`
+
+	// rounding strategy of A price by region, takes priority over the strategy of currency
+	RegionRoundingStrategy map[string]RoundingStrategyConfig `json:"region_rounding_strategy"`
+
+	// guardrail of calculated prices by destination region, no guardrail if region is not configured
+	PriceGuardrailConfig map[string]PriceGuardrailSetting `json:"price_guardrail_config"`
+
+	// formula version rollout by formula name: cb_sip, local_sip, cbsc, cb_sip_item_price
+	FormulaVersionConfig map[string]FormulaVersionSetting `json:"formula_version_config"`
+
+	// weight matching of local SIP shipping fee and initial hidden price tables by "P_A" region pair,
+	// step mode if region pair is not configured
+	LocalSipFeeTableConfig map[string]LocalSipFeeTableConfig `json:"local_sip_fee_table_config"`
+
+	// volumetric weight of package by A region, actual weight is charged if region is not configured
+	VolumetricWeightConfig map[string]VolumetricWeightSetting `json:"volumetric_weight_config"`
+
+	// currencies tried in order to triangulate a CB SIP exchange rate without direct pair, e.g. ["USD", "CNY"]
+	ExchangeRatePivotCurrencies []string `json:"exchange_rate_pivot_currencies"`
+}
+
+// VolumetricWeightSetting volumetric weight in kg is length * width * height of package in cm divided by Divisor,
+// the heavier one of actual weight and volumetric weight is charged
+type VolumetricWeightSetting struct {
+	Enable  bool    `json:"enable"`
+	Divisor float64 `json:"divisor"` // e.g. 5000 or 6000, volumetric weight is disabled if not positive
+}
+
+const (
+	LocalSipFeeTableModeStep          = "step"            // fee of the first row not lighter than the weight
+	LocalSipFeeTableModeLinear        = "linear"          // interpolated between the rows around the weight
+	LocalSipFeeTableModeBasePlusExtra = "base_plus_extra" // step, above the last row its fee plus PerExtraGram for each extra gram
+)
+
+// LocalSipFeeTableConfig weight matching of the local SIP fee tables of one region pair
+type LocalSipFeeTableConfig struct {
+	ShippingFee LocalSipFeeTableSetting `json:"shipping_fee"`
+	HiddenPrice LocalSipFeeTableSetting `json:"hidden_price"`
+}
+
+type LocalSipFeeTableSetting struct {
+	Mode         string  `json:"mode"`           // step if empty
+	PerExtraGram float64 `json:"per_extra_gram"` // base_plus_extra only, real price in A currency
+}
+
+// FormulaVersionSetting selects the live version of a formula by shop, then region, then default,
+// and the candidate version computed in background to compare with the live one
+type FormulaVersionSetting struct {
+	DefaultVersion string            `json:"default_version"`
+	RegionVersions map[string]string `json:"region_versions"`
+	ShopVersions   map[uint64]string `json:"shop_versions"`
+	ShadowVersion  string            `json:"shadow_version"` // shadow is disabled if empty
+	ShadowRegions  []string          `json:"shadow_regions"` // shadow is enabled in all regions if empty
+}
+
+const (
+	PriceGuardrailModeFlag   = "flag"   // outlier price is returned with flagged status
+	PriceGuardrailModeReject = "reject" // outlier price is not returned
+)
+
+// PriceGuardrailSetting bounds of calculated price, zero value means the bound is not checked.
+// multipliers are applied on the source price converted at exchange rate, prices are real values in destination currency
+type PriceGuardrailSetting struct {
+	Mode          string  `json:"mode"` // flag or reject, guardrail is disabled if empty, flag if unknown
+	MinMultiplier float64 `json:"min_multiplier"`
+	MaxMultiplier float64 `json:"max_multiplier"`
+	MinPrice      float64 `json:"min_price"`
+	MaxPrice      float64 `json:"max_price"`
 }
 
 type LocalSipLimitConfig struct {
@@ -32,6 +104,8 @@ type LocalSipLimitConfig struct {
 	InitHiddenPriceMin float64 `json:"init_hidden_price_min"`
 	BufferMax          float64 `json:"buffer_max"`
 	BufferMin          float64 `json:"buffer_min"`
+	ShippingFeeMax     float64 `json:"shipping_fee_max"`
+	ShippingFeeMin     float64 `json:"shipping_fee_min"`
 }
 
 type CurrencyCommonConf struct {
@@ -44,6 +118,18 @@ type CurrencyCommonSetting struct {
 	Precision            int     `json:"precision"`
 	PrecisionForFee      int     `json:"precision_for_fee"` // TODO used for order snapshot, remove after migration
 	UseSpecialRoundup    bool    `json:"use_special_roundup"`
+
+	// optional, UseSpecialRoundup decides between special round up and ceil when not set
+	RoundingStrategy *RoundingStrategyConfig `json:"rounding_strategy,omitempty"`
+}
+
+// RoundingStrategyConfig names a strategy registered in calcutil with its parameters
+type RoundingStrategyConfig struct {
+	Name      string    `json:"name"`                // ceil, floor, round_nearest, special_round_up, psychological, round_to_n
+	Precision *int      `json:"precision,omitempty"` // optional, currency precision is used when not set
+	Unit      float64   `json:"unit,omitempty"`      // psychological only, the price range the endings repeat in, 1 if not set
+	Endings   []float64 `json:"endings,omitempty"`   // psychological only, ascending endings inside unit, e.g. [0.5, 0.9, 0.99]
+	N         float64   `json:"n,omitempty"`         // round_to_n only, e.g. 1000 for VND
 }
 
 func (ccc *CurrencyCommonConf) GetByCurrency(currency string) CurrencyCommonSetting {
@@ -84,6 +170,8 @@ func onSIPMigrationConfigUpdate(e uniconfig.Event) {
 		return
 	}
 
+	dropUnknownFormulaVersions(newConfig)
+	flagUnknownPriceGuardrailModes(newConfig)
 	confVal.SIPMigrationCfg = newConfig
 
 	logging.GetLogger(context.Background()).Info("SIPMigrationConfig updated", ulog.String("updated", cutil.JSONEncode(newConfig)))
@@ -148,3 +236,161 @@ func GetCurrencyByRegion(region string) (string, error) {
 	currency := conf.GetByRegion(region).Currency
 	return currency, nil
 }
+
+// UseDecimalPriceCalc whether the price formulas and round up of region are calculated with decimal arithmetic
+func UseDecimalPriceCalc(region string) bool {
+	if confVal == nil || confVal.SIPMigrationCfg == nil {
+		return false
+	}
+	for _, r := range confVal.SIPMigrationCfg.DecimalPriceCalcRegions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRoundingStrategyConfig returns the rounding strategy configured for region, or for currency if region has none
+func GetRoundingStrategyConfig(region string, currency string) (RoundingStrategyConfig, bool) {
+	if confVal == nil || confVal.SIPMigrationCfg == nil {
+		return RoundingStrategyConfig{}, false
+	}
+	if strategy, ok := confVal.SIPMigrationCfg.RegionRoundingStrategy[strings.ToUpper(region)]; ok {
+		return strategy, true
+	}
+	if setting, ok := confVal.SIPMigrationCfg.CurrencyCommonConf.CommonSetting[currency]; ok && setting.RoundingStrategy != nil {
+		return *setting.RoundingStrategy, true
+	}
+	return RoundingStrategyConfig{}, false
+}
+
+// GetPriceGuardrailSetting returns the guardrail of calculated prices in region, ok is false if guardrail is disabled.
+// an unknown mode is returned as flag, so outliers are still reported
+func GetPriceGuardrailSetting(region string) (PriceGuardrailSetting, bool) {
+	if confVal == nil || confVal.SIPMigrationCfg == nil {
+		return PriceGuardrailSetting{}, false
+	}
+	setting, ok := confVal.SIPMigrationCfg.PriceGuardrailConfig[strings.ToUpper(region)]
+	if !ok || len(setting.Mode) == 0 {
+		return PriceGuardrailSetting{}, false
+	}
+	if !isPriceGuardrailModeKnown(setting.Mode) {
+		setting.Mode = PriceGuardrailModeFlag
+	}
+	return setting, true
+}
+
+func isPriceGuardrailModeKnown(mode string) bool {
+	return mode == PriceGuardrailModeFlag || mode == PriceGuardrailModeReject
+}
+
+// flagUnknownPriceGuardrailModes turns the guardrails of unknown mode in cfg into flag when it is loaded or updated,
+// so a typo of "reject" still reports outliers
+func flagUnknownPriceGuardrailModes(cfg *SIPMigrationConfig) {
+	if cfg == nil {
+		return
+	}
+	for region, setting := range cfg.PriceGuardrailConfig {
+		if len(setting.Mode) == 0 || isPriceGuardrailModeKnown(setting.Mode) {
+			continue
+		}
+		logging.GetLogger(context.Background()).Error(fmt.Sprintf("[Price Guardrail] mode is unknown and flag is used instead, region=%v, mode=%v",
+			region, setting.Mode))
+		setting.Mode = PriceGuardrailModeFlag
+		cfg.PriceGuardrailConfig[region] = setting
+	}
+}
+
+// formulaVersions versions implemented by each formula, registered by the formula packages in init
+var formulaVersions = map[string]map[string]bool{}
+
+// RegisterFormulaVersion registers an implemented version of formula, it must be called in init only
+func RegisterFormulaVersion(formula string, version string) {
+	if _, ok := formulaVersions[formula]; !ok {
+		formulaVersions[formula] = map[string]bool{}
+	}
+	formulaVersions[formula][version] = true
+}
+
+// IsFormulaVersionRegistered any version is accepted for a formula without registered versions
+func IsFormulaVersionRegistered(formula string, version string) bool {
+	versions, ok := formulaVersions[formula]
+	return !ok || versions[version]
+}
+
+// dropUnknownFormulaVersions removes the versions which are not implemented from cfg when it is loaded, so a typo or a
+// version configured before its code is released is never resolved
+func dropUnknownFormulaVersions(cfg *SIPMigrationConfig) {
+	if cfg == nil {
+		return
+	}
+	for formula, setting := range cfg.FormulaVersionConfig {
+		isUnknown := func(version string) bool {
+			if len(version) == 0 || IsFormulaVersionRegistered(formula, version) {
+				return false
+			}
+			logging.GetLogger(context.Background()).Error(fmt.Sprintf("[Formula Version] version is not implemented and ignored, formula=%v, version=%v",
+				formula, version))
+			return true
+		}
+		if isUnknown(setting.DefaultVersion) {
+			setting.DefaultVersion = ""
+		}
+		if isUnknown(setting.ShadowVersion) {
+			setting.ShadowVersion = ""
+		}
+		regionVersions := make(map[string]string, len(setting.RegionVersions))
+		for region, version := range setting.RegionVersions {
+			if !isUnknown(version) {
+				regionVersions[region] = version
+			}
+		}
+		setting.RegionVersions = regionVersions
+		shopVersions := make(map[uint64]string, len(setting.ShopVersions))
+		for shopId, version := range setting.ShopVersions {
+			if !isUnknown(version) {
+				shopVersions[shopId] = version
+			}
+		}
+		setting.ShopVersions = shopVersions
+		cfg.FormulaVersionConfig[formula] = setting
+	}
+}
+
+// GetFormulaVersionSetting returns the version rollout of formula, ok is false if formula is not configured
+func GetFormulaVersionSetting(formula string) (FormulaVersionSetting, bool) {
+	if confVal == nil || confVal.SIPMigrationCfg == nil {
+		return FormulaVersionSetting{}, false
+	}
+	setting, ok := confVal.SIPMigrationCfg.FormulaVersionConfig[formula]
+	return setting, ok
+}
+
+// GetLocalSipFeeTableConfig returns the weight matching of local SIP fee tables of region pair, zero value is step mode
+func GetLocalSipFeeTableConfig(pRegion string, aRegion string) LocalSipFeeTableConfig {
+	if confVal == nil || confVal.SIPMigrationCfg == nil {
+		return LocalSipFeeTableConfig{}
+	}
+	key := fmt.Sprintf("%s_%s", strings.ToUpper(pRegion), strings.ToUpper(aRegion))
+	return confVal.SIPMigrationCfg.LocalSipFeeTableConfig[key]
+}
+
+// GetVolumetricWeightSetting returns the volumetric weight setting of A region, ok is false if it is disabled
+func GetVolumetricWeightSetting(aRegion string) (VolumetricWeightSetting, bool) {
+	if confVal == nil || confVal.SIPMigrationCfg == nil {
+		return VolumetricWeightSetting{}, false
+	}
+	setting, ok := confVal.SIPMigrationCfg.VolumetricWeightConfig[strings.ToUpper(aRegion)]
+	if !ok || !setting.Enable || setting.Divisor <= 0 {
+		return VolumetricWeightSetting{}, false
+	}
+	return setting, true
+}
+
+// GetExchangeRatePivotCurrencies returns nil if triangulation is disabled
+func GetExchangeRatePivotCurrencies() []string {
+	if confVal == nil || confVal.SIPMigrationCfg == nil {
+		return nil
+	}
+	return confVal.SIPMigrationCfg.ExchangeRatePivotCurrencies
+}
@@ -151,7 +151,7 @@ func (dm *calculateAffiMpskuDm) doCalcLocalSipOverseaDiscount(ctx context.Contex
 		return result
 	}
 
-	affiPrice := calcutil.CalculateAffiPriceForLocalSip(ctx,
+	affiPrice := calcutil.CalculateAffiPriceForLocalSip(ctx, affiRegion,
 		calcutil.ToRealPrice(primaryOriginPrice),
 		calcutil.DbWeightToGram(weight), itemMargin, shopMargin,
 		initHiddenPrice, shippingFee,
@@ -184,7 +184,7 @@ func (dm *calculateAffiMpskuDm) calcHiddenPrice(ctx context.Context,
 				Weight:       weight,
 				PRegion:      calcData.PrimaryRegion,
 			},
-		})
+		}, false)
 	if err != nil {
 		return 0, err
 	}
@@ -55,7 +55,7 @@ func Test_calculateAffiMpskuDm_doCalcLocalSipOverseaDiscount(t *testing.T) {
 
 	calculationFactorsRepo := mockfactors.NewMockCalculationFactorsRepo(ctrl)
 	calculationFactorsRepo.EXPECT().
-		GetInitialHiddenPriceForLocalSip(ctx, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		GetInitialHiddenPriceForLocalSip(ctx, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return([]model.LocalSipHiddenPriceResult{
 			{
 				QueryId:     0,
@@ -175,6 +175,7 @@ func (dm *CalculateMtskuAndMpskuDmImpl) calcMtskuAndMpsku(ctx context.Context, q
 		[]model.GetHidePriceForCbscRequest{
 			{
 				QueryId:              0,
+				MerchantId:           query.MerchantId,
 				Region:               query.MpskuRegion,
 				Weight:               mpskuItemWeight,
 				IsMtskuToMpsku:       query.QueryMtskuToMpsku,
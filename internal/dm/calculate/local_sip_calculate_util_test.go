@@ -15,6 +15,7 @@ import (
 func TestCalcAffiPriceForLocalWithOverseaDiscount(t *testing.T) {
 	type args struct {
 		ctx                 context.Context
+		aRegion             string
 		primaryPrice        float64
 		affiRealWeight      float64
 		itemMargin          float64
@@ -34,6 +35,7 @@ func TestCalcAffiPriceForLocalWithOverseaDiscount(t *testing.T) {
 			name: "calc test",
 			args: args{
 				ctx:             context.Background(),
+				aRegion:         "MY",
 				primaryPrice:    82900,
 				affiRealWeight:  1200.0,
 				itemMargin:      1.0,
@@ -52,7 +54,7 @@ func TestCalcAffiPriceForLocalWithOverseaDiscount(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calcutil.CalculateAffiPriceForLocalSip(tt.args.ctx, tt.args.primaryPrice, tt.args.affiRealWeight, tt.args.itemMargin, tt.args.shopMargin, tt.args.initHiddenPrice, tt.args.shippingFee, tt.args.localPriceConfig, &tt.args.overseaDiscountRate)
+			got := calcutil.CalculateAffiPriceForLocalSip(tt.args.ctx, tt.args.aRegion, tt.args.primaryPrice, tt.args.affiRealWeight, tt.args.itemMargin, tt.args.shopMargin, tt.args.initHiddenPrice, tt.args.shippingFee, tt.args.localPriceConfig, &tt.args.overseaDiscountRate)
 			if !assert.InDelta(t, tt.want, got, 0.0001) {
 				t.Errorf("CalcAffiPriceForLocalWithOverseaDiscount() = %v, want %v", got, tt.want)
 			}
@@ -0,0 +1,245 @@
+package http
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"git.garena.com/shopee/platform/service-governance/observability/metric"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/core-logic/cutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/cache"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/httpcliutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+const (
+	slsHiddenFeeCacheKeyPrefix  = "sls_hidden_fee"
+	slsHiddenFeeCacheMetricName = "sls_hidden_fee_cache"
+
+	slsHiddenFeeCacheHit      = "hit"
+	slsHiddenFeeCacheMiss     = "miss"
+	slsHiddenFeeCacheInflight = "inflight" // waited for the same query sent by another request
+)
+
+type slsHiddenFeeCacheBypassKey struct{}
+
+// WithSlsHiddenFeeCacheBypass SLS hidden fee cache is neither read nor written under the returned ctx, for create
+// flows which must show the fees of the current SLS rates
+func WithSlsHiddenFeeCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, slsHiddenFeeCacheBypassKey{}, true)
+}
+
+func isSlsHiddenFeeCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(slsHiddenFeeCacheBypassKey{}).(bool)
+	return bypass
+}
+
+// slsHiddenFeeCall one query being sent to SLS, requests of the same query wait for it instead of calling SLS again
+type slsHiddenFeeCall struct {
+	done    chan struct{}
+	results []*model.CalcHiddenFeeResult
+	err     error
+}
+
+var slsHiddenFeeInflight = struct {
+	sync.Mutex
+	calls map[string]*slsHiddenFeeCall
+}{calls: make(map[string]*slsHiddenFeeCall)}
+
+// BatchCalcHiddenFeeWithCache same as BatchCalcHiddenFee, but the result of each query is cached in local cache and
+// redis by the normalized query, and concurrent requests of the same query share one SLS call. all queries must be of
+// region. results with any failed channel are not cached
+func BatchCalcHiddenFeeWithCache(httpCli httpcliutil.HTTPCli, ctx context.Context, commonCache cache.CommonCache,
+	region string, regionDomain string, req *model.BatchCalcHiddenFeeRequest) (*model.BatchCalcHiddenFeeResponse, error) {
+	return batchCalcHiddenFeeWithCache(ctx, commonCache, region, req, func(req *model.BatchCalcHiddenFeeRequest) (*model.BatchCalcHiddenFeeResponse, error) {
+		return BatchCalcHiddenFee(httpCli, ctx, regionDomain, req)
+	})
+}
+
+// batchCalcHiddenFeeWithCache calcHiddenFee sends queries to SLS
+func batchCalcHiddenFeeWithCache(ctx context.Context, commonCache cache.CommonCache, region string, req *model.BatchCalcHiddenFeeRequest,
+	calcHiddenFee func(req *model.BatchCalcHiddenFeeRequest) (*model.BatchCalcHiddenFeeResponse, error)) (*model.BatchCalcHiddenFeeResponse, error) {
+	cacheCfg := config.GetSlsHiddenFeeCacheConfig(region)
+	if cacheCfg == nil || commonCache == nil || isSlsHiddenFeeCacheBypassed(ctx) {
+		return calcHiddenFee(req)
+	}
+	localExpire := time.Duration(cacheCfg.LocalExpireSeconds) * time.Second
+	remoteExpire := time.Duration(cacheCfg.RemoteExpireSeconds) * time.Second
+
+	// same queries in one request are sent once, SLS is called with the query of caller and the normalized query is
+	// only used as the cache key
+	weightBucket := cacheCfg.WeightBucketGram[strings.ToUpper(region)]
+	keys := make([]string, 0, len(req.List))
+	queryByKey := make(map[string]*model.CalcHiddenFee)
+	indexesByKey := make(map[string][]int)
+	for i, query := range req.List {
+		key := slsHiddenFeeCacheKey(region, normalizeCalcHiddenFee(query, weightBucket))
+		if _, ok := queryByKey[key]; !ok {
+			keys = append(keys, key)
+			queryByKey[key] = query
+		}
+		indexesByKey[key] = append(indexesByKey[key], i)
+	}
+
+	resultByKey := make(map[string][]*model.CalcHiddenFeeResult, len(keys))
+	vals, founds, err := commonCache.GetSlsHiddenFeeResultsWithLocal(ctx, keys, localExpire)
+	if err != nil {
+		logging.GetLogger(ctx).Warn(fmt.Sprintf("failed to get sls hidden fee from cache, region=%s, err=%v", region, err))
+	}
+	missKeys := make([]string, 0)
+	for i, key := range keys {
+		if founds[i] {
+			resultByKey[key] = vals[i]
+			reportSlsHiddenFeeCache(region, slsHiddenFeeCacheHit)
+		} else {
+			missKeys = append(missKeys, key)
+		}
+	}
+
+	ownedKeys, ownedCalls, waitingCalls := joinSlsHiddenFeeCalls(missKeys)
+	if len(ownedKeys) > 0 {
+		ownedQueries := make([]*model.CalcHiddenFee, 0, len(ownedKeys))
+		for _, key := range ownedKeys {
+			ownedQueries = append(ownedQueries, queryByKey[key])
+			reportSlsHiddenFeeCache(region, slsHiddenFeeCacheMiss)
+		}
+		resp, err := calcHiddenFee(&model.BatchCalcHiddenFeeRequest{List: ownedQueries})
+		if err == nil && resp.RetCode == 0 && len(resp.Data) != len(ownedQueries) {
+			err = cerr.New(fmt.Sprintf("the length of response is different with queries, query=%d, response=%d",
+				len(ownedQueries), len(resp.Data)), uint32(priceSyncPriceCalculationPb.Constant_ERROR_HTTP_API))
+		}
+		if err == nil && resp.RetCode != 0 {
+			// the caller handles ret code as without cache, waiting requests get it as an error
+			leaveSlsHiddenFeeCalls(ownedKeys, ownedCalls, nil, cerr.New(fmt.Sprintf("failed to calc hidden fee, code=%d, msg=%s",
+				resp.RetCode, resp.Message), uint32(priceSyncPriceCalculationPb.Constant_CALCULATE_HIDDEN_FEE_ERROR)))
+			return resp, nil
+		}
+		if err != nil {
+			leaveSlsHiddenFeeCalls(ownedKeys, ownedCalls, nil, err)
+			return nil, err
+		}
+
+		toCache := make(map[string][]*model.CalcHiddenFeeResult)
+		for i, key := range ownedKeys {
+			resultByKey[key] = resp.Data[i]
+			if isSlsHiddenFeeResultCacheable(resp.Data[i]) {
+				toCache[key] = resp.Data[i]
+			}
+		}
+		leaveSlsHiddenFeeCalls(ownedKeys, ownedCalls, resp.Data, nil)
+		if len(toCache) > 0 {
+			if err := commonCache.SetSlsHiddenFeeResultsWithLocal(ctx, toCache, localExpire, remoteExpire); err != nil {
+				logging.GetLogger(ctx).Warn(fmt.Sprintf("failed to set sls hidden fee into cache, region=%s, err=%v", region, err))
+			}
+		}
+	}
+
+	for key, call := range waitingCalls {
+		reportSlsHiddenFeeCache(region, slsHiddenFeeCacheInflight)
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, cerr.New(fmt.Sprintf("context done while waiting for sls hidden fee, query=%v, err=%v",
+				cutil.JSONEncode(queryByKey[key]), ctx.Err()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_HTTP_API))
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		resultByKey[key] = call.results
+	}
+
+	resp := &model.BatchCalcHiddenFeeResponse{
+		Data: make([][]*model.CalcHiddenFeeResult, len(req.List)),
+	}
+	for key, indexes := range indexesByKey {
+		for _, i := range indexes {
+			resp.Data[i] = resultByKey[key]
+		}
+	}
+	return resp, nil
+}
+
+// joinSlsHiddenFeeCalls registers calls of keys which are not being sent, the other keys wait for the existing calls
+func joinSlsHiddenFeeCalls(keys []string) (ownedKeys []string, ownedCalls []*slsHiddenFeeCall, waitingCalls map[string]*slsHiddenFeeCall) {
+	waitingCalls = make(map[string]*slsHiddenFeeCall)
+	slsHiddenFeeInflight.Lock()
+	defer slsHiddenFeeInflight.Unlock()
+	for _, key := range keys {
+		if call, ok := slsHiddenFeeInflight.calls[key]; ok {
+			waitingCalls[key] = call
+			continue
+		}
+		call := &slsHiddenFeeCall{done: make(chan struct{})}
+		slsHiddenFeeInflight.calls[key] = call
+		ownedKeys = append(ownedKeys, key)
+		ownedCalls = append(ownedCalls, call)
+	}
+	return ownedKeys, ownedCalls, waitingCalls
+}
+
+// leaveSlsHiddenFeeCalls publishes results or err of owned calls to waiting requests
+func leaveSlsHiddenFeeCalls(keys []string, calls []*slsHiddenFeeCall, results [][]*model.CalcHiddenFeeResult, err error) {
+	slsHiddenFeeInflight.Lock()
+	defer slsHiddenFeeInflight.Unlock()
+	for i, call := range calls {
+		if err != nil {
+			call.err = err
+		} else {
+			call.results = results[i]
+		}
+		delete(slsHiddenFeeInflight.calls, keys[i])
+		close(call.done)
+	}
+}
+
+// normalizeCalcHiddenFee copies query for the cache key with sorted channels. sku weights are rounded up to
+// weightBucket gram if it is configured, which is an approximation: queries in the same bucket share the fees of the
+// weight which is sent to SLS first
+func normalizeCalcHiddenFee(query *model.CalcHiddenFee, weightBucket float64) *model.CalcHiddenFee {
+	normalized := *query
+	normalized.ProductIDs = append([]int64(nil), query.ProductIDs...)
+	sort.Slice(normalized.ProductIDs, func(i, j int) bool {
+		return normalized.ProductIDs[i] < normalized.ProductIDs[j]
+	})
+	normalized.SkuInfos = make([]*model.SkuInfo, 0, len(query.SkuInfos))
+	for _, sku := range query.SkuInfos {
+		normalizedSku := *sku
+		if weightBucket > 0 {
+			normalizedSku.Weight = math.Ceil(sku.Weight/weightBucket) * weightBucket
+		}
+		normalized.SkuInfos = append(normalized.SkuInfos, &normalizedSku)
+	}
+	return &normalized
+}
+
+func slsHiddenFeeCacheKey(region string, query *model.CalcHiddenFee) string {
+	sum := md5.Sum([]byte(cutil.JSONEncode(query)))
+	return fmt.Sprintf("%s:%s:%s", slsHiddenFeeCacheKeyPrefix, strings.ToUpper(region), hex.EncodeToString(sum[:]))
+}
+
+func isSlsHiddenFeeResultCacheable(results []*model.CalcHiddenFeeResult) bool {
+	for _, result := range results {
+		if result == nil || result.RetCode != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func reportSlsHiddenFeeCache(region string, result string) {
+	_ = metric.RPCCustomReporter.ReportCounter(slsHiddenFeeCacheMetricName, map[string]string{
+		"region": strings.ToUpper(region),
+		"result": result,
+	}, 1)
+}
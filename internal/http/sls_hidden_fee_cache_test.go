@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/cache"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+)
+
+type fakeCommonCache struct {
+	cache.CommonCache
+	mu      sync.Mutex
+	results map[string][]*model.CalcHiddenFeeResult
+}
+
+func (f *fakeCommonCache) GetSlsHiddenFeeResultsWithLocal(ctx context.Context, keys []string, localExpire time.Duration) ([][]*model.CalcHiddenFeeResult, []bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	vals := make([][]*model.CalcHiddenFeeResult, len(keys))
+	founds := make([]bool, len(keys))
+	for i, key := range keys {
+		vals[i], founds[i] = f.results[key]
+	}
+	return vals, founds, nil
+}
+
+func (f *fakeCommonCache) SetSlsHiddenFeeResultsWithLocal(ctx context.Context, kvs map[string][]*model.CalcHiddenFeeResult, localExpire time.Duration, remoteExpire time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, val := range kvs {
+		f.results[key] = val
+	}
+	return nil
+}
+
+func newCalcHiddenFee(itemId uint64, weight float64, channelIds ...int64) *model.CalcHiddenFee {
+	return &model.CalcHiddenFee{
+		ProductIDs: channelIds,
+		Region:     "SG",
+		ShopID:     1,
+		SkuInfos:   []*model.SkuInfo{{ItemID: itemId, CategoryID: 100, Weight: weight, Quantity: 1}},
+		Direction:  1,
+	}
+}
+
+func TestSlsHiddenFeeCacheKey(t *testing.T) {
+	query := newCalcHiddenFee(10, 101.5, 3, 1)
+	normalized := normalizeCalcHiddenFee(query, 10)
+	assert.Equal(t, []int64{1, 3}, normalized.ProductIDs)
+	assert.Equal(t, float64(110), normalized.SkuInfos[0].Weight)
+	assert.Equal(t, uint64(10), normalized.SkuInfos[0].ItemID)
+	// query of caller is not changed
+	assert.Equal(t, []int64{3, 1}, query.ProductIDs)
+	assert.Equal(t, 101.5, query.SkuInfos[0].Weight)
+
+	key := slsHiddenFeeCacheKey("sg", normalized)
+	assert.Equal(t, key, slsHiddenFeeCacheKey("SG", normalizeCalcHiddenFee(newCalcHiddenFee(10, 109, 1, 3), 10)))
+	assert.NotEqual(t, key, slsHiddenFeeCacheKey("SG", normalizeCalcHiddenFee(newCalcHiddenFee(20, 109, 1, 3), 10)))
+	assert.NotEqual(t, key, slsHiddenFeeCacheKey("SG", normalizeCalcHiddenFee(newCalcHiddenFee(10, 111, 1, 3), 10)))
+	assert.NotEqual(t, key, slsHiddenFeeCacheKey("SG", normalizeCalcHiddenFee(newCalcHiddenFee(10, 101.5, 1), 10)))
+	assert.NotEqual(t, slsHiddenFeeCacheKey("SG", normalizeCalcHiddenFee(newCalcHiddenFee(10, 101.5, 1), 0)),
+		slsHiddenFeeCacheKey("SG", normalizeCalcHiddenFee(newCalcHiddenFee(10, 101.6, 1), 0)))
+}
+
+func TestBatchCalcHiddenFeeWithCache(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{CommonCfg: &config.CommonConfig{
+		SlsHiddenFeeCache: &config.SlsHiddenFeeCacheConfig{Enable: true, WeightBucketGram: map[string]float64{"SG": 10}},
+	}})
+	commonCache := &fakeCommonCache{results: map[string][]*model.CalcHiddenFeeResult{}}
+
+	// the hidden fee of SLS is the weight sent
+	var calls int32
+	calcHiddenFee := func(req *model.BatchCalcHiddenFeeRequest) (*model.BatchCalcHiddenFeeResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		resp := &model.BatchCalcHiddenFeeResponse{}
+		for _, query := range req.List {
+			resp.Data = append(resp.Data, []*model.CalcHiddenFeeResult{{ProductID: 1, HiddenFee: query.SkuInfos[0].Weight}})
+		}
+		return resp, nil
+	}
+	calc := func(query *model.CalcHiddenFee) float64 {
+		resp, err := batchCalcHiddenFeeWithCache(ctx, commonCache, "SG", &model.BatchCalcHiddenFeeRequest{List: []*model.CalcHiddenFee{query}}, calcHiddenFee)
+		assert.NoError(t, err)
+		return resp.Data[0][0].HiddenFee
+	}
+
+	// SLS is called with the actual weight, the same bucket of the same item hits the cache
+	assert.Equal(t, 101.5, calc(newCalcHiddenFee(10, 101.5, 1)))
+	assert.Equal(t, 101.5, calc(newCalcHiddenFee(10, 109, 1)))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, float64(109), calc(newCalcHiddenFee(20, 109, 1)))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// concurrent requests of the same query share one SLS call
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blockingCalcHiddenFee := func(req *model.BatchCalcHiddenFeeRequest) (*model.BatchCalcHiddenFeeResponse, error) {
+		close(started)
+		<-release
+		return calcHiddenFee(req)
+	}
+	var wg sync.WaitGroup
+	fees := make([]float64, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		resp, err := batchCalcHiddenFeeWithCache(ctx, commonCache, "SG", &model.BatchCalcHiddenFeeRequest{List: []*model.CalcHiddenFee{newCalcHiddenFee(10, 201, 1)}},
+			blockingCalcHiddenFee)
+		assert.NoError(t, err)
+		fees[0] = resp.Data[0][0].HiddenFee
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		fees[1] = calc(newCalcHiddenFee(10, 201, 1))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []float64{201, 201}, fees)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
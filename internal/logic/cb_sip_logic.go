@@ -4,13 +4,23 @@ import (
 	"context"
 
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 )
 
 type CbSipLogic interface {
 	CalculateSipItemPriceForCbSip(ctx context.Context, req model.CbSipCalculateSipItemPriceRequest) ([]model.CbSipCalculateSipItemPriceResult, error)
 	CalculateAPriceByPItemForCbSip(ctx context.Context, request model.CbSipCalculateAPriceByPItemRequest) ([]model.CbSipCalculateAPriceByPItemResult, error)
+	SimulateAPriceByPItemForCbSip(ctx context.Context, request model.CbSipCalculateAPriceByPItemRequest, overrides *model.PriceFactorOverrides) ([]model.CbSipCalculateAPriceByPItemResult, []model.CbSipCalculateAPriceByPItemResult, error)
+	ReplayAPriceByFactorSnapForCbSip(ctx context.Context, aRegion string, snap *pb.CbSipPriceFactorSnap, queries []model.AItemCbSipQueryId) ([]model.CbSipCalculateAPriceByPItemResult, error)
+	CalculatePPriceByAPriceForCbSip(ctx context.Context, request model.CbSipCalculatePPriceByAPriceRequest) ([]model.CbSipCalculatePPriceByAPriceResult, error)
 	CalculateAItemOPL(ctx context.Context, request *model.CbSipCalculateAOPLByPItemRequest) (*model.CbSipCalculateAOPLByPItemResult, error)
 	GetCbSipAHiddenFeeConfig(ctx context.Context, req model.CbSipGetAHiddenPriceConfigRequest) (*model.CbSipGetAHiddenPriceConfigResult, error)
+	ResolveHiddenFeeConfigForCbSip(ctx context.Context, request model.CbSipResolveHiddenFeeConfigRequest) (*model.CbSipResolveHiddenFeeConfigResult, error)
+	PreviewHiddenFeeRateTableForCbSip(ctx context.Context, request model.CbSipPreviewHiddenFeeRateTableRequest) ([]model.CbSipHiddenFeePreviewPoint, error)
+	ValidateHiddenFeeRateTableForCbSip(ctx context.Context, request model.CbSipValidateRateTableRequest) ([]*model.RateTableViolation, error)
+	CreateHpfnRateTableVersion(ctx context.Context, request model.CreateHpfnRateTableVersionRequest) (*model.HpfnRateTableVersion, error)
+	RollbackHpfnRateTableVersion(ctx context.Context, request model.RollbackHpfnRateTableVersionRequest) (*model.HpfnRateTableVersion, error)
+	ListHpfnRateTableVersions(ctx context.Context, target pb.Constant_RateTableVersionTarget, targetKey string) ([]*model.HpfnRateTableVersion, error)
 	GetCbSipRateConfig(ctx context.Context, infoType uint32) (model.CbSipRateConfigResult, error)
 	GetCbSipShopLevelConfig(ctx context.Context, req model.CbSipGetShopLevelConfigRequest) (model.CbSipGetShopLevelConfigResult, error)
 	GetCbSipRegionLevelConfig(ctx context.Context, req model.CbSipGetRegionLevelConfigRequest) (model.CbSipGetRegionLevelConfigResult, error)
@@ -2,8 +2,8 @@ package cb_sip_logic
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/golang/protobuf/proto"
 
@@ -12,7 +12,6 @@ import (
 	"git.garena.com/shopee/core-server/core-logic/clog"
 	"git.garena.com/shopee/core-server/core-logic/cutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
-	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/constant"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/dm/calculate"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	ib "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/item_business.pb"
@@ -20,12 +19,170 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/factors"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_v2_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/service"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/cidutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logicutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
 func (c *CbSipLogicImpl) CalculateAPriceByPItemForCbSip(ctx context.Context, request model.CbSipCalculateAPriceByPItemRequest) ([]model.CbSipCalculateAPriceByPItemResult, error) {
+	priceFactors, err := c.getCbSipPriceFactors(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if priceFactors == nil {
+		return nil, nil
+	}
+
+	versions, candidateVersions, hasShadow := resolveCbSipFormulaVersions(request)
+
+	results, err := calcCbSipAPriceByFactors(ctx, versions, true, request.ARegion, request.Queries, priceFactors)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasShadow {
+		logicutil.RunFormulaShadow(ctx, logicutil.FormulaCbSip, func(ctx context.Context) []logicutil.FormulaShadowResult {
+			candidateResults, err := calcCbSipAPriceByFactors(ctx, candidateVersions, false, request.ARegion, request.Queries, priceFactors)
+			return compareCbSipAPriceResults(request.ARegion, results, candidateVersions, candidateResults, err)
+		})
+	}
+	return results, nil
+}
+
+// resolveCbSipFormulaVersions A shop decides the formula version, which is same for all queries
+func resolveCbSipFormulaVersions(request model.CbSipCalculateAPriceByPItemRequest) (versions []string, candidateVersions []string, hasShadow bool) {
+	targets := make([]logicutil.FormulaVersionTarget, len(request.Queries))
+	for i := range request.Queries {
+		targets[i] = logicutil.FormulaVersionTarget{Region: request.ARegion, ShopId: request.AShopId}
+	}
+	return logicutil.ResolveFormulaVersions(logicutil.FormulaCbSip, targets)
+}
+
+type cbSipAPriceFormula func(ctx context.Context, aRegion string, query model.AItemCbSipQueryId, priceFactors *model.CbSipPriceFactors) (model.CbSipCalculateAPriceByPItemResult, error)
+
+// cbSipAPriceFormulas CB SIP A price formula of each version, a new version is added here and rolled out by FormulaVersionConfig
+var cbSipAPriceFormulas = map[string]cbSipAPriceFormula{
+	logicutil.FormulaVersionV1: calcCbSipAPriceV1,
+}
+
+func init() {
+	for version := range cbSipAPriceFormulas {
+		config.RegisterFormulaVersion(logicutil.FormulaCbSip, version)
+	}
+}
+
+// calcCbSipAPriceByFactors calculates A prices of all queries with the given factors and formula version of each query, no IO involved.
+// guardrail is skipped for shadow calculation, so that outliers of candidate version are not reported.
+// prices rejected by guardrail are kept in result for comparison, but not returned to caller
+func calcCbSipAPriceByFactors(ctx context.Context, versions []string, checkGuardrail bool, aRegion string, queries []model.AItemCbSipQueryId, priceFactors *model.CbSipPriceFactors) ([]model.CbSipCalculateAPriceByPItemResult, error) {
+	res := make([]model.CbSipCalculateAPriceByPItemResult, len(queries))
+	for i, query := range queries {
+		formula, ok := cbSipAPriceFormulas[versions[i]]
+		if !ok {
+			res[i] = model.CbSipCalculateAPriceByPItemResult{
+				Err:            cerr.New(fmt.Sprintf("cb sip formula version=%v is not supported", versions[i]), uint32(pb.Constant_ERROR_INTERNAL)),
+				FormulaVersion: versions[i],
+			}
+			continue
+		}
+
+		result, err := formula(ctx, aRegion, query, priceFactors)
+		if err != nil {
+			return nil, err
+		}
+		result.FormulaVersion = versions[i]
+		if result.Snap != nil {
+			result.Snap.FormulaVersion = proto.String(versions[i])
+		}
+
+		if checkGuardrail && result.Err == nil {
+			result.Guardrail = checkCbSipPriceGuardrail(ctx, aRegion, query, priceFactors, result)
+			if result.Guardrail.IsRejected() {
+				result.Err = calcutil.NewPriceGuardrailRejectedError(aRegion, result.Guardrail)
+			}
+		}
+		res[i] = result
+	}
+
+	return res, nil
+}
+
+// compareCbSipAPriceResults compares the results of live version and candidate version query by query
+func compareCbSipAPriceResults(aRegion string, results []model.CbSipCalculateAPriceByPItemResult, candidateVersions []string, candidateResults []model.CbSipCalculateAPriceByPItemResult, candidateErr error) []logicutil.FormulaShadowResult {
+	shadowResults := make([]logicutil.FormulaShadowResult, 0, len(results))
+	for i, result := range results {
+		shadowResult := logicutil.FormulaShadowResult{Region: aRegion, LiveVersion: result.FormulaVersion, CandidateVersion: candidateVersions[i]}
+		if candidateErr != nil {
+			shadowResult.Diff = fmt.Sprintf("candidate error: %v", candidateErr)
+			shadowResults = append(shadowResults, shadowResult)
+			continue
+		}
+		candidate := candidateResults[i]
+		switch {
+		case (result.Err == nil || result.Guardrail.IsRejected()) != (candidate.Err == nil):
+			shadowResult.Diff = fmt.Sprintf("err: %v -> %v", result.Err, candidate.Err)
+		case result.ANormalPrice != candidate.ANormalPrice || result.APromotionPrice != candidate.APromotionPrice || result.ASettlementPrice != candidate.ASettlementPrice:
+			shadowResult.Diff = fmt.Sprintf("normal: %v -> %v, promotion: %v -> %v, settlement: %v -> %v",
+				result.ANormalPrice, candidate.ANormalPrice, result.APromotionPrice, candidate.APromotionPrice, result.ASettlementPrice, candidate.ASettlementPrice)
+		}
+		shadowResults = append(shadowResults, shadowResult)
+	}
+	return shadowResults
+}
+
+// cbSipPromotionRatio ratio of P normal price to P promotion price applied on A normal price, 1 if no promotion
+func cbSipPromotionRatio(aRegion string, query model.AItemCbSipQueryId) float64 {
+	return pricekernel.CbSipPromotionRatio(query.PNormalPrice, query.PPromotionPrice, calcutil.CbSipPromotionRatioLimit(aRegion))
+}
+
+// checkCbSipPriceGuardrail A prices are checked against P item price converted to A currency, the promotion ratio is applied on normal price
+func checkCbSipPriceGuardrail(ctx context.Context, aRegion string, query model.AItemCbSipQueryId, priceFactors *model.CbSipPriceFactors, result model.CbSipCalculateAPriceByPItemResult) model.PriceGuardrailResult {
+	convertedPrice := calcutil.ToRealPrice(query.PItemPrice) * priceFactors.PriceRatio * priceFactors.ExchangeRate
+	guardrail := calcutil.CheckPriceGuardrail(ctx, aRegion, model.PriceNameNormal, convertedPrice*cbSipPromotionRatio(aRegion, query), calcutil.ToRealPrice(result.ANormalPrice))
+	if result.APromotionPrice >= 0 {
+		guardrail = guardrail.Merge(calcutil.CheckPriceGuardrail(ctx, aRegion, model.PriceNamePromotion, convertedPrice, calcutil.ToRealPrice(result.APromotionPrice)))
+	}
+	return guardrail
+}
+
+// calcCbSipAPriceV1 CB SIP A price formula v1
+func calcCbSipAPriceV1(ctx context.Context, aRegion string, query model.AItemCbSipQueryId, priceFactors *model.CbSipPriceFactors) (model.CbSipCalculateAPriceByPItemResult, error) {
+	prices, err := pricekernel.CalcCbSipAPrice(pricekernel.CbSipInput{
+		PItemPrice:      query.PItemPrice,
+		PNormalPrice:    query.PNormalPrice,
+		PPromotionPrice: query.PPromotionPrice,
+	}, calcutil.NewCbSipKernelFactors(aRegion, priceFactors))
+	if errors.Is(err, pricekernel.ErrInvalidSourcePrice) {
+		return model.CbSipCalculateAPriceByPItemResult{}, cerr.New(fmt.Sprintf("invalid p_item_price=%v", query.PItemPrice), uint32(pb.Constant_ERROR_PARAMS))
+	}
+
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] Calc price for CBSIP, "+
+		"query=%v, basePrice=%v, ratio=%v, aRegion=%v, exchangeRate=%v, priceRatio=%v, countryMargin=%v, shopMargin=%v, itemMargin=%v, aHiddenPrice=%v, serviceFee=%v, commissionFee=%v, handlingFee=%v "+
+		"| result: affiNormalPriceDB=%v, affiPromotionPriceDB=%v, affiSettlementPriceDB=%v",
+		cutil.JSONEncode(query), query.PItemPrice, prices.PromotionRatio, aRegion, priceFactors.ExchangeRate, priceFactors.PriceRatio, priceFactors.CountryMargin, priceFactors.ShopMargin, priceFactors.ItemMargin, priceFactors.HiddenPrice, priceFactors.ServiceFee, priceFactors.CommissionFee, priceFactors.HandlingFee,
+		prices.NormalPrice, prices.PromotionPrice, prices.SettlementPrice))
+
+	if err != nil {
+		return model.CbSipCalculateAPriceByPItemResult{}, cerr.New(fmt.Sprintf("a settlement price is invalid, query=%v, price=%v",
+			cutil.JSONEncode(query), prices.SettlementPrice), uint32(pb.Constant_ERROR_INTERNAL))
+	}
+
+	return model.CbSipCalculateAPriceByPItemResult{
+		ANormalPrice:             prices.NormalPrice,
+		APromotionPrice:          prices.PromotionPrice,
+		ASettlementPrice:         prices.SettlementPrice,
+		ASettlementPriceCurrency: priceFactors.SrcCurrency,
+		Snap:                     buildCbSipPriceFactorSnap(priceFactors),
+		Traces:                   prices.Traces,
+		RoundingStrategy:         prices.Traces[0].RoundingRule,
+	}, nil
+}
+
+// getCbSipPriceFactors gathers all factors of CB SIP A price formula, returns nil if P shop is not sip primary shop
+func (c *CbSipLogicImpl) getCbSipPriceFactors(ctx context.Context, request model.CbSipCalculateAPriceByPItemRequest) (*model.CbSipPriceFactors, error) {
 	pItemData, err := c.getPItemInfo(ctx, request.PShopId, request.PItemId)
 	if err != nil {
 		return nil, err
@@ -46,7 +203,13 @@ func (c *CbSipLogicImpl) CalculateAPriceByPItemForCbSip(ctx context.Context, req
 		pItemWeight = pItemData.Weight
 	}
 
-	weight := calcutil.DbWeightToGram(factors.GetCalcWeightByAItemAndPItemWeight(itemRealWeight, pItemWeight))
+	pProductInfo, err := c.getItemProductInfo(ctx, request.PShopId, request.PItemId, request.PRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeableWeight := factors.GetChargeableWeight(itemRealWeight, pItemWeight, service.GetItemDimension(pProductInfo.GetLogistics()), request.ARegion)
+	weight := calcutil.DbWeightToGram(chargeableWeight.Weight)
 
 	isSipPShop, err := c.isSipPShop(ctx, request.PShopId)
 	if err != nil {
@@ -62,7 +225,7 @@ func (c *CbSipLogicImpl) CalculateAPriceByPItemForCbSip(ctx context.Context, req
 		return nil, err
 	}
 
-	aHiddenPrice := c.factorsRepo.GetHiddenPriceForCbSip(ctx, pItemData, pShopData, request.MerchantRegion, request.PRegion, request.ARegion, weight, false)
+	aHiddenPrice, hiddenPriceConf := c.factorsRepo.GetHiddenPriceForCbSip(ctx, pItemData, pShopData, request.MerchantRegion, request.PRegion, request.ARegion, weight, false)
 
 	serviceFee, err := c.factorsRepo.GetShopServiceFeeForCbSip(ctx, request.ARegion, int64(request.AShopId))
 	if err != nil {
@@ -84,17 +247,12 @@ func (c *CbSipLogicImpl) CalculateAPriceByPItemForCbSip(ctx context.Context, req
 		return nil, cerr.New(fmt.Sprintf("sync price biz exception: serviceFee %v + commissionFee %v + handlingFee %v <= 0", serviceFee, commissionFee, handlingFee), uint32(pb.Constant_ERROR_INTERNAL))
 	}
 
-	pProductInfo, err := c.getItemProductInfo(ctx, request.PShopId, request.PItemId, request.PRegion)
-	if err != nil {
-		return nil, err
-	}
-
 	srcCurrency, dstCurrency, err := c.factorsRepo.GetCurrencyForCbSip(ctx, request.MerchantId, request.ARegion, pProductInfo)
 	if err != nil {
 		return nil, err
 	}
 
-	exchangeRate, err := c.factorsRepo.GetExchangeRateForCbSip(ctx, srcCurrency, dstCurrency, false)
+	exchangeRate, exchangeRatePath, err := c.factorsRepo.GetExchangeRateForCbSip(ctx, srcCurrency, dstCurrency, false)
 	if err != nil {
 		return nil, err
 	}
@@ -122,64 +280,72 @@ func (c *CbSipLogicImpl) CalculateAPriceByPItemForCbSip(ctx context.Context, req
 
 	priceRatio := calcutil.ToRealPect(int(aShopData.GetPriceRatio()))
 
-	res := make([]model.CbSipCalculateAPriceByPItemResult, len(request.Queries))
-	for i, query := range request.Queries {
-		var affiNormalPriceDB int64
-		var affiSettlementPriceDB int64
-		ratio := 1.0
-		aPromotionPrice := int64(-1)
-		pNormalPriceReal := calcutil.ToRealPrice(query.PNormalPrice)
-
-		basePrice := query.PItemPrice
-
-		if basePrice <= 0 {
-			return nil, cerr.New(fmt.Sprintf("invalid p_item_price=%v", query.PItemPrice), uint32(pb.Constant_ERROR_PARAMS))
-		}
-		if query.PPromotionPrice != nil && *query.PPromotionPrice > 0 {
-			pPromotionPriceReal := calcutil.ToRealPrice(*query.PPromotionPrice)
-			ratio = pNormalPriceReal / pPromotionPriceReal
-			if strings.ToUpper(request.ARegion) == "VN" && ratio > constant.VnPromoRatioLimit {
-				ratio = constant.VnPromoRatioLimit
-			}
-			aPromotionPrice = calcutil.CalcAffiDBPriceForCbSip(basePrice, request.ARegion, exchangeRate, priceRatio, 1.0, countryMargin, float64(shopMargin), float64(itemMargin), aHiddenPrice, finalFee)
-		}
-
-		affiNormalPriceDB = calcutil.CalcAffiDBPriceForCbSip(basePrice, request.ARegion, exchangeRate, priceRatio, ratio, countryMargin, float64(shopMargin), float64(itemMargin), aHiddenPrice, finalFee)
-		affiSettlementPriceDB = calcutil.DBPriceRoundNearest(srcCurrency, int64(float64(basePrice)*priceRatio)) // basePrice is db price value alr
-
-		logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] Calc price for CBSIP, "+
-			"query=%v, basePrice=%v, ratio=%v, aRegion=%v, exchangeRate=%v, priceRatio=%v, countryMargin=%v, shopMargin=%v, itemMargin=%v, aHiddenPrice=%v, serviceFee=%v, commissionFee=%v, handlingFee=%v "+
-			"| result: affiNormalPriceDB=%v, affiPromotionPriceDB=%v, affiSettlementPriceDB=%v",
-			cutil.JSONEncode(query), basePrice, ratio, request.ARegion, exchangeRate, priceRatio, countryMargin, shopMargin, itemMargin, aHiddenPrice, serviceFee, commissionFee, handlingFee,
-			affiNormalPriceDB, aPromotionPrice, affiSettlementPriceDB))
-
-		if affiSettlementPriceDB < 0 {
-			return nil, cerr.New(fmt.Sprintf("a settlement price is invalid, query=%v, price=%v",
-				cutil.JSONEncode(query), affiSettlementPriceDB), uint32(pb.Constant_ERROR_INTERNAL))
-		}
+	return &model.CbSipPriceFactors{
+		Weight:        weight,
+		CountryMargin: countryMargin,
+		ShopMargin:    float64(shopMargin),
+		ItemMargin:    float64(itemMargin),
+		ExchangeRate:  exchangeRate,
+		PriceRatio:    priceRatio,
+		HiddenPrice:   aHiddenPrice,
+		SrcCurrency:   srcCurrency,
+		ServiceFee:    serviceFee,
+		CommissionFee: commissionFee,
+		HandlingFee:   handlingFee,
+		FinalFee:      finalFee,
+
+		HiddenFeeConfig: buildHiddenFeeConfigInfo(hiddenPriceConf),
+
+		WeightSource:     chargeableWeight.Source,
+		VolumetricWeight: calcutil.DbWeightToGram(chargeableWeight.VolumetricWeight),
+
+		ExchangeRatePath: exchangeRatePath,
+	}, nil
+}
 
-		res[i] = model.CbSipCalculateAPriceByPItemResult{
-			ANormalPrice:             affiNormalPriceDB,
-			APromotionPrice:          aPromotionPrice,
-			ASettlementPrice:         affiSettlementPriceDB,
-			ASettlementPriceCurrency: srcCurrency,
-			Snap: &pb.CbSipPriceFactorSnap{
-				Weight:          proto.Float64(weight),
-				CountryMargin:   proto.Float64(countryMargin),
-				ShopMargin:      proto.Float64(float64(shopMargin)),
-				ItemMargin:      proto.Float64(float64(itemMargin)),
-				ExchangeRate:    proto.Float64(exchangeRate),
-				PriceRatio:      proto.Float64(priceRatio),
-				AffiHiddenPrice: proto.Float64(aHiddenPrice),
-				SrcCurrency:     proto.String(srcCurrency),
-				ServiceFee:      proto.Float64(serviceFee),
-				CommissionFee:   proto.Float64(commissionFee),
-				HandlingFee:     proto.Float64(handlingFee),
-			},
-		}
+func buildCbSipPriceFactorSnap(priceFactors *model.CbSipPriceFactors) *pb.CbSipPriceFactorSnap {
+	return &pb.CbSipPriceFactorSnap{
+		Weight:          proto.Float64(priceFactors.Weight),
+		CountryMargin:   proto.Float64(priceFactors.CountryMargin),
+		ShopMargin:      proto.Float64(priceFactors.ShopMargin),
+		ItemMargin:      proto.Float64(priceFactors.ItemMargin),
+		ExchangeRate:    proto.Float64(priceFactors.ExchangeRate),
+		PriceRatio:      proto.Float64(priceFactors.PriceRatio),
+		AffiHiddenPrice: proto.Float64(priceFactors.HiddenPrice),
+		SrcCurrency:     proto.String(priceFactors.SrcCurrency),
+		ServiceFee:      proto.Float64(priceFactors.ServiceFee),
+		CommissionFee:   proto.Float64(priceFactors.CommissionFee),
+		HandlingFee:     proto.Float64(priceFactors.HandlingFee),
+		HiddenFeeConfig: priceFactors.HiddenFeeConfig,
+
+		WeightSource:     proto.Uint32(uint32(priceFactors.WeightSource)),
+		VolumetricWeight: proto.Float64(priceFactors.VolumetricWeight),
+
+		ExchangeRatePath: priceFactors.ExchangeRatePath,
 	}
+}
 
-	return res, nil
+// buildHiddenFeeConfigInfo level NONE if no rate table matched
+func buildHiddenFeeConfigInfo(conf *model.HiddenPriceConf) *pb.HiddenFeeConfigInfo {
+	if conf == nil {
+		return &pb.HiddenFeeConfigInfo{Level: proto.Uint32(uint32(pb.Constant_HIDDEN_FEE_CONFIG_LEVEL_NONE))}
+	}
+	return &pb.HiddenFeeConfigInfo{
+		Level:          proto.Uint32(uint32(conf.HpfnCfgLevel)),
+		HpfnKey:        proto.String(conf.HpfnKey),
+		WeightRange:    proto.Int64(conf.WeightRange),
+		RateTableRowId: proto.Int64(conf.RateTableRowId),
+		RateTableRow: &pb.AHiddenFeeRuleRow{
+			WeightRange: proto.Int64(conf.WeightRange),
+			StartPrice:  proto.Int64(conf.StartPrice),
+			StartWeight: proto.Int64(conf.StartWeight),
+			RoundSize:   proto.Int64(conf.RoundSize),
+			Price:       proto.Int64(conf.Price),
+			WeightStep:  proto.Int64(conf.WeightStep),
+			Adjustment:  proto.Int64(conf.Adjustment),
+			DescInfo:    proto.String(conf.DescInfo),
+		},
+	}
 }
 
 func (c *CbSipLogicImpl) CalculateAItemOPL(ctx context.Context, request *model.CbSipCalculateAOPLByPItemRequest) (*model.CbSipCalculateAOPLByPItemResult, error) {
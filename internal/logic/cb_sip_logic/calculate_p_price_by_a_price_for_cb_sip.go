@@ -0,0 +1,65 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"fmt"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// CalculatePPriceByAPriceForCbSip calculates the P item price required to get the target A normal price,
+// with the same factors used by CalculateAPriceByPItemForCbSip
+func (c *CbSipLogicImpl) CalculatePPriceByAPriceForCbSip(ctx context.Context, request model.CbSipCalculatePPriceByAPriceRequest) ([]model.CbSipCalculatePPriceByAPriceResult, error) {
+	priceFactors, err := c.getCbSipPriceFactors(ctx, model.CbSipCalculateAPriceByPItemRequest{
+		MerchantId:         request.MerchantId,
+		MerchantRegion:     request.MerchantRegion,
+		PShopId:            request.PShopId,
+		PRegion:            request.PRegion,
+		PItemId:            request.PItemId,
+		AShopId:            request.AShopId,
+		ARegion:            request.ARegion,
+		AItemId:            request.AItemId,
+		CalculateForCreate: request.CalculateForCreate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if priceFactors == nil {
+		return nil, nil
+	}
+
+	snap := buildCbSipPriceFactorSnap(priceFactors)
+	res := make([]model.CbSipCalculatePPriceByAPriceResult, len(request.Queries))
+	for i, query := range request.Queries {
+		pItemPrice, aNormalPrice, ok := calcutil.CalcPItemDBPriceForCbSip(query.TargetANormalPrice, request.ARegion, priceFactors.SrcCurrency,
+			priceFactors.ExchangeRate, priceFactors.PriceRatio, priceFactors.CountryMargin, priceFactors.ShopMargin, priceFactors.ItemMargin,
+			priceFactors.HiddenPrice, priceFactors.FinalFee)
+
+		logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] Calc P price by A price for CBSIP, "+
+			"query=%+v, aRegion=%v, priceFactors=%+v | result: pItemPrice=%v, aNormalPrice=%v, ok=%v",
+			query, request.ARegion, priceFactors, pItemPrice, aNormalPrice, ok))
+
+		if !ok {
+			res[i] = model.CbSipCalculatePPriceByAPriceResult{
+				Err: cerr.New(fmt.Sprintf("no positive p item price can reach target a normal price=%v", query.TargetANormalPrice),
+					uint32(pb.Constant_ERROR_TARGET_PRICE_UNREACHABLE)),
+				Snap: snap,
+			}
+			continue
+		}
+
+		res[i] = model.CbSipCalculatePPriceByAPriceResult{
+			PItemPrice:         pItemPrice,
+			PItemPriceCurrency: priceFactors.SrcCurrency,
+			ANormalPrice:       aNormalPrice,
+			IsExactMatch:       aNormalPrice == query.TargetANormalPrice,
+			Snap:               snap,
+		}
+	}
+
+	return res, nil
+}
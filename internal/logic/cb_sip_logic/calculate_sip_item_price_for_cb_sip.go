@@ -14,6 +14,8 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logicutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
 func (c *CbSipLogicImpl) CalculateSipItemPriceForCbSip(ctx context.Context, req model.CbSipCalculateSipItemPriceRequest) ([]model.CbSipCalculateSipItemPriceResult, error) {
@@ -52,6 +54,29 @@ func (c *CbSipLogicImpl) CalculateSipItemPriceForCbSip(ctx context.Context, req
 		}
 	}
 
+	priceFactors, err := c.getCbSipItemPriceFactors(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, candidateVersions, hasShadow := logicutil.ResolveFormulaVersions(logicutil.FormulaCbSipItemPrice,
+		[]logicutil.FormulaVersionTarget{{Region: req.Region, ShopId: req.ShopId}})
+	results, err := calcCbSipItemPriceByFactors(ctx, versions[0], req.Queries, priceFactors)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasShadow {
+		logicutil.RunFormulaShadow(ctx, logicutil.FormulaCbSipItemPrice, func(ctx context.Context) []logicutil.FormulaShadowResult {
+			candidateResults, err := calcCbSipItemPriceByFactors(ctx, candidateVersions[0], req.Queries, priceFactors)
+			return compareCbSipItemPriceResults(req.Region, versions[0], candidateVersions[0], results, candidateResults, err)
+		})
+	}
+	return results, nil
+}
+
+// getCbSipItemPriceFactors gathers all factors of CB SIP item price formula, which are same for all models of the item
+func (c *CbSipLogicImpl) getCbSipItemPriceFactors(ctx context.Context, req model.CbSipCalculateSipItemPriceRequest) (*model.CbSipItemPriceFactors, error) {
 	var decimalReferenceServiceFee, decimalTransactionFee, decimalCommissionFee decimal.Decimal
 	var decimalExchangeRate decimal.Decimal
 
@@ -86,52 +111,102 @@ func (c *CbSipLogicImpl) CalculateSipItemPriceForCbSip(ctx context.Context, req
 	if err != nil {
 		return nil, err
 	}
-	rateFloat, err := c.factorsRepo.GetExchangeRateForCbSip(ctx, currency, targetCurrency, true)
+	rateFloat, _, err := c.factorsRepo.GetExchangeRateForCbSip(ctx, currency, targetCurrency, true)
 	if err != nil {
 		return nil, err
 	}
 	decimalExchangeRate = decimal.NewFromFloat(rateFloat) // rateFloat is original exchange_rate
 
-	deductFee := decimal.NewFromInt(1).Sub(decimalReferenceServiceFee).Sub(decimalTransactionFee).Sub(decimalCommissionFee)
-
-	hiddenFee, err := c.logisticService.CalcHiddenFeeForCbSip(ctx, req.ShopId, req.Region, req.ItemId, req.LeafCategoryId, req.Weight, req.ChannelIdList)
+	hiddenFee, channelHiddenFees, err := c.logisticService.CalcHiddenFeeForCbSip(ctx, req.ShopId, req.Region, req.ItemId, req.LeafCategoryId, req.Weight, req.ChannelIdList)
 	if err != nil {
 		return nil, err
 	}
 	hiddenFeeDb := calcutil.RoundFloatToInt(hiddenFee, constant.PricePrecision, 2)
 	decimalHiddenPrice := decimal.NewFromInt(hiddenFeeDb).Div(decimalPricePrecision)
 
-	results := make([]model.CbSipCalculateSipItemPriceResult, len(req.Queries))
-	for i, info := range req.Queries {
-		price := info.Price // already magnify 100000 times
-		decimalPrice := decimal.NewFromInt(price).Div(decimalPricePrecision)
-
-		var decimalItemPrice decimal.Decimal
-		if decimalPrice.Mul(deductFee).Sub(decimalHiddenPrice).IsPositive() {
-			// psku_price*(1 - commission_fee - service_fee - transaction_fee) - hidden_price > 0
-			decimalItemPrice = decimalPrice.Mul(deductFee).Sub(decimalHiddenPrice).DivRound(decimalExchangeRate, 2)
-		} else {
-			decimalItemPrice = decimalPrice.Mul(deductFee).DivRound(decimalExchangeRate, 2)
-		}
-		if decimalItemPrice.Sub(decimal.NewFromFloat(0.01)).IsNegative() {
-			// item_price < 0.01, then item_price=0.01
-			decimalItemPrice = decimal.NewFromFloat(0.01)
-		}
-		magnifyDecimalItemPrice := decimalItemPrice.Mul(decimalPricePrecision)
-		itemPrice := uint64(magnifyDecimalItemPrice.IntPart()) // itemPrice magnify 100000 times
+	return &model.CbSipItemPriceFactors{
+		ReferenceServiceFee: decimalReferenceServiceFee,
+		TransactionFee:      decimalTransactionFee,
+		CommissionFee:       decimalCommissionFee,
+		ExchangeRate:        decimalExchangeRate,
+		HiddenPrice:         decimalHiddenPrice,
+		Currency:            currency,
+		ChannelHiddenFees:   channelHiddenFees,
+	}, nil
+}
 
-		logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] Calc item price for CBSIP, query=%+v, referenceServiceFee=%v, transactionFee=%v, commissionFee=%v, deductFee=%v, hiddenFee=%v, exchangeRate=%v | result=%v", info, decimalReferenceServiceFee, decimalTransactionFee, decimalCommissionFee, deductFee, decimalHiddenPrice, decimalExchangeRate, itemPrice))
+type cbSipItemPriceFormula func(ctx context.Context, query model.CbSipCalculateSipItemPriceSingleQuery, priceFactors *model.CbSipItemPriceFactors) (model.CbSipCalculateSipItemPriceResult, error)
 
-		results[i] = model.CbSipCalculateSipItemPriceResult{
-			ModelId:        info.ModelId,
-			CbSipItemPrice: int64(itemPrice),
-			Currency:       currency,
-		}
+// cbSipItemPriceFormulas CB SIP item price formula of each version, a new version is added here and rolled out by FormulaVersionConfig
+var cbSipItemPriceFormulas = map[string]cbSipItemPriceFormula{
+	logicutil.FormulaVersionV1: calcCbSipItemPriceV1,
+}
+
+func init() {
+	for version := range cbSipItemPriceFormulas {
+		config.RegisterFormulaVersion(logicutil.FormulaCbSipItemPrice, version)
 	}
+}
 
+// calcCbSipItemPriceByFactors calculates item prices of all models with the given factors and formula version, no IO involved
+func calcCbSipItemPriceByFactors(ctx context.Context, version string, queries []model.CbSipCalculateSipItemPriceSingleQuery, priceFactors *model.CbSipItemPriceFactors) ([]model.CbSipCalculateSipItemPriceResult, error) {
+	formula, ok := cbSipItemPriceFormulas[version]
+	if !ok {
+		return nil, cerr.New(fmt.Sprintf("cb sip item price formula version=%v is not supported", version), uint32(pb.Constant_ERROR_INTERNAL))
+	}
+
+	results := make([]model.CbSipCalculateSipItemPriceResult, len(queries))
+	for i, query := range queries {
+		result, err := formula(ctx, query, priceFactors)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+		results[i].FormulaVersion = version
+	}
 	return results, nil
 }
 
+// calcCbSipItemPriceV1 CB SIP item price formula v1
+func calcCbSipItemPriceV1(ctx context.Context, info model.CbSipCalculateSipItemPriceSingleQuery, priceFactors *model.CbSipItemPriceFactors) (model.CbSipCalculateSipItemPriceResult, error) {
+	itemPrice, trace, err := pricekernel.CalcCbSipItemPrice(info.Price, pricekernel.CbSipItemPriceFactors{
+		ReferenceServiceFee: priceFactors.ReferenceServiceFee,
+		TransactionFee:      priceFactors.TransactionFee,
+		CommissionFee:       priceFactors.CommissionFee,
+		ExchangeRate:        priceFactors.ExchangeRate,
+		HiddenPrice:         priceFactors.HiddenPrice,
+	})
+	if err != nil {
+		return model.CbSipCalculateSipItemPriceResult{}, cerr.New(fmt.Sprintf("invalid exchange rate of currency=%v, exchangeRate=%v",
+			priceFactors.Currency, priceFactors.ExchangeRate), uint32(pb.Constant_ERROR_INTERNAL))
+	}
+
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] Calc item price for CBSIP, query=%+v, referenceServiceFee=%v, transactionFee=%v, commissionFee=%v, hiddenFee=%v, exchangeRate=%v | formula=%v, result=%v",
+		info, priceFactors.ReferenceServiceFee, priceFactors.TransactionFee, priceFactors.CommissionFee, priceFactors.HiddenPrice, priceFactors.ExchangeRate, trace.Formula, itemPrice))
+
+	return model.CbSipCalculateSipItemPriceResult{
+		ModelId:           info.ModelId,
+		CbSipItemPrice:    itemPrice,
+		Currency:          priceFactors.Currency,
+		ChannelHiddenFees: priceFactors.ChannelHiddenFees,
+	}, nil
+}
+
+// compareCbSipItemPriceResults compares the results of live version and candidate version model by model
+func compareCbSipItemPriceResults(region string, version string, candidateVersion string, results []model.CbSipCalculateSipItemPriceResult, candidateResults []model.CbSipCalculateSipItemPriceResult, candidateErr error) []logicutil.FormulaShadowResult {
+	shadowResults := make([]logicutil.FormulaShadowResult, 0, len(results))
+	for i, result := range results {
+		shadowResult := logicutil.FormulaShadowResult{Region: region, LiveVersion: version, CandidateVersion: candidateVersion}
+		if candidateErr != nil {
+			shadowResult.Diff = fmt.Sprintf("candidate error: %v", candidateErr)
+		} else if result.CbSipItemPrice != candidateResults[i].CbSipItemPrice {
+			shadowResult.Diff = fmt.Sprintf("modelId=%v, item price: %v -> %v", result.ModelId, result.CbSipItemPrice, candidateResults[i].CbSipItemPrice)
+		}
+		shadowResults = append(shadowResults, shadowResult)
+	}
+	return shadowResults
+}
+
 func (c *CbSipLogicImpl) isShopUserBannedOrDeleted(ctx context.Context, shopId uint64, region string) (bool, error) {
 	shopUserStatusMap, err := c.accountServiceRepo.GetUserStatusMap(ctx, []model.ShopIdRegion{
 		{
@@ -8,6 +8,7 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/factors"
 )
 
 func (c *CbSipLogicImpl) GetCbSipRegionLevelConfig(ctx context.Context, req model.CbSipGetRegionLevelConfigRequest) (model.CbSipGetRegionLevelConfigResult, error) {
@@ -64,10 +65,12 @@ func (c *CbSipLogicImpl) getExchangeRates(ctx context.Context, queries []model.R
 		if exchangeRateMap[srcCurrency] == nil {
 			return nil, cerr.New(fmt.Sprintf("failed to get exchange rate for currency=%v", srcCurrency), uint32(pb.Constant_ERROR_NOT_FOUND))
 		}
+		// empty if the pair has neither direct nor triangulated rate
+		exchangeRate, _, _ := factors.FindExchangeRateInMapForCbSip(exchangeRateMap, srcCurrency, dstCurrency)
 		res = append(res, model.ExchangeRate{
 			SrcCurrency:  srcCurrency,
 			DstCurrency:  dstCurrency,
-			ExchangeRate: exchangeRateMap[srcCurrency][dstCurrency],
+			ExchangeRate: exchangeRate,
 		})
 	}
 	return res, nil
@@ -0,0 +1,178 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config/config_parser"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/hpfn_config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+)
+
+// effective_from within this many seconds in the past is treated as now, to tolerate clock skew of callers
+const rateTableVersionEffectiveFromTolerance = 60
+
+// CreateHpfnRateTableVersion validates and stores a new version of HPFN rate table or rate_table_cfg assignment, it
+// takes effect in calculation when effective_from is reached
+func (c *CbSipLogicImpl) CreateHpfnRateTableVersion(ctx context.Context, request model.CreateHpfnRateTableVersionRequest) (*model.HpfnRateTableVersion, error) {
+	return c.createHpfnRateTableVersion(ctx, request, 0)
+}
+
+// RollbackHpfnRateTableVersion creates a new version with the content of request.VersionId, so the target switches
+// back to it at effective_from. existing versions are never changed
+func (c *CbSipLogicImpl) RollbackHpfnRateTableVersion(ctx context.Context, request model.RollbackHpfnRateTableVersionRequest) (*model.HpfnRateTableVersion, error) {
+	version, err := c.hpfnConfigRepo.GetRateTableVersion(ctx, request.VersionId)
+	if err != nil {
+		return nil, err
+	}
+	return c.createHpfnRateTableVersion(ctx, model.CreateHpfnRateTableVersionRequest{
+		Target:        version.Target,
+		TargetKey:     version.TargetKey,
+		Content:       version.Content,
+		EffectiveFrom: request.EffectiveFrom,
+		Operator:      request.Operator,
+		Remark:        request.Remark,
+	}, version.Id)
+}
+
+func (c *CbSipLogicImpl) ListHpfnRateTableVersions(ctx context.Context, target pb.Constant_RateTableVersionTarget, targetKey string) ([]*model.HpfnRateTableVersion, error) {
+	return c.hpfnConfigRepo.ListRateTableVersions(ctx, target, targetKey)
+}
+
+func (c *CbSipLogicImpl) createHpfnRateTableVersion(ctx context.Context, request model.CreateHpfnRateTableVersionRequest, rollbackFromId uint64) (*model.HpfnRateTableVersion, error) {
+	now := time.Now().Unix()
+	effectiveFrom := request.EffectiveFrom
+	if effectiveFrom == 0 {
+		effectiveFrom = now
+	}
+	if effectiveFrom < now-rateTableVersionEffectiveFromTolerance {
+		return nil, cerr.New(fmt.Sprintf("effective_from=%v is in the past", effectiveFrom), uint32(pb.Constant_ERROR_PARAMS))
+	}
+
+	if err := validateRateTableVersionTargetKey(request.Target, request.TargetKey); err != nil {
+		return nil, err
+	}
+	var err error
+	if request.Target == pb.Constant_RATE_TABLE_VERSION_TARGET_HPFN_RATE_TABLE {
+		err = validateRateTableVersionRows(request.TargetKey, request.Content)
+	} else {
+		err = c.validateRateTableVersionCfg(ctx, request.Content, effectiveFrom)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the existing versions are loaded before creating, so a failure does not leave a created version unreported
+	versions, err := c.hpfnConfigRepo.ListRateTableVersions(ctx, request.Target, request.TargetKey)
+	if err != nil {
+		return nil, err
+	}
+	version := &model.HpfnRateTableVersion{
+		Target:         request.Target,
+		TargetKey:      request.TargetKey,
+		Content:        request.Content,
+		EffectiveFrom:  effectiveFrom,
+		RollbackFromId: rollbackFromId,
+		Operator:       request.Operator,
+		Remark:         request.Remark,
+	}
+	if err := c.hpfnConfigRepo.CreateRateTableVersion(ctx, version); err != nil {
+		return nil, err
+	}
+	// a reached version is superseded if an active one has a later effective_from, the same as ListHpfnRateTableVersions
+	hpfn_config.SetRateTableVersionStatus(append(versions, version), now)
+	return version, nil
+}
+
+func validateRateTableVersionTargetKey(target pb.Constant_RateTableVersionTarget, targetKey string) error {
+	if len(targetKey) == 0 {
+		return cerr.New("target_key is required", uint32(pb.Constant_ERROR_PARAMS))
+	}
+	switch target {
+	case pb.Constant_RATE_TABLE_VERSION_TARGET_HPFN_RATE_TABLE:
+		return nil
+	case pb.Constant_RATE_TABLE_VERSION_TARGET_REGION_RATE_TABLE_CFG:
+		regions := strings.Split(targetKey, ":")
+		if len(regions) != 2 || len(regions[0]) == 0 || len(regions[1]) == 0 {
+			return cerr.New(fmt.Sprintf("target_key=%v of region rate table cfg must be {merchant_region}:{p_region}", targetKey), uint32(pb.Constant_ERROR_PARAMS))
+		}
+		return nil
+	case pb.Constant_RATE_TABLE_VERSION_TARGET_SHOP_RATE_TABLE_CFG, pb.Constant_RATE_TABLE_VERSION_TARGET_ITEM_RATE_TABLE_CFG:
+		if id, err := strconv.ParseUint(targetKey, 10, 64); err != nil || id == 0 {
+			return cerr.New(fmt.Sprintf("target_key=%v of shop or item rate table cfg must be id", targetKey), uint32(pb.Constant_ERROR_PARAMS))
+		}
+		return nil
+	default:
+		return cerr.New(fmt.Sprintf("unknown target=%v", target), uint32(pb.Constant_ERROR_PARAMS))
+	}
+}
+
+// validateRateTableVersionRows rejects rows with any violation of the rate table validator
+func validateRateTableVersionRows(hpfnKey string, content model.HpfnRateTableVersionContent) error {
+	if len(content.Rows) == 0 {
+		return cerr.New("rows are required for hpfn rate table", uint32(pb.Constant_ERROR_PARAMS))
+	}
+	if content.RateTableCfg != nil {
+		return cerr.New("rate_table_cfg must not be set for hpfn rate table", uint32(pb.Constant_ERROR_PARAMS))
+	}
+
+	rows := make([]*sip_db.HpfnConfig, 0, len(content.Rows))
+	for _, row := range content.Rows {
+		rows = append(rows, &sip_db.HpfnConfig{
+			HpfnKey:     hpfnKey,
+			WeightRange: row.WeightRange,
+			StartPrice:  row.StartPrice,
+			StartWeight: row.StartWeight,
+			RoundSize:   row.RoundSize,
+			Price:       row.Price,
+			WeightStep:  row.WeightStep,
+			Adjustment:  row.Adjustment,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].WeightRange < rows[j].WeightRange
+	})
+	violations := config_parser.ValidateRateTables(pb.Constant_RATE_TABLE_SOURCE_HPFN_CONFIG_TAB, map[string][]*sip_db.HpfnConfig{hpfnKey: rows})
+	if len(violations) > 0 {
+		s, _ := json.Marshal(violations)
+		return cerr.New(fmt.Sprintf("invalid rate table, violations=%s", s), uint32(pb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
+
+// validateRateTableVersionCfg every key of rate_table_cfg must be in hpfn_config_tab, or have a rate table version
+// effective no later than the assignment
+func (c *CbSipLogicImpl) validateRateTableVersionCfg(ctx context.Context, content model.HpfnRateTableVersionContent, effectiveFrom int64) error {
+	if len(content.Rows) > 0 {
+		return cerr.New("rows must not be set for rate table cfg", uint32(pb.Constant_ERROR_PARAMS))
+	}
+
+	all, err := c.hpfnConfigRepo.GetAllHpfnConfig(ctx)
+	if err != nil {
+		return err
+	}
+	hpfnKeys := make(map[string][]*sip_db.HpfnConfig, len(all))
+	for key, rows := range all {
+		hpfnKeys[key] = rows
+	}
+	for _, version := range c.hpfnConfigRepo.GetAllRateTableVersions(ctx) {
+		if version.Target == pb.Constant_RATE_TABLE_VERSION_TARGET_HPFN_RATE_TABLE && version.EffectiveFrom <= effectiveFrom {
+			hpfnKeys[version.TargetKey] = nil
+		}
+	}
+
+	violations := config_parser.ValidateRateTableCfgKeys(pb.Constant_RATE_TABLE_SOURCE_UNKNOWN, 0, "", content.RateTableCfg, hpfnKeys)
+	if len(violations) > 0 {
+		s, _ := json.Marshal(violations)
+		return cerr.New(fmt.Sprintf("rate_table_cfg references unknown hpfn keys, violations=%s", s), uint32(pb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
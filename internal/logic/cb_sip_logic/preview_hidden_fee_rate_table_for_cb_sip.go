@@ -0,0 +1,46 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// PreviewHiddenFeeRateTableForCbSip returns the hidden price at each weight from WeightFrom to WeightTo by WeightStep.
+// the rate table is HpfnKey if set, otherwise it is resolved for P item through item, shop, region and default levels
+func (c *CbSipLogicImpl) PreviewHiddenFeeRateTableForCbSip(ctx context.Context, request model.CbSipPreviewHiddenFeeRateTableRequest) ([]model.CbSipHiddenFeePreviewPoint, error) {
+	getHiddenPrice := func(weight float64) (float64, *model.HiddenPriceConf) {
+		return c.factorsRepo.GetHiddenPriceByHpfnKeyForCbSip(ctx, request.HpfnKey, weight)
+	}
+	if len(request.HpfnKey) == 0 {
+		pItemData, err := c.getPItemInfo(ctx, request.PShopId, request.PItemId)
+		if err != nil {
+			return nil, err
+		}
+		pShopData, err := c.getPShopInfo(ctx, request.PShopId, false)
+		if err != nil {
+			return nil, err
+		}
+		getHiddenPrice = func(weight float64) (float64, *model.HiddenPriceConf) {
+			return c.factorsRepo.GetHiddenPriceForCbSip(ctx, pItemData, pShopData, request.MerchantRegion, request.PRegion, request.ARegion, weight, false)
+		}
+	}
+
+	// weights are counted by step index, adding up a float step drifts and may skip WeightTo
+	count := int(math.Round((request.WeightTo - request.WeightFrom) / request.WeightStep))
+	points := make([]model.CbSipHiddenFeePreviewPoint, 0, count+1)
+	for i := 0; i <= count; i++ {
+		weight := request.WeightFrom + float64(i)*request.WeightStep
+		hiddenPrice, conf := getHiddenPrice(weight)
+		points = append(points, model.CbSipHiddenFeePreviewPoint{
+			Weight:          weight,
+			HiddenPrice:     hiddenPrice,
+			HiddenFeeConfig: buildHiddenFeeConfigInfo(conf),
+		})
+	}
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] previewed hidden fee rate table, request=%+v, points=%v", request, len(points)))
+	return points, nil
+}
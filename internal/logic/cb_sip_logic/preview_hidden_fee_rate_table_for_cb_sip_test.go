@@ -0,0 +1,50 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/factors"
+)
+
+type fakeFactorsRepo struct {
+	factors.CalculationFactorsRepo
+}
+
+// GetHiddenPriceByHpfnKeyForCbSip the hidden price is the weight
+func (f *fakeFactorsRepo) GetHiddenPriceByHpfnKeyForCbSip(ctx context.Context, hpfnKey string, weight float64) (float64, *model.HiddenPriceConf) {
+	return weight, &model.HiddenPriceConf{HpfnKey: hpfnKey}
+}
+
+func TestPreviewHiddenFeeRateTableForCbSip(t *testing.T) {
+	ctx := context.Background()
+	c := &CbSipLogicImpl{factorsRepo: &fakeFactorsRepo{}}
+	preview := func(from, to, step float64) []float64 {
+		points, err := c.PreviewHiddenFeeRateTableForCbSip(ctx, model.CbSipPreviewHiddenFeeRateTableRequest{
+			HpfnKey:    "SG_default",
+			WeightFrom: from,
+			WeightTo:   to,
+			WeightStep: step,
+		})
+		assert.NoError(t, err)
+		weights := make([]float64, 0, len(points))
+		for _, point := range points {
+			assert.Equal(t, point.Weight, point.HiddenPrice)
+			assert.Equal(t, "SG_default", point.HiddenFeeConfig.GetHpfnKey())
+			weights = append(weights, point.Weight)
+		}
+		return weights
+	}
+
+	assert.Equal(t, []float64{0, 10, 20, 30}, preview(0, 30, 10))
+	assert.Equal(t, []float64{5}, preview(5, 5, 1))
+
+	// WeightTo is kept although the sum of the float steps exceeds it
+	weights := preview(0, 1, 0.1)
+	assert.Len(t, weights, 11)
+	assert.Equal(t, float64(1), weights[10])
+	assert.InDelta(t, 0.3, weights[3], 1e-12)
+}
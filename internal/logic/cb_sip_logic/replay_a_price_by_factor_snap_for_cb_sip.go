@@ -0,0 +1,48 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"fmt"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logicutil"
+)
+
+// ReplayAPriceByFactorSnapForCbSip recalculates A prices purely from the factor snap returned by CalculateAPriceByPItemForCbSip,
+// with the formula version recorded in snap. no DB, cache or SPEX is called
+func (c *CbSipLogicImpl) ReplayAPriceByFactorSnapForCbSip(ctx context.Context, aRegion string, snap *pb.CbSipPriceFactorSnap, queries []model.AItemCbSipQueryId) ([]model.CbSipCalculateAPriceByPItemResult, error) {
+	priceFactors, err := newCbSipPriceFactorsFromSnap(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := logicutil.ReplayFormulaVersions(snap.GetFormulaVersion(), len(queries))
+	return calcCbSipAPriceByFactors(ctx, versions, true, aRegion, queries, priceFactors)
+}
+
+// newCbSipPriceFactorsFromSnap is the reverse of buildCbSipPriceFactorSnap
+func newCbSipPriceFactorsFromSnap(snap *pb.CbSipPriceFactorSnap) (*model.CbSipPriceFactors, error) {
+	finalFee := 1 + snap.GetServiceFee() + snap.GetCommissionFee() + snap.GetHandlingFee()
+	if finalFee <= 0 {
+		return nil, cerr.New(fmt.Sprintf("snap serviceFee %v + commissionFee %v + handlingFee %v <= 0", snap.GetServiceFee(), snap.GetCommissionFee(), snap.GetHandlingFee()), uint32(pb.Constant_ERROR_PARAMS))
+	}
+
+	return &model.CbSipPriceFactors{
+		Weight:        snap.GetWeight(),
+		CountryMargin: snap.GetCountryMargin(),
+		ShopMargin:    snap.GetShopMargin(),
+		ItemMargin:    snap.GetItemMargin(),
+		ExchangeRate:  snap.GetExchangeRate(),
+		PriceRatio:    snap.GetPriceRatio(),
+		HiddenPrice:   snap.GetAffiHiddenPrice(),
+		SrcCurrency:   snap.GetSrcCurrency(),
+		ServiceFee:    snap.GetServiceFee(),
+		CommissionFee: snap.GetCommissionFee(),
+		HandlingFee:   snap.GetHandlingFee(),
+		FinalFee:      finalFee,
+
+		HiddenFeeConfig: snap.GetHiddenFeeConfig(),
+	}, nil
+}
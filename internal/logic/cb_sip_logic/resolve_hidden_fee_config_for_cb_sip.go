@@ -0,0 +1,51 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"fmt"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/factors"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/service"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// ResolveHiddenFeeConfigForCbSip resolves the hidden fee rate table of P item for A region in the same way as
+// CalculateAPriceByPItemForCbSip, falling through item, shop, region and default levels
+func (c *CbSipLogicImpl) ResolveHiddenFeeConfigForCbSip(ctx context.Context, request model.CbSipResolveHiddenFeeConfigRequest) (*model.CbSipResolveHiddenFeeConfigResult, error) {
+	pItemData, err := c.getPItemInfo(ctx, request.PShopId, request.PItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	weight := request.Weight
+	if weight <= 0 {
+		if pItemData == nil {
+			return nil, cerr.New(fmt.Sprintf("p item not found and weight is not set, pShopId=%v, pItemId=%v", request.PShopId, request.PItemId),
+				uint32(pb.Constant_ERROR_NOT_FOUND))
+		}
+		pProductInfo, err := c.getItemProductInfo(ctx, request.PShopId, request.PItemId, request.PRegion)
+		if err != nil {
+			return nil, err
+		}
+		dimension := service.GetItemDimension(pProductInfo.GetLogistics())
+		weight = calcutil.DbWeightToGram(factors.GetChargeableWeight(0, pItemData.Weight, dimension, request.ARegion).Weight)
+	}
+
+	pShopData, err := c.getPShopInfo(ctx, request.PShopId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	hiddenPrice, conf := c.factorsRepo.GetHiddenPriceForCbSip(ctx, pItemData, pShopData, request.MerchantRegion, request.PRegion, request.ARegion, weight, false)
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] resolved hidden fee config, request=%+v, weight=%v, hiddenPrice=%v, conf=%+v", request, weight, hiddenPrice, conf))
+
+	return &model.CbSipResolveHiddenFeeConfigResult{
+		Weight:          weight,
+		HiddenPrice:     hiddenPrice,
+		HiddenFeeConfig: buildHiddenFeeConfigInfo(conf),
+	}, nil
+}
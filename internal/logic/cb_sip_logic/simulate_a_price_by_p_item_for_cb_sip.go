@@ -0,0 +1,67 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"fmt"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+// SimulateAPriceByPItemForCbSip calculates A prices with the fetched factors as baseline, and again with the overridden factors.
+// overridden factors only live in this calculation, they are never persisted or cached
+func (c *CbSipLogicImpl) SimulateAPriceByPItemForCbSip(ctx context.Context, request model.CbSipCalculateAPriceByPItemRequest, overrides *model.PriceFactorOverrides) (baseline []model.CbSipCalculateAPriceByPItemResult, overridden []model.CbSipCalculateAPriceByPItemResult, err error) {
+	priceFactors, err := c.getCbSipPriceFactors(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	if priceFactors == nil {
+		return nil, nil, nil
+	}
+
+	versions, _, _ := resolveCbSipFormulaVersions(request)
+	baseline, err = calcCbSipAPriceByFactors(ctx, versions, true, request.ARegion, request.Queries, priceFactors)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	overriddenFactors, err := overrideCbSipPriceFactors(priceFactors, overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	overridden, err = calcCbSipAPriceByFactors(ctx, versions, true, request.ARegion, request.Queries, overriddenFactors)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return baseline, overridden, nil
+}
+
+// overrideCbSipPriceFactors returns a copy of priceFactors with overrides applied, priceFactors is untouched
+func overrideCbSipPriceFactors(priceFactors *model.CbSipPriceFactors, overrides *model.PriceFactorOverrides) (*model.CbSipPriceFactors, error) {
+	res := *priceFactors
+	if overrides == nil {
+		return &res, nil
+	}
+
+	model.OverrideFactor(&res.ExchangeRate, overrides.ExchangeRate)
+	model.OverrideFactor(&res.CountryMargin, overrides.CountryMargin)
+	model.OverrideFactor(&res.ShopMargin, overrides.ShopMargin)
+	model.OverrideFactor(&res.ItemMargin, overrides.ItemMargin)
+	model.OverrideFactor(&res.HiddenPrice, overrides.HiddenFee)
+	model.OverrideFactor(&res.PriceRatio, overrides.PriceRatio)
+	model.OverrideFactor(&res.ServiceFee, overrides.ServiceFee)
+	model.OverrideFactor(&res.CommissionFee, overrides.CommissionFee)
+	model.OverrideFactor(&res.HandlingFee, overrides.HandlingFee)
+	if overrides.HiddenFee != nil {
+		// hidden price no longer comes from the rate table
+		res.HiddenFeeConfig = nil
+	}
+
+	res.FinalFee = 1 + res.ServiceFee + res.CommissionFee + res.HandlingFee
+	if res.FinalFee <= 0 {
+		return nil, cerr.New(fmt.Sprintf("overridden serviceFee %v + commissionFee %v + handlingFee %v <= 0", res.ServiceFee, res.CommissionFee, res.HandlingFee), uint32(pb.Constant_ERROR_PARAMS))
+	}
+	return &res, nil
+}
@@ -0,0 +1,99 @@
+package cb_sip_logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config/config_parser"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// ValidateHiddenFeeRateTableForCbSip lints hpfn_config_tab and the default rate table, then checks the region rate table
+// config and rate_table_cfg of the requested P shops and P items only reference keys in hpfn_config_tab
+func (c *CbSipLogicImpl) ValidateHiddenFeeRateTableForCbSip(ctx context.Context, request model.CbSipValidateRateTableRequest) ([]*model.RateTableViolation, error) {
+	violations := c.hpfnConfigRepo.ValidateRateTables(ctx)
+	hpfnKeys, err := c.hpfnConfigRepo.GetAllHpfnConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	regionViolations, err := c.validateRegionRateTableCfg(ctx, hpfnKeys)
+	if err != nil {
+		return nil, err
+	}
+	violations = append(violations, regionViolations...)
+
+	if len(request.PShopIds) > 0 {
+		pShops, err := c.getPShopInfoBatch(ctx, request.PShopIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, pShop := range pShops {
+			violations = append(violations, config_parser.ValidateRateTableCfg(pb.Constant_RATE_TABLE_SOURCE_MST_SHOP_RATE_TABLE_CFG,
+				uint64(pShop.ShopId), pShop.Country, pShop.RateTableCfg, hpfnKeys)...)
+		}
+	}
+
+	if len(request.PItemIds) > 0 {
+		session := c.sipV2Repo.DbSession()
+		pItems, err := c.sipV2Repo.GetMstItemRecordBatch(ctx, session, request.ItemPShopId, request.PItemIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, pItem := range pItems {
+			violations = append(violations, config_parser.ValidateRateTableCfg(pb.Constant_RATE_TABLE_SOURCE_MST_ITEM_RATE_TABLE_CFG,
+				pItem.ItemId, "", pItem.RateTableCfg, hpfnKeys)...)
+		}
+	}
+
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[CB SIP] validated hidden fee rate tables, request=%+v, violations=%v", request, len(violations)))
+	return violations, nil
+}
+
+func (c *CbSipLogicImpl) validateRegionRateTableCfg(ctx context.Context, hpfnKeys map[string][]*sip_db.HpfnConfig) ([]*model.RateTableViolation, error) {
+	session := c.sipRepo.DbSession()
+	syscfg, err := c.sipRepo.GetSystemConfigRecordByType(ctx, session, sip_db.SystemConfigTypeRegionRateTableConfig)
+	if err != nil {
+		return nil, err
+	}
+	if syscfg == nil || len(syscfg.ConfigData) == 0 {
+		return nil, nil
+	}
+
+	regionCfg := map[string]map[string]*model.RateTableCfg{}
+	if err := json.Unmarshal([]byte(syscfg.ConfigData), &regionCfg); err != nil {
+		return []*model.RateTableViolation{{
+			Source: pb.Constant_RATE_TABLE_SOURCE_REGION_RATE_TABLE_CFG,
+			Type:   pb.Constant_RATE_TABLE_VIOLATION_INVALID_ROW,
+			Detail: fmt.Sprintf("unmarshal region rate table cfg failed, err=%v", err),
+		}}, nil
+	}
+
+	merchantRegions := make([]string, 0, len(regionCfg))
+	for merchantRegion := range regionCfg {
+		merchantRegions = append(merchantRegions, merchantRegion)
+	}
+	sort.Strings(merchantRegions)
+
+	violations := make([]*model.RateTableViolation, 0)
+	for _, merchantRegion := range merchantRegions {
+		pRegions := make([]string, 0, len(regionCfg[merchantRegion]))
+		for pRegion := range regionCfg[merchantRegion] {
+			pRegions = append(pRegions, pRegion)
+		}
+		sort.Strings(pRegions)
+		for _, pRegion := range pRegions {
+			for _, v := range config_parser.ValidateRateTableCfgKeys(pb.Constant_RATE_TABLE_SOURCE_REGION_RATE_TABLE_CFG, 0, pRegion,
+				regionCfg[merchantRegion][pRegion], hpfnKeys) {
+				v.Detail = fmt.Sprintf("merchant_region=%s, %s", merchantRegion, v.Detail)
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations, nil
+}
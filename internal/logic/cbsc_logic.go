@@ -10,6 +10,7 @@ import (
 
 type CbscLogic interface {
 	CalculatePriceForCbsc(ctx context.Context, merchantId uint64, isMtskuToMpsku bool, queries []model.MtskuMpskuPriceQuery) ([]model.MtskuMpskuPriceCalcResult, error)
+	SimulatePriceForCbsc(ctx context.Context, merchantId uint64, isMtskuToMpsku bool, queries []model.MtskuMpskuPriceQuery, overrides *model.PriceFactorOverrides) ([]model.MtskuMpskuPriceCalcResult, []model.MtskuMpskuPriceCalcResult, error)
 	GetCbscPriceFactor(ctx context.Context, query *pb.GetCbscPriceFactorRequest) (*pb.CbscPriceFactor, error)
 	SetCbscPriceFactor(ctx context.Context, query model.SetCbscPriceFactorQuery) error
 	GetCbscPriceFactorLimit(ctx context.Context, merchantId uint64) (*pb.CbscServiceFeeRateLimit, map[string]*pb.CbscProfitRateLimit, error)
@@ -11,6 +11,8 @@ import (
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logicutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
 func (c *CbscLogicImpl) CalculatePriceForCbsc(ctx context.Context, merchantId uint64, isMtskuToMpsku bool, queries []model.MtskuMpskuPriceQuery) ([]model.MtskuMpskuPriceCalcResult, error) {
@@ -18,23 +20,42 @@ func (c *CbscLogicImpl) CalculatePriceForCbsc(ctx context.Context, merchantId ui
 		return nil, nil
 	}
 
-	// get merchant region
-	merchantRegion, err := c.shopMerchantService.GetMerchantRegion(ctx, merchantId)
+	merchantRegion, merchantCurrency, priceFactorsList, err := c.getCbscPriceFactors(ctx, merchantId, isMtskuToMpsku, queries)
 	if err != nil {
 		return nil, err
 	}
 
+	versions, candidateVersions, hasShadow := resolveCbscFormulaVersions(isMtskuToMpsku, merchantRegion, queries)
+	results := calcCbscPriceByFactors(ctx, versions, true, isMtskuToMpsku, merchantRegion, merchantCurrency, queries, priceFactorsList)
+
+	if hasShadow {
+		logicutil.RunFormulaShadow(ctx, logicutil.FormulaCbsc, func(ctx context.Context) []logicutil.FormulaShadowResult {
+			candidateResults := calcCbscPriceByFactors(ctx, candidateVersions, false, isMtskuToMpsku, merchantRegion, merchantCurrency, queries, priceFactorsList)
+			return compareCbscPriceResults(isMtskuToMpsku, merchantRegion, queries, results, candidateVersions, candidateResults)
+		})
+	}
+	return results, nil
+}
+
+// getCbscPriceFactors gathers factors of CBSC price formula for each query, the length and order is same like queries
+func (c *CbscLogicImpl) getCbscPriceFactors(ctx context.Context, merchantId uint64, isMtskuToMpsku bool, queries []model.MtskuMpskuPriceQuery) (merchantRegion string, merchantCurrency string, priceFactorsList []*model.CbscPriceFactors, err error) {
+	// get merchant region
+	merchantRegion, err = c.shopMerchantService.GetMerchantRegion(ctx, merchantId)
+	if err != nil {
+		return "", "", nil, err
+	}
+
 	// get merchant config
 	merchantConfigMap, err := c.merchantConfigService.GetSingleMerchantConfigSettingInfoMap(ctx, merchantId)
 	if err != nil {
-		return nil, err
+		return "", "", nil, err
 	}
 
 	// get factors.
 	// get exchange rates
 	merchantCurrency, exchangeRateMap, err := c.factorsRepo.GetExchangeRateMapForCbsc(ctx, merchantId)
 	if err != nil {
-		return nil, err
+		return "", "", nil, err
 	}
 
 	// get hidden fees
@@ -42,6 +63,7 @@ func (c *CbscLogicImpl) CalculatePriceForCbsc(ctx context.Context, merchantId ui
 	for idx, query := range queries {
 		hidePriceQueries = append(hidePriceQueries, model.GetHidePriceForCbscRequest{
 			QueryId:              idx,
+			MerchantId:           merchantId,
 			Region:               query.MpskuRegion,
 			Weight:               query.Weight,
 			IsMtskuToMpsku:       isMtskuToMpsku,
@@ -54,7 +76,7 @@ func (c *CbscLogicImpl) CalculatePriceForCbsc(ctx context.Context, merchantId ui
 	}
 	hidePriceList, err := c.factorsRepo.GetHidePriceForCbsc(ctx, hidePriceQueries)
 	if err != nil {
-		return nil, err
+		return "", "", nil, err
 	}
 
 	// get commission rates
@@ -67,7 +89,7 @@ func (c *CbscLogicImpl) CalculatePriceForCbsc(ctx context.Context, merchantId ui
 	}
 	commissionRates := c.factorsRepo.GetCommissionRateBatchForCbsc(ctx, commissionRateQueries)
 	if len(commissionRates) != len(commissionRateQueries) {
-		return nil, cerr.New(fmt.Sprintf("the length of returned commissionRates is unexpected, length=%d, expected=%d",
+		return "", "", nil, cerr.New(fmt.Sprintf("the length of returned commissionRates is unexpected, length=%d, expected=%d",
 			len(commissionRates), len(commissionRateQueries)), uint32(pb.Constant_ERROR_INTERNAL))
 	}
 
@@ -82,7 +104,7 @@ func (c *CbscLogicImpl) CalculatePriceForCbsc(ctx context.Context, merchantId ui
 	}
 	cbscPriceRates, err := c.factorsRepo.GetCbscPriceRateBatchForCbsc(ctx, merchantRegion, merchantConfigMap, cbscPriceRateQueries)
 	if err != nil {
-		return nil, err
+		return "", "", nil, err
 	}
 
 	// get profit rates
@@ -94,80 +116,177 @@ func (c *CbscLogicImpl) CalculatePriceForCbsc(ctx context.Context, merchantId ui
 	}
 	profitRates, err := c.factorsRepo.GetProfitRateBatchForCbsc(ctx, merchantConfigMap, profitRateQueries)
 	if err != nil {
-		return nil, err
+		return "", "", nil, err
 	}
 
-	finalResult := make([]model.MtskuMpskuPriceCalcResult, len(queries))
+	priceFactorsList = make([]*model.CbscPriceFactors, len(queries))
 	for i, query := range queries {
-		calcPrice := calcutil.RoundIntToFloat(query.SourcePrice, constant.PricePrecision, 2)
 		exchangeRate, ok := exchangeRateMap[query.MpskuRegion]
 		if !ok {
-			finalResult[i] = model.MtskuMpskuPriceCalcResult{
+			priceFactorsList[i] = &model.CbscPriceFactors{
 				Err: cerr.New(fmt.Sprintf("failed to get exchange rate for region=%v", query.MpskuRegion), uint32(pb.Constant_ERROR_GET_MERCHANT_EXCHANGE_RATE)),
 			}
 			continue
 		}
 		profitRateRes := profitRates[i]
 		if profitRateRes.Err != nil {
-			finalResult[i] = model.MtskuMpskuPriceCalcResult{
+			priceFactorsList[i] = &model.CbscPriceFactors{
 				Err: profitRateRes.Err,
 			}
 			continue
 		}
-		profitRate := profitRateRes.ProfitRate
 
 		hidePriceRes := hidePriceList[i]
 		if hidePriceRes.Err != nil {
-			finalResult[i] = model.MtskuMpskuPriceCalcResult{
+			priceFactorsList[i] = &model.CbscPriceFactors{
 				Err:                hidePriceRes.Err,
 				HidePriceErrorCode: int32(cerr.Code(hidePriceRes.Err)),
 			}
 			continue
 		}
-		hidePrice := hidePriceRes.HidePrice
 
 		cbscPriceRateRes := cbscPriceRates[i]
 		if cbscPriceRateRes.Err != nil {
-			finalResult[i] = model.MtskuMpskuPriceCalcResult{
+			priceFactorsList[i] = &model.CbscPriceFactors{
 				Err: cbscPriceRateRes.Err,
 			}
 			continue
 		}
-		cbscPriceRate := cbscPriceRateRes.CbscPriceRate
 
-		var dstPrice float64
-		if isMtskuToMpsku {
-			dstPrice = calcutil.CalculateMpskuPrice(calcPrice, exchangeRate, profitRate, hidePrice, cbscPriceRate)
-		} else {
-			dstPrice = calcutil.CalculateMtskuPrice(calcPrice, exchangeRate, profitRate, hidePrice, cbscPriceRate)
+		priceFactorsList[i] = &model.CbscPriceFactors{
+			ExchangeRate:      exchangeRate,
+			ProfitRate:        profitRateRes.ProfitRate,
+			HidePrice:         hidePriceRes.HidePrice,
+			CbscPriceRate:     cbscPriceRateRes.CbscPriceRate,
+			ChannelHiddenFees: hidePriceRes.ChannelHiddenFees,
+			HiddenFeeStrategy: hidePriceRes.HiddenFeeStrategy,
 		}
+	}
+
+	return merchantRegion, merchantCurrency, priceFactorsList, nil
+}
 
-		var pricePrecision int32
-		if isMtskuToMpsku {
-			pricePrecision = config.GetPricePrecision(query.MpskuRegion)
-		} else {
-			pricePrecision = config.GetPricePrecision(merchantCurrency)
+type cbscPriceFormula func(ctx context.Context, isMtskuToMpsku bool, merchantCurrency string, query model.MtskuMpskuPriceQuery, priceFactors *model.CbscPriceFactors) model.MtskuMpskuPriceCalcResult
+
+// cbscPriceFormulas CBSC mtsku<->mpsku price formula of each version, a new version is added here and rolled out by FormulaVersionConfig
+var cbscPriceFormulas = map[string]cbscPriceFormula{
+	logicutil.FormulaVersionV1: calcCbscPriceV1,
+}
+
+func init() {
+	for version := range cbscPriceFormulas {
+		config.RegisterFormulaVersion(logicutil.FormulaCbsc, version)
+	}
+}
+
+// resolveCbscFormulaVersions mpsku shop of each query and the region of dst price decide its formula version
+func resolveCbscFormulaVersions(isMtskuToMpsku bool, merchantRegion string, queries []model.MtskuMpskuPriceQuery) (versions []string, candidateVersions []string, hasShadow bool) {
+	targets := make([]logicutil.FormulaVersionTarget, len(queries))
+	for i, query := range queries {
+		targets[i] = logicutil.FormulaVersionTarget{Region: getCbscDstRegion(isMtskuToMpsku, merchantRegion, query), ShopId: query.MpskuShopId}
+	}
+	return logicutil.ResolveFormulaVersions(logicutil.FormulaCbsc, targets)
+}
+
+func getCbscDstRegion(isMtskuToMpsku bool, merchantRegion string, query model.MtskuMpskuPriceQuery) string {
+	if isMtskuToMpsku {
+		return query.MpskuRegion
+	}
+	return merchantRegion
+}
+
+// calcCbscPriceByFactors calculates dst prices of all queries with the given factors and formula version of each query, no IO involved.
+// guardrail is skipped for shadow calculation, so that outliers of candidate version are not reported.
+// prices rejected by guardrail are kept in result for comparison, but not returned to caller
+func calcCbscPriceByFactors(ctx context.Context, versions []string, checkGuardrail bool, isMtskuToMpsku bool, merchantRegion string, merchantCurrency string, queries []model.MtskuMpskuPriceQuery, priceFactorsList []*model.CbscPriceFactors) []model.MtskuMpskuPriceCalcResult {
+	finalResult := make([]model.MtskuMpskuPriceCalcResult, len(queries))
+	for i, query := range queries {
+		priceFactors := priceFactorsList[i]
+		if priceFactors.Err != nil {
+			finalResult[i] = model.MtskuMpskuPriceCalcResult{
+				Err:                priceFactors.Err,
+				HidePriceErrorCode: priceFactors.HidePriceErrorCode,
+			}
+			continue
 		}
 
-		inflatedHidePrice := calcutil.RoundFloatToInt(hidePrice, constant.PricePrecision, pricePrecision)
-		inflatedDstPrice := calcutil.RoundFloatToInt(dstPrice, constant.PricePrecision, pricePrecision)
-		if !isMtskuToMpsku && inflatedDstPrice == 0 {
-			inflatedDstPrice = calcutil.GetMiniPriceValueBasedOnCurrency(pricePrecision)
+		formula, ok := cbscPriceFormulas[versions[i]]
+		if !ok {
+			finalResult[i] = model.MtskuMpskuPriceCalcResult{
+				Err:            cerr.New(fmt.Sprintf("cbsc formula version=%v is not supported", versions[i]), uint32(pb.Constant_ERROR_INTERNAL)),
+				FormulaVersion: versions[i],
+			}
+			continue
 		}
 
-		finalResult[i] = model.MtskuMpskuPriceCalcResult{
-			DstPrice:  inflatedDstPrice,
-			HidePrice: inflatedHidePrice,
+		result := formula(ctx, isMtskuToMpsku, merchantCurrency, query, priceFactors)
+		result.FormulaVersion = versions[i]
+		result.ChannelHiddenFees = priceFactors.ChannelHiddenFees
+		result.HiddenFeeStrategy = priceFactors.HiddenFeeStrategy
+
+		if checkGuardrail && result.Err == nil {
+			dstRegion := getCbscDstRegion(isMtskuToMpsku, merchantRegion, query)
+			result.Guardrail = checkCbscPriceGuardrail(ctx, isMtskuToMpsku, dstRegion, query, priceFactors, result)
+			if result.Guardrail.IsRejected() {
+				result.Err = calcutil.NewPriceGuardrailRejectedError(dstRegion, result.Guardrail)
+			}
 		}
+		finalResult[i] = result
+	}
+
+	return finalResult
+}
+
+// calcCbscPriceV1 CBSC mtsku<->mpsku price formula v1
+func calcCbscPriceV1(ctx context.Context, isMtskuToMpsku bool, merchantCurrency string, query model.MtskuMpskuPriceQuery, priceFactors *model.CbscPriceFactors) model.MtskuMpskuPriceCalcResult {
+	kernelFactors := calcutil.NewCbscKernelFactors(isMtskuToMpsku, query.MpskuRegion, merchantCurrency, priceFactors)
+	prices := pricekernel.CalcCbscPrice(isMtskuToMpsku, query.SourcePrice, kernelFactors)
+
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[CBSC] Calc price for CBSC | isMtskuToMpsku:%v | "+
+		"query=%+v, exchangeRate=%v, profitRate=%v, hidePrice=%v, cbscPriceRate=%v, "+
+		"mpskuRegion=%s, merchantCurrency=%s, pricePrecision=%d | "+
+		"actualPriceResult=%v, inflatedPriceRes=%v, inflatedHidePrice=%v",
+		isMtskuToMpsku, query, priceFactors.ExchangeRate, priceFactors.ProfitRate, priceFactors.HidePrice, priceFactors.CbscPriceRate,
+		query.MpskuRegion, merchantCurrency, kernelFactors.RoundPlace,
+		prices.Trace.PreRoundingPrice, prices.DstPrice, prices.HidePrice))
 
-		logging.GetLogger(ctx).Info(fmt.Sprintf("[CBSC] Calc price for CBSC | isMtskuToMpsku:%v | "+
-			"query=%+v, sourcePrice=%v, exchangeRate=%v, profitRate=%v, hidePrice=%v, cbscPriceRate=%v, "+
-			"mpskuRegion=%s, merchantCurrency=%s, pricePrecision=%d | "+
-			"actualPriceResult=%v, inflatedPriceRes=%v, inflatedHidePrice=%v",
-			isMtskuToMpsku, query, calcPrice, exchangeRate, profitRate, hidePrice, cbscPriceRate,
-			query.MpskuRegion, merchantCurrency, pricePrecision,
-			dstPrice, inflatedDstPrice, inflatedHidePrice))
+	return model.MtskuMpskuPriceCalcResult{
+		DstPrice:  prices.DstPrice,
+		HidePrice: prices.HidePrice,
+		Trace:     prices.Trace,
 	}
+}
+
+// checkCbscPriceGuardrail dst price is checked against source price converted to dst currency,
+// exchange rate is dst currency per merchant currency
+func checkCbscPriceGuardrail(ctx context.Context, isMtskuToMpsku bool, dstRegion string, query model.MtskuMpskuPriceQuery, priceFactors *model.CbscPriceFactors, result model.MtskuMpskuPriceCalcResult) model.PriceGuardrailResult {
+	sourcePrice := calcutil.RoundIntToFloat(query.SourcePrice, constant.PricePrecision, 2)
+	var convertedPrice float64
+	if isMtskuToMpsku {
+		convertedPrice = sourcePrice * priceFactors.ExchangeRate
+	} else if priceFactors.ExchangeRate > 0 {
+		convertedPrice = sourcePrice / priceFactors.ExchangeRate
+	}
+	return calcutil.CheckPriceGuardrail(ctx, dstRegion, model.PriceNameDst, convertedPrice, calcutil.ToRealPrice(result.DstPrice))
+}
 
-	return finalResult, nil
+// compareCbscPriceResults compares the results of live version and candidate version query by query
+func compareCbscPriceResults(isMtskuToMpsku bool, merchantRegion string, queries []model.MtskuMpskuPriceQuery, results []model.MtskuMpskuPriceCalcResult, candidateVersions []string, candidateResults []model.MtskuMpskuPriceCalcResult) []logicutil.FormulaShadowResult {
+	shadowResults := make([]logicutil.FormulaShadowResult, 0, len(results))
+	for i, result := range results {
+		candidate := candidateResults[i]
+		shadowResult := logicutil.FormulaShadowResult{
+			Region:           getCbscDstRegion(isMtskuToMpsku, merchantRegion, queries[i]),
+			LiveVersion:      result.FormulaVersion,
+			CandidateVersion: candidateVersions[i],
+		}
+		switch {
+		case (result.Err == nil || result.Guardrail.IsRejected()) != (candidate.Err == nil):
+			shadowResult.Diff = fmt.Sprintf("err: %v -> %v", result.Err, candidate.Err)
+		case result.DstPrice != candidate.DstPrice || result.HidePrice != candidate.HidePrice:
+			shadowResult.Diff = fmt.Sprintf("dst: %v -> %v, hide: %v -> %v", result.DstPrice, candidate.DstPrice, result.HidePrice, candidate.HidePrice)
+		}
+		shadowResults = append(shadowResults, shadowResult)
+	}
+	return shadowResults
 }
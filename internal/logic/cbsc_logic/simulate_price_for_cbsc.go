@@ -0,0 +1,43 @@
+package cbsc_logic
+
+import (
+	"context"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+)
+
+// SimulatePriceForCbsc calculates dst prices with the fetched factors as baseline, and again with the overridden factors.
+// overridden factors only live in this calculation, they are never persisted or cached
+func (c *CbscLogicImpl) SimulatePriceForCbsc(ctx context.Context, merchantId uint64, isMtskuToMpsku bool, queries []model.MtskuMpskuPriceQuery, overrides *model.PriceFactorOverrides) (baseline []model.MtskuMpskuPriceCalcResult, overridden []model.MtskuMpskuPriceCalcResult, err error) {
+	if len(queries) == 0 {
+		return nil, nil, nil
+	}
+
+	merchantRegion, merchantCurrency, priceFactorsList, err := c.getCbscPriceFactors(ctx, merchantId, isMtskuToMpsku, queries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	overriddenFactorsList := make([]*model.CbscPriceFactors, 0, len(priceFactorsList))
+	for _, priceFactors := range priceFactorsList {
+		overriddenFactorsList = append(overriddenFactorsList, overrideCbscPriceFactors(priceFactors, overrides))
+	}
+
+	versions, _, _ := resolveCbscFormulaVersions(isMtskuToMpsku, merchantRegion, queries)
+	return calcCbscPriceByFactors(ctx, versions, true, isMtskuToMpsku, merchantRegion, merchantCurrency, queries, priceFactorsList),
+		calcCbscPriceByFactors(ctx, versions, true, isMtskuToMpsku, merchantRegion, merchantCurrency, queries, overriddenFactorsList), nil
+}
+
+// overrideCbscPriceFactors returns a copy of priceFactors with overrides applied, priceFactors is untouched
+func overrideCbscPriceFactors(priceFactors *model.CbscPriceFactors, overrides *model.PriceFactorOverrides) *model.CbscPriceFactors {
+	res := *priceFactors
+	if overrides == nil || res.Err != nil {
+		return &res
+	}
+
+	model.OverrideFactor(&res.ExchangeRate, overrides.ExchangeRate)
+	model.OverrideFactor(&res.ProfitRate, overrides.ProfitRate)
+	model.OverrideFactor(&res.HidePrice, overrides.HiddenFee)
+	model.OverrideFactor(&res.CbscPriceRate, overrides.DenominatorPriceRate)
+	return &res
+}
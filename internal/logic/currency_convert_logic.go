@@ -4,8 +4,11 @@ import (
 	"context"
 
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 )
 
 type CurrencyConvertLogic interface {
 	ConvertCurrency(ctx context.Context, req model.ConvertCurrencyRequest) (model.ConvertCurrencyResult, error)
+	ListQuarantinedExchangeRates(ctx context.Context, status pb.Constant_ExchangeRateQuarantineStatus) ([]*model.QuarantinedExchangeRate, error)
+	ApproveQuarantinedExchangeRate(ctx context.Context, id uint64, operator string) (*model.QuarantinedExchangeRate, error)
 }
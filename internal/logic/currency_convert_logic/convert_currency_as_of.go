@@ -0,0 +1,144 @@
+package currency_convert_logic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/convutil"
+)
+
+// convertCurrencyAsOf converts by the rates which applied at req.AsOf, the rates are processed the same as the current
+// ones of each source. rates are only known since the service first saw them
+func (c *CurrencyConvertLogicImpl) convertCurrencyAsOf(ctx context.Context, req model.ConvertCurrencyRequest) (model.ConvertCurrencyResult, error) {
+	exchangeRate, exchangeRatePath, err := c.getExchangeRateAsOfBySource(ctx, req.ExchangeRateSource, req, req.AsOf)
+	if err != nil {
+		return model.ConvertCurrencyResult{}, err
+	}
+
+	var res []int64
+	if req.ExchangeRateSource == model.ExchangeRateSourceOrderMart {
+		pricePrecision := config.GetPricePrecision(req.DstCurrency)
+		res = c.convertByExchangeRate(req.SrcPriceList, exchangeRate, true, &pricePrecision)
+	} else {
+		res = c.convertByExchangeRate(req.SrcPriceList, exchangeRate, false, nil)
+	}
+	return model.ConvertCurrencyResult{
+		ExchangeRate:       exchangeRate,
+		DstPrices:          res,
+		ExchangeRateSource: req.ExchangeRateSource,
+		ExchangeRatePath:   exchangeRatePath,
+	}, nil
+}
+
+// getExchangeRateAsOfBySource the rate of source for req which applied at asOf, path is only returned for source cb sip
+func (c *CurrencyConvertLogicImpl) getExchangeRateAsOfBySource(ctx context.Context, source model.ExchangeRateSource, req model.ConvertCurrencyRequest, asOf int64) (float64, []string, error) {
+	switch source {
+	case model.ExchangeRateSourceCbSipExchangeRate:
+		return c.getCbSipExchangeRateAsOf(ctx, req.SrcCurrency, req.DstCurrency, asOf)
+	case model.ExchangeRateSourceSellerPlatform:
+		exchangeRate, err := c.getExchangeRateAsOf(ctx, pb.Constant_SELLER_PLATFORM, exchange_rate_history.SellerPlatformRateKey(req.MerchantId, req.MpskuRegion), asOf)
+		return exchangeRate, nil, err
+	case model.ExchangeRateSourceOrderMart:
+		exchangeRate, err := c.getOrderMartExchangeRateAsOf(ctx, req.SrcCurrency, req.DstCurrency, asOf)
+		return exchangeRate, nil, err
+	default:
+		return 0, nil, cerr.New(fmt.Sprintf("invalid exchange rate source: %v", source), uint32(pb.Constant_ERROR_PARAMS))
+	}
+}
+
+// getCbSipExchangeRateAsOf same as FindExchangeRateInMapForCbSip, the reversed and triangulated rates are rounded to 10
+// decimals
+func (c *CurrencyConvertLogicImpl) getCbSipExchangeRateAsOf(ctx context.Context, srcCurrency, dstCurrency string, asOf int64) (float64, []string, error) {
+	exchangeRate, err := c.getCbSipDirectExchangeRateAsOf(ctx, srcCurrency, dstCurrency, asOf)
+	if err == nil {
+		return exchangeRate, []string{srcCurrency, dstCurrency}, nil
+	}
+	for _, pivotCurrency := range config.GetExchangeRatePivotCurrencies() {
+		if pivotCurrency == srcCurrency || pivotCurrency == dstCurrency {
+			continue
+		}
+		toPivot, pivotErr := c.getCbSipDirectExchangeRateAsOf(ctx, srcCurrency, pivotCurrency, asOf)
+		if pivotErr != nil {
+			continue
+		}
+		fromPivot, pivotErr := c.getCbSipDirectExchangeRateAsOf(ctx, pivotCurrency, dstCurrency, asOf)
+		if pivotErr != nil {
+			continue
+		}
+		exchangeRate, pivotErr = parseExchangeRate(convutil.FloatFormat(toPivot*fromPivot, 10))
+		if pivotErr != nil {
+			return 0, nil, pivotErr
+		}
+		return exchangeRate, []string{srcCurrency, pivotCurrency, dstCurrency}, nil
+	}
+	return 0, nil, err
+}
+
+// getCbSipDirectExchangeRateAsOf rate of the row of currency pair, reversed if the row is of the other direction
+func (c *CurrencyConvertLogicImpl) getCbSipDirectExchangeRateAsOf(ctx context.Context, srcCurrency, dstCurrency string, asOf int64) (float64, error) {
+	if srcCurrency == dstCurrency {
+		return 1, nil
+	}
+	exchangeRate, found, err := c.findExchangeRateAsOf(ctx, pb.Constant_CB_SIP_EXCHANGE_RATE, exchange_rate_history.CbSipRateKey(srcCurrency, dstCurrency), asOf)
+	if err != nil || found {
+		return exchangeRate, err
+	}
+	reversedExchangeRate, err := c.getExchangeRateAsOf(ctx, pb.Constant_CB_SIP_EXCHANGE_RATE, exchange_rate_history.CbSipRateKey(dstCurrency, srcCurrency), asOf)
+	if err != nil {
+		return 0, err
+	}
+	return parseExchangeRate(convutil.FloatFormat(1.0/reversedExchangeRate, 10))
+}
+
+// getOrderMartExchangeRateAsOf same as GetOrderMartExchangeRate
+func (c *CurrencyConvertLogicImpl) getOrderMartExchangeRateAsOf(ctx context.Context, srcCurrency, dstCurrency string, asOf int64) (float64, error) {
+	if srcCurrency == dstCurrency {
+		return 1, nil
+	}
+	srcExchangeRate, err := c.getExchangeRateAsOf(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, exchange_rate_history.OrderMartRateKey(srcCurrency), asOf)
+	if err != nil {
+		return 0, err
+	}
+	dstExchangeRate, err := c.getExchangeRateAsOf(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, exchange_rate_history.OrderMartRateKey(dstCurrency), asOf)
+	if err != nil {
+		return 0, err
+	}
+	return dstExchangeRate / srcExchangeRate, nil
+}
+
+func (c *CurrencyConvertLogicImpl) getExchangeRateAsOf(ctx context.Context, source pb.Constant_ExchangeRateSource, rateKey string, asOf int64) (float64, error) {
+	exchangeRate, found, err := c.findExchangeRateAsOf(ctx, source, rateKey, asOf)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, cerr.New(fmt.Sprintf("cannot find exchange rate history for source=%v, rateKey=%v, asOf=%v", source, rateKey, asOf), uint32(pb.Constant_ERROR_NOT_FOUND))
+	}
+	return exchangeRate, nil
+}
+
+func (c *CurrencyConvertLogicImpl) findExchangeRateAsOf(ctx context.Context, source pb.Constant_ExchangeRateSource, rateKey string, asOf int64) (float64, bool, error) {
+	exchangeRateStr, found, err := c.exchangeRateHistoryRepo.GetExchangeRateAsOf(ctx, source, rateKey, asOf)
+	if err != nil || !found {
+		return 0, false, err
+	}
+	exchangeRate, err := parseExchangeRate(exchangeRateStr)
+	if err != nil {
+		return 0, false, err
+	}
+	return exchangeRate, true, nil
+}
+
+func parseExchangeRate(exchangeRateStr string) (float64, error) {
+	exchangeRate, err := strconv.ParseFloat(exchangeRateStr, 64)
+	if err != nil {
+		return 0, cerr.New(fmt.Sprintf("invalid exchange rate: %v, err=%v", exchangeRateStr, err), uint32(pb.Constant_ERROR_INTERNAL))
+	}
+	return exchangeRate, nil
+}
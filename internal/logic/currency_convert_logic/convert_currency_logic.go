@@ -13,29 +13,55 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/constant"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/factors"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
 )
 
 type CurrencyConvertLogicImpl struct {
-	factorsRepo factors.CalculationFactorsRepo
+	factorsRepo             factors.CalculationFactorsRepo
+	exchangeRateHistoryRepo exchange_rate_history.ExchangeRateHistoryRepo
 }
 
-func NewCurrencyConverterLogic(factorsRepo factors.CalculationFactorsRepo) *CurrencyConvertLogicImpl {
-	return &CurrencyConvertLogicImpl{factorsRepo: factorsRepo}
+func NewCurrencyConverterLogic(factorsRepo factors.CalculationFactorsRepo, exchangeRateHistoryRepo exchange_rate_history.ExchangeRateHistoryRepo) *CurrencyConvertLogicImpl {
+	return &CurrencyConvertLogicImpl{factorsRepo: factorsRepo, exchangeRateHistoryRepo: exchangeRateHistoryRepo}
 }
 
 func (c *CurrencyConvertLogicImpl) ConvertCurrency(ctx context.Context, req model.ConvertCurrencyRequest) (model.ConvertCurrencyResult, error) {
-	switch req.ExchangeRateSource {
+	if req.AsOf > 0 {
+		// the rate which applied at that time is reproduced from the requested source only
+		return c.convertCurrencyAsOf(ctx, req)
+	}
+
+	result, err := c.convertCurrencyBySource(ctx, req.ExchangeRateSource, req)
+	if err == nil {
+		return result, nil
+	}
+	fallbackCfg := config.GetExchangeRateFallbackConfig(req.Caller)
+	if fallbackCfg == nil {
+		return model.ConvertCurrencyResult{}, err
+	}
+	return c.convertCurrencyByFallback(ctx, req, fallbackCfg, err)
+}
+
+func (c *CurrencyConvertLogicImpl) convertCurrencyBySource(ctx context.Context, source model.ExchangeRateSource, req model.ConvertCurrencyRequest) (model.ConvertCurrencyResult, error) {
+	var result model.ConvertCurrencyResult
+	var err error
+	switch source {
 	case model.ExchangeRateSourceCbSipExchangeRate:
-		return c.convertCurrencyForCbSip(ctx, req)
+		result, err = c.convertCurrencyForCbSip(ctx, req)
 	case model.ExchangeRateSourceSellerPlatform:
-		return c.convertCurrencyBySellerPlatform(ctx, req)
+		result, err = c.convertCurrencyBySellerPlatform(ctx, req)
 	case model.ExchangeRateSourceOrderMart:
-		return c.convertCurrencyByOrderMart(ctx, req)
+		result, err = c.convertCurrencyByOrderMart(ctx, req)
 	default:
-		return model.ConvertCurrencyResult{}, cerr.New(fmt.Sprintf("invalid exchange rate source: %v", req.ExchangeRateSource), uint32(pb.Constant_ERROR_PARAMS))
+		return model.ConvertCurrencyResult{}, cerr.New(fmt.Sprintf("invalid exchange rate source: %v", source), uint32(pb.Constant_ERROR_PARAMS))
 	}
+	if err != nil {
+		return model.ConvertCurrencyResult{}, err
+	}
+	result.ExchangeRateSource = source
+	return result, nil
 }
 
 func (c *CurrencyConvertLogicImpl) convertCurrencyBySellerPlatform(ctx context.Context, req model.ConvertCurrencyRequest) (model.ConvertCurrencyResult, error) {
@@ -69,7 +95,7 @@ func (c *CurrencyConvertLogicImpl) convertCurrencyForCbSip(ctx context.Context,
 	if err != nil {
 		return model.ConvertCurrencyResult{}, err
 	}
-	exchangeRateStr, ok := exchangeRateMap[req.SrcCurrency][req.DstCurrency]
+	exchangeRateStr, exchangeRatePath, ok := factors.FindExchangeRateInMapForCbSip(exchangeRateMap, req.SrcCurrency, req.DstCurrency)
 	if !ok {
 		return model.ConvertCurrencyResult{}, cerr.New(fmt.Sprintf("failed to get exchange rate for srcCurrency=%v and dstCurrency=%v", req.SrcCurrency, req.DstCurrency), uint32(pb.Constant_ERROR_NOT_FOUND))
 	}
@@ -81,22 +107,28 @@ func (c *CurrencyConvertLogicImpl) convertCurrencyForCbSip(ctx context.Context,
 	res := c.convertByExchangeRate(req.SrcPriceList, exchangeRate, false, nil)
 
 	return model.ConvertCurrencyResult{
-		ExchangeRate: exchangeRate,
-		DstPrices:    res,
+		ExchangeRate:     exchangeRate,
+		DstPrices:        res,
+		ExchangeRatePath: exchangeRatePath,
 	}, nil
 }
 
 func (c *CurrencyConvertLogicImpl) convertCurrencyByOrderMart(ctx context.Context, req model.ConvertCurrencyRequest) (model.ConvertCurrencyResult, error) {
-	exchangeRate, err := c.factorsRepo.GetOrderMartExchangeRate(ctx, req.SrcCurrency, req.DstCurrency)
+	exchangeRate, rateAge, err := c.factorsRepo.GetOrderMartExchangeRate(ctx, req.SrcCurrency, req.DstCurrency)
 	if err != nil {
 		return model.ConvertCurrencyResult{}, err
 	}
+	if req.MaxRateAge > 0 && (rateAge == model.UnknownRateAge || rateAge > req.MaxRateAge) {
+		return model.ConvertCurrencyResult{}, cerr.New(fmt.Sprintf("order mart exchange rate is stale, srcCurrency=%v, dstCurrency=%v, rateAge=%v, maxRateAge=%v",
+			req.SrcCurrency, req.DstCurrency, rateAge, req.MaxRateAge), uint32(pb.Constant_ERROR_EXCHANGE_RATE_STALE))
+	}
 
 	pricePrecision := config.GetPricePrecision(req.DstCurrency)
 	res := c.convertByExchangeRate(req.SrcPriceList, exchangeRate, true, &pricePrecision)
 	return model.ConvertCurrencyResult{
-		ExchangeRate: exchangeRate,
-		DstPrices:    res,
+		ExchangeRate:    exchangeRate,
+		DstPrices:       res,
+		ExchangeRateAge: rateAge,
 	}, nil
 }
 
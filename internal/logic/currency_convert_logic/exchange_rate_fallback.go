@@ -0,0 +1,81 @@
+package currency_convert_logic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"git.garena.com/shopee/platform/service-governance/observability/metric"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+const (
+	exchangeRateFallbackMetricName = "exchange_rate_fallback"
+
+	exchangeRateFallbackAnswered = "answered"
+	exchangeRateFallbackRejected = "rejected"
+	exchangeRateFallbackFailed   = "failed" // no fallback source could answer
+)
+
+// convertCurrencyByFallback tries the fallback sources of caller in order after the requested source failed with
+// sourceErr. the rate of a fallback source is only accepted if it is within tolerance of the last known rate of the
+// requested source, fallback is rejected if the requested source has never been seen
+func (c *CurrencyConvertLogicImpl) convertCurrencyByFallback(ctx context.Context, req model.ConvertCurrencyRequest,
+	fallbackCfg *config.ExchangeRateFallbackConfig, sourceErr error) (model.ConvertCurrencyResult, error) {
+	referenceRate, _, err := c.getExchangeRateAsOfBySource(ctx, req.ExchangeRateSource, req, time.Now().Unix())
+	if err != nil {
+		reportExchangeRateFallback(req.Caller, req.ExchangeRateSource, req.ExchangeRateSource, exchangeRateFallbackRejected)
+		return model.ConvertCurrencyResult{}, cerr.New(fmt.Sprintf("exchange rate fallback rejected, no known rate of source=%v, sourceErr=%v, err=%v",
+			req.ExchangeRateSource, sourceErr, err), uint32(pb.Constant_ERROR_EXCHANGE_RATE_FALLBACK_REJECTED))
+	}
+
+	for _, source := range fallbackCfg.Sources {
+		if source == req.ExchangeRateSource {
+			continue
+		}
+		result, err := c.convertCurrencyBySource(ctx, source, req)
+		if err != nil {
+			logging.GetLogger(ctx).Warn(fmt.Sprintf("[Exchange Rate Fallback] fallback source failed, caller=%v, source=%v, err=%v",
+				req.Caller, source, err))
+			continue
+		}
+		if !isExchangeRateWithinTolerance(result.ExchangeRate, referenceRate, fallbackCfg.Tolerance) {
+			reportExchangeRateFallback(req.Caller, req.ExchangeRateSource, source, exchangeRateFallbackRejected)
+			errMsg := fmt.Sprintf("exchange rate fallback rejected, caller=%v, source=%v, referenceRate=%v, fallbackSource=%v, fallbackRate=%v, tolerance=%v, sourceErr=%v",
+				req.Caller, req.ExchangeRateSource, referenceRate, source, result.ExchangeRate, fallbackCfg.Tolerance, sourceErr)
+			logging.GetLogger(ctx).Error(errMsg)
+			return model.ConvertCurrencyResult{}, cerr.New(errMsg, uint32(pb.Constant_ERROR_EXCHANGE_RATE_FALLBACK_REJECTED))
+		}
+
+		reportExchangeRateFallback(req.Caller, req.ExchangeRateSource, source, exchangeRateFallbackAnswered)
+		logging.GetLogger(ctx).Info(fmt.Sprintf("[Exchange Rate Fallback] answered by fallback source, caller=%v, source=%v, fallbackSource=%v, sourceErr=%v",
+			req.Caller, req.ExchangeRateSource, source, sourceErr))
+		return result, nil
+	}
+
+	reportExchangeRateFallback(req.Caller, req.ExchangeRateSource, req.ExchangeRateSource, exchangeRateFallbackFailed)
+	return model.ConvertCurrencyResult{}, sourceErr
+}
+
+func isExchangeRateWithinTolerance(rate float64, referenceRate float64, tolerance float64) bool {
+	if referenceRate <= 0 {
+		return false
+	}
+	return math.Abs(rate-referenceRate)/referenceRate <= tolerance
+}
+
+func reportExchangeRateFallback(caller string, source model.ExchangeRateSource, fallbackSource model.ExchangeRateSource, result string) {
+	_ = metric.RPCCustomReporter.ReportCounter(exchangeRateFallbackMetricName, map[string]string{
+		"caller":          caller,
+		"source":          strconv.FormatUint(uint64(source), 10),
+		"fallback_source": strconv.FormatUint(uint64(fallbackSource), 10),
+		"result":          result,
+	}, 1)
+}
@@ -0,0 +1,112 @@
+package currency_convert_logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/factors"
+)
+
+type fakeFactorsRepo struct {
+	factors.CalculationFactorsRepo
+	cbSipExchangeRates map[string]map[string]string
+	// order mart cache is empty if rate is 0
+	orderMartExchangeRate float64
+	orderMartRateAge      int64
+}
+
+func (f *fakeFactorsRepo) GetOrderMartExchangeRate(ctx context.Context, srcCurrency string, dstCurrency string) (float64, int64, error) {
+	if f.orderMartExchangeRate == 0 {
+		return 0, 0, cerr.New("order mart cache is empty", uint32(pb.Constant_ERROR_CACHE))
+	}
+	return f.orderMartExchangeRate, f.orderMartRateAge, nil
+}
+
+func (f *fakeFactorsRepo) GetAllExchangeRateMapForCbSip(ctx context.Context) (map[string]map[string]string, error) {
+	return f.cbSipExchangeRates, nil
+}
+
+type fakeExchangeRateHistoryRepo struct {
+	exchange_rate_history.ExchangeRateHistoryRepo
+	rates map[pb.Constant_ExchangeRateSource]map[string]string
+}
+
+func (f *fakeExchangeRateHistoryRepo) Observe(ctx context.Context, source pb.Constant_ExchangeRateSource, rates map[string]string) {
+}
+
+func (f *fakeExchangeRateHistoryRepo) GetExchangeRateAsOf(ctx context.Context, source pb.Constant_ExchangeRateSource, rateKey string, asOf int64) (string, bool, error) {
+	rate, ok := f.rates[source][rateKey]
+	return rate, ok, nil
+}
+
+func TestConvertCurrencyByFallback(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{CommonCfg: &config.CommonConfig{
+		ExchangeRateFallback: map[string]*config.ExchangeRateFallbackConfig{
+			"price-sync": {
+				Sources:   []uint32{uint32(pb.Constant_ORDER_MART_EXCHANGE_RATE), uint32(pb.Constant_CB_SIP_EXCHANGE_RATE)},
+				Tolerance: 0.02,
+			},
+		},
+	}})
+	// order mart rates are against USD
+	history := &fakeExchangeRateHistoryRepo{rates: map[pb.Constant_ExchangeRateSource]map[string]string{
+		pb.Constant_ORDER_MART_EXCHANGE_RATE: {"SGD": "1.35", "MYR": "4.725"},
+	}}
+	newLogic := func(sgdToMyr string) *CurrencyConvertLogicImpl {
+		return NewCurrencyConverterLogic(&fakeFactorsRepo{cbSipExchangeRates: map[string]map[string]string{
+			"SGD": {"MYR": sgdToMyr},
+		}}, history)
+	}
+	req := model.ConvertCurrencyRequest{
+		SrcPriceList:       []int64{100000},
+		ExchangeRateSource: model.ExchangeRateSourceOrderMart,
+		SrcCurrency:        "SGD",
+		DstCurrency:        "MYR",
+		Caller:             "price-sync",
+	}
+
+	result, err := newLogic("3.52").ConvertCurrency(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, model.ExchangeRateSourceCbSipExchangeRate, result.ExchangeRateSource)
+	assert.Equal(t, 3.52, result.ExchangeRate)
+	assert.Equal(t, []int64{352000}, result.DstPrices)
+
+	_, err = newLogic("3.2").ConvertCurrency(ctx, req)
+	assert.Equal(t, uint32(pb.Constant_ERROR_EXCHANGE_RATE_FALLBACK_REJECTED), cerr.Code(err))
+
+	// callers without fallback get the error of requested source
+	req.Caller = "other"
+	_, err = newLogic("3.52").ConvertCurrency(ctx, req)
+	assert.Equal(t, uint32(pb.Constant_ERROR_CACHE), cerr.Code(err))
+}
+
+func TestConvertCurrencyByOrderMartMaxRateAge(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{CommonCfg: &config.CommonConfig{}})
+	req := model.ConvertCurrencyRequest{
+		SrcPriceList:       []int64{100000},
+		ExchangeRateSource: model.ExchangeRateSourceOrderMart,
+		SrcCurrency:        "SGD",
+		DstCurrency:        "MYR",
+		MaxRateAge:         2 * 24 * 60 * 60,
+	}
+
+	result, err := NewCurrencyConverterLogic(&fakeFactorsRepo{orderMartExchangeRate: 3.5, orderMartRateAge: 30 * 60 * 60}, nil).ConvertCurrency(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30*60*60), result.ExchangeRateAge)
+	assert.Equal(t, model.ExchangeRateSourceOrderMart, result.ExchangeRateSource)
+
+	_, err = NewCurrencyConverterLogic(&fakeFactorsRepo{orderMartExchangeRate: 3.5, orderMartRateAge: 3 * 24 * 60 * 60}, nil).ConvertCurrency(ctx, req)
+	assert.Equal(t, uint32(pb.Constant_ERROR_EXCHANGE_RATE_STALE), cerr.Code(err))
+
+	_, err = NewCurrencyConverterLogic(&fakeFactorsRepo{orderMartExchangeRate: 3.5, orderMartRateAge: model.UnknownRateAge}, nil).ConvertCurrency(ctx, req)
+	assert.Equal(t, uint32(pb.Constant_ERROR_EXCHANGE_RATE_STALE), cerr.Code(err))
+}
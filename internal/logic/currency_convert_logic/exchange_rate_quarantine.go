@@ -0,0 +1,16 @@
+package currency_convert_logic
+
+import (
+	"context"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+func (c *CurrencyConvertLogicImpl) ListQuarantinedExchangeRates(ctx context.Context, status pb.Constant_ExchangeRateQuarantineStatus) ([]*model.QuarantinedExchangeRate, error) {
+	return c.exchangeRateHistoryRepo.ListQuarantinedExchangeRates(ctx, status)
+}
+
+func (c *CurrencyConvertLogicImpl) ApproveQuarantinedExchangeRate(ctx context.Context, id uint64, operator string) (*model.QuarantinedExchangeRate, error) {
+	return c.exchangeRateHistoryRepo.ApproveQuarantinedExchangeRate(ctx, id, operator)
+}
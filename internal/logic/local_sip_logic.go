@@ -10,5 +10,8 @@ import (
 type LocalSipLogic interface {
 	GetLocalSipPriceFactors(ctx context.Context, infoType model.LocalSipPriceFactorInfoType, queries []model.GetLocalSipPriceFactorQuery) ([]model.LocalSipPriceFactorInfo, error)
 	CalculateAPriceByPItemForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64, pRegion string, queries []model.LocalSipCalculateAPriceQuery, calculateForCreate bool) ([]model.LocalSipCalculateAPriceResult, error)
+	SimulateAPriceByPItemForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64, pRegion string, queries []model.LocalSipCalculateAPriceQuery, calculateForCreate bool, overrides *model.PriceFactorOverrides) ([]model.LocalSipCalculateAPriceResult, []model.LocalSipCalculateAPriceResult, error)
+	ReplayAPriceByFactorSnapForLocalSip(ctx context.Context, snap *pb.LocalSipPriceFactorSnap, queries []model.LocalSipCalculateAPriceQuery) []model.LocalSipCalculateAPriceResult
+	CalculatePPriceByAPriceForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64, pRegion string, queries []model.LocalSipCalculatePPriceQuery, calculateForCreate bool) ([]model.LocalSipCalculatePPriceResult, error)
 	CalculateAItemOPL(ctx context.Context, pRegion string, pItemId uint64, aShopId uint64, aRegion string) (*pb.CustomizedOPL, error)
 }
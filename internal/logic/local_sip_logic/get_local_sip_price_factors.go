@@ -8,6 +8,7 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/local_sip_fee_config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
 )
 
@@ -44,8 +45,12 @@ func (l *LocalSipLogicImpl) getLocalSipPriceFactorShippingFeeInfo(ctx context.Co
 				ShippingFeePrice: dbInfo.ShippingFeePrice,
 			})
 		}
+		// fee table is not capped if limit of region pair is not configured
+		limitCfg, _ := config.GetLocalSipSettingLimitConfigByRegions(query.PRegion, query.ARegion)
+		tableCfg := config.GetLocalSipFeeTableConfig(query.PRegion, query.ARegion)
 		finalResults[i] = model.LocalSipPriceFactorInfo{
-			LocalShippingFeeInfo: shippingFeeInfos,
+			LocalShippingFeeInfo:  shippingFeeInfos,
+			LocalShippingFeeTable: newLocalSipFeeTableInfo(tableCfg.ShippingFee, limitCfg.ShippingFeeMin, limitCfg.ShippingFeeMax),
 		}
 	}
 	return finalResults, nil
@@ -71,13 +76,28 @@ func (l *LocalSipLogicImpl) getLocalSipPriceFactorHiddenFeeInfo(ctx context.Cont
 				HiddenPrice: dbInfo.HiddenPrice,
 			})
 		}
+		limitCfg, _ := config.GetLocalSipSettingLimitConfigByRegions(query.PRegion, query.ARegion)
+		tableCfg := config.GetLocalSipFeeTableConfig(query.PRegion, query.ARegion)
 		finalResults[i] = model.LocalSipPriceFactorInfo{
-			LocalHiddenFeeInfo: hiddenFeeInfos,
+			LocalHiddenFeeInfo:  hiddenFeeInfos,
+			LocalHiddenFeeTable: newLocalSipFeeTableInfo(tableCfg.HiddenPrice, limitCfg.InitHiddenPriceMin, limitCfg.InitHiddenPriceMax),
 		}
 	}
 	return finalResults, nil
 }
 
+func newLocalSipFeeTableInfo(setting config.LocalSipFeeTableSetting, minFee float64, maxFee float64) *model.LocalSipFeeTableInfo {
+	info := &model.LocalSipFeeTableInfo{
+		Mode:   local_sip_fee_config.FeeTableMode(setting),
+		MinFee: minFee,
+		MaxFee: maxFee,
+	}
+	if info.Mode == pb.Constant_LOCAL_SIP_FEE_TABLE_MODE_BASE_PLUS_EXTRA {
+		info.PerExtraGram = setting.PerExtraGram
+	}
+	return info
+}
+
 func (l *LocalSipLogicImpl) getLocalSipPriceFactorBasicInfo(ctx context.Context, queries []model.GetLocalSipPriceFactorQuery) ([]model.LocalSipPriceFactorInfo, error) {
 	allLocalSipPriceConfig, err := l.factors.GetAllLocalSipPriceConfig(ctx)
 	if err != nil {
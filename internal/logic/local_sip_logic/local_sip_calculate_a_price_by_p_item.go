@@ -18,9 +18,31 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/service"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logicutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/slice"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
 func (l *LocalSipLogicImpl) CalculateAPriceByPItemForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64, pRegion string, queries []model.LocalSipCalculateAPriceQuery, calculateForCreate bool) ([]model.LocalSipCalculateAPriceResult, error) {
+	priceFactorsList, err := l.getLocalSipPriceFactors(ctx, pShopId, pItemId, pRegion, queries, calculateForCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, candidateVersions, hasShadow := resolveLocalSipFormulaVersions(queries)
+	results := calcLocalSipAPriceByFactors(ctx, versions, true, queries, priceFactorsList)
+
+	if hasShadow {
+		logicutil.RunFormulaShadow(ctx, logicutil.FormulaLocalSip, func(ctx context.Context) []logicutil.FormulaShadowResult {
+			candidateResults := calcLocalSipAPriceByFactors(ctx, candidateVersions, false, queries, priceFactorsList)
+			return compareLocalSipAPriceResults(results, candidateVersions, candidateResults)
+		})
+	}
+	return results, nil
+}
+
+// getLocalSipPriceFactors gathers factors of local SIP A price formula for each query, the length and order is same like queries
+func (l *LocalSipLogicImpl) getLocalSipPriceFactors(ctx context.Context, pShopId uint64, pItemId uint64, pRegion string, queries []model.LocalSipCalculateAPriceQuery, calculateForCreate bool) ([]*model.LocalSipPriceFactors, error) {
 	aRegions := model.PickUniqARegionFromLocalSipCalculateAPriceQueries(queries)
 	localSipConfigMap, err := l.factors.GetLocalSipConfigByRegionBatch(ctx, pRegion, aRegions)
 	if err != nil {
@@ -54,22 +76,31 @@ func (l *LocalSipLogicImpl) CalculateAPriceByPItemForLocalSip(ctx context.Contex
 		}
 	}
 
+	// package dimension is only needed if volumetric weight is enabled in any A region
+	var pItemDimension *model.ItemDimension
+	for _, aRegion := range aRegions {
+		if _, ok := config.GetVolumetricWeightSetting(aRegion); ok {
+			pItemDimension = l.factors.GetPItemDimensionForLocalSip(ctx, pItemId, pRegion)
+			break
+		}
+	}
+
 	// P item id  + A shop id is unique already for weight,
 	// and why we don't use A item id here, since it is possible to be 0 (create A item case)
-	weightMapByAShopId := make(map[uint64]int64)
+	weightMapByAShopId := make(map[uint64]model.ChargeableWeight)
 	for _, query := range queries {
 		aWeight := int64(0)
 		if val, exist := aItemDataByAShopIdMap[query.AShopId]; exist {
 			aWeight = int64(val.GetAffiRealWeight())
 		}
 
-		weightMapByAShopId[query.AShopId] = factors.GetCalcWeightByAItemAndPItemWeight(aWeight, pItemData.Weight)
+		weightMapByAShopId[query.AShopId] = factors.GetChargeableWeight(aWeight, pItemData.Weight, pItemDimension, query.ARegion)
 	}
 
 	shippingFeeQueries := make([]model.LocalSipShippingFeeQuery, 0)
 	initHiddenFeeQueries := make([]model.LocalSipHiddenPriceQuery, 0)
 	for _, query := range queries {
-		weight := weightMapByAShopId[query.AShopId]
+		weight := weightMapByAShopId[query.AShopId].Weight
 
 		shippingFeeQueries = append(shippingFeeQueries, model.LocalSipShippingFeeQuery{
 			QueryId:              query.QueryId,
@@ -102,7 +133,7 @@ func (l *LocalSipLogicImpl) CalculateAPriceByPItemForLocalSip(ctx context.Contex
 		return nil, cerr.New(fmt.Sprintf("len(shippingFeeResults) != len(shippingFeeQueries), queries=%+v, results=%+v", shippingFeeQueries, shippingFeeResults), uint32(pb.Constant_ERROR_INTERNAL))
 	}
 
-	hiddenPriceResults, err := l.factors.GetInitialHiddenPriceForLocalSip(ctx, pItemId, pShopId, pRegion, initHiddenFeeQueries)
+	hiddenPriceResults, err := l.factors.GetInitialHiddenPriceForLocalSip(ctx, pItemId, pShopId, pRegion, initHiddenFeeQueries, calculateForCreate)
 	if err != nil {
 		return nil, err
 	}
@@ -111,37 +142,19 @@ func (l *LocalSipLogicImpl) CalculateAPriceByPItemForLocalSip(ctx context.Contex
 		return nil, cerr.New(fmt.Sprintf("len(hiddenPriceResults) != len(initHiddenFeeQueries), queries=%+v, results=%+v", initHiddenFeeQueries, hiddenPriceResults), uint32(pb.Constant_ERROR_INTERNAL))
 	}
 
-	finalResults := make([]model.LocalSipCalculateAPriceResult, len(queries))
+	priceFactorsList := make([]*model.LocalSipPriceFactors, len(queries))
 	for i, query := range queries {
-
 		shippingFeeRes := shippingFeeResults[i]
 		hiddenPriceRes := hiddenPriceResults[i]
 		if shippingFeeRes.Err != nil {
-			finalResults[i] = model.LocalSipCalculateAPriceResult{
-				Err:      shippingFeeRes.Err,
-				AShopId:  query.AShopId,
-				ARegion:  query.ARegion,
-				AItemId:  query.AItemId,
-				AModelId: query.AModelId,
-			}
+			priceFactorsList[i] = &model.LocalSipPriceFactors{Err: shippingFeeRes.Err}
 			continue
 		}
 		if hiddenPriceRes.Err != nil {
-			finalResults[i] = model.LocalSipCalculateAPriceResult{
-				Err:      hiddenPriceRes.Err,
-				AShopId:  query.AShopId,
-				ARegion:  query.ARegion,
-				AItemId:  query.AItemId,
-				AModelId: query.AModelId,
-			}
+			priceFactorsList[i] = &model.LocalSipPriceFactors{Err: hiddenPriceRes.Err}
 			continue
 		}
 
-		pNormalPrice := query.PNormalPrice
-
-		weight := weightMapByAShopId[query.AShopId]
-		realWeight := calcutil.DbWeightToGram(weight)
-
 		var itemMargin int64
 		if aItemDataByAShopIdMap[query.AShopId] == nil {
 			// if item map not found, only continue process for create scenario
@@ -151,61 +164,188 @@ func (l *LocalSipLogicImpl) CalculateAPriceByPItemForLocalSip(ctx context.Contex
 		} else {
 			itemMargin = int64(aItemDataByAShopIdMap[query.AShopId].GetItemMargin())
 		}
-		realItemMargin := calcutil.GetItemMargin(calcutil.ToRealRatio(itemMargin))
 
 		if shopMappings[query.AShopId] == nil {
 			return nil, cerr.New(fmt.Sprintf("cannot find shop map for aShopId=%v", query.AShopId), uint32(pb.Constant_ERROR_NOT_FOUND))
 		}
 		shopMargin := shopMappings[query.AShopId].GetShopMargin()
-		realShopMargin := calcutil.GetShopMargin(calcutil.ToRealRatio(shopMargin))
 
-		localPriceCfg := localSipConfigMap[query.ARegion]
-		realHiddenPrice := hiddenPriceRes.HiddenPrice
-		realShippingFee := shippingFeeRes.ShippingFee
+		chargeableWeight := weightMapByAShopId[query.AShopId]
+		priceFactorsList[i] = &model.LocalSipPriceFactors{
+			Weight:          calcutil.DbWeightToGram(chargeableWeight.Weight),
+			ItemMargin:      calcutil.GetItemMargin(calcutil.ToRealRatio(itemMargin)),
+			ShopMargin:      calcutil.GetShopMargin(calcutil.ToRealRatio(shopMargin)),
+			InitHiddenPrice: hiddenPriceRes.HiddenPrice,
+			ShippingFee:     shippingFeeRes.ShippingFee,
+			PriceConfig:     localSipConfigMap[query.ARegion],
+
+			WeightSource:     chargeableWeight.Source,
+			VolumetricWeight: calcutil.DbWeightToGram(chargeableWeight.VolumetricWeight),
+		}
+	}
+
+	return priceFactorsList, nil
+}
+
+type localSipAPriceFormula func(ctx context.Context, query model.LocalSipCalculateAPriceQuery, priceFactors *model.LocalSipPriceFactors) model.LocalSipCalculateAPriceResult
+
+// localSipAPriceFormulas local SIP A price formula of each version, a new version is added here and rolled out by FormulaVersionConfig
+var localSipAPriceFormulas = map[string]localSipAPriceFormula{
+	logicutil.FormulaVersionV1: calcLocalSipAPriceV1,
+}
+
+func init() {
+	for version := range localSipAPriceFormulas {
+		config.RegisterFormulaVersion(logicutil.FormulaLocalSip, version)
+	}
+}
+
+// resolveLocalSipFormulaVersions A shop of each query decides its formula version
+func resolveLocalSipFormulaVersions(queries []model.LocalSipCalculateAPriceQuery) (versions []string, candidateVersions []string, hasShadow bool) {
+	targets := make([]logicutil.FormulaVersionTarget, len(queries))
+	for i, query := range queries {
+		targets[i] = logicutil.FormulaVersionTarget{Region: query.ARegion, ShopId: query.AShopId}
+	}
+	return logicutil.ResolveFormulaVersions(logicutil.FormulaLocalSip, targets)
+}
+
+// calcLocalSipAPriceByFactors calculates A prices of all queries with the given factors and formula version of each query, no IO involved.
+// guardrail is skipped for shadow calculation, so that outliers of candidate version are not reported.
+// prices rejected by guardrail are kept in result for comparison, but not returned to caller
+func calcLocalSipAPriceByFactors(ctx context.Context, versions []string, checkGuardrail bool, queries []model.LocalSipCalculateAPriceQuery, priceFactorsList []*model.LocalSipPriceFactors) []model.LocalSipCalculateAPriceResult {
+	finalResults := make([]model.LocalSipCalculateAPriceResult, len(queries))
+	for i, query := range queries {
+		priceFactors := priceFactorsList[i]
+		if priceFactors.Err != nil {
+			finalResults[i] = model.LocalSipCalculateAPriceResult{
+				Err:      priceFactors.Err,
+				AShopId:  query.AShopId,
+				ARegion:  query.ARegion,
+				AItemId:  query.AItemId,
+				AModelId: query.AModelId,
+			}
+			continue
+		}
 
-		realCountryMargin := calcutil.GetLocalSipCountryMargin(localPriceCfg)
-		realExchangeRate := calcutil.GetLocalSipExchangeRate(localPriceCfg)
+		formula, ok := localSipAPriceFormulas[versions[i]]
+		if !ok {
+			finalResults[i] = model.LocalSipCalculateAPriceResult{
+				Err:            cerr.New(fmt.Sprintf("local sip formula version=%v is not supported", versions[i]), uint32(pb.Constant_ERROR_INTERNAL)),
+				AShopId:        query.AShopId,
+				ARegion:        query.ARegion,
+				AItemId:        query.AItemId,
+				AModelId:       query.AModelId,
+				FormulaVersion: versions[i],
+			}
+			continue
+		}
 
-		var resultNormalPrice int64
-		if query.PNormalPrice > 0 {
-			localSipANormalPrice := calcutil.CalculateAffiPriceForLocalSip(ctx, calcutil.ToRealPrice(pNormalPrice), realWeight, realItemMargin, realShopMargin, realHiddenPrice, realShippingFee, localPriceCfg, nil)
-			resultNormalPrice = calcutil.ToDBPrice(calcutil.PriceRoundUpByCountry(query.ARegion, localSipANormalPrice))
+		result := formula(ctx, query, priceFactors)
+		result.FormulaVersion = versions[i]
+		if result.PriceCalSnap != nil {
+			result.PriceCalSnap.FormulaVersion = proto.String(versions[i])
+		}
 
-			logging.GetLogger(ctx).Info(fmt.Sprintf("[Local SIP] Calc local sip normal price, "+
-				"query=%+v, pNormalPrice=%v, weight=%v, itemMargin=%v, shopMargin=%v, localPriceConfig=%v, realHiddenPrice=%v, realShippingFee=%v | result=%v",
-				query, pNormalPrice, weight, itemMargin, shopMargin, cutil.JSONEncode(localPriceCfg), realHiddenPrice, realShippingFee, localSipANormalPrice))
+		if checkGuardrail && result.Err == nil {
+			result.Guardrail = checkLocalSipPriceGuardrail(ctx, query, priceFactors, result)
+			if result.Guardrail.IsRejected() {
+				result.Err = calcutil.NewPriceGuardrailRejectedError(query.ARegion, result.Guardrail)
+			}
 		}
+		finalResults[i] = result
+	}
+
+	return finalResults
+}
+
+// calcLocalSipAPriceV1 local SIP A price formula v1
+func calcLocalSipAPriceV1(ctx context.Context, query model.LocalSipCalculateAPriceQuery, priceFactors *model.LocalSipPriceFactors) model.LocalSipCalculateAPriceResult {
+	prices := pricekernel.CalcLocalSipAPrice(pricekernel.LocalSipInput{
+		PNormalPrice:     query.PNormalPrice,
+		PPromotionPrices: query.PPromotionPrices,
+	}, calcutil.NewLocalSipKernelFactors(query.ARegion, priceFactors))
+
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[Local SIP] Calc local sip prices, "+
+		"query=%+v, weight=%v, itemMargin=%v, shopMargin=%v, localPriceConfig=%v, realHiddenPrice=%v, realShippingFee=%v | normalPrice=%v, promotionPrices=%v",
+		query, priceFactors.Weight, priceFactors.ItemMargin, priceFactors.ShopMargin, cutil.JSONEncode(priceFactors.PriceConfig), priceFactors.InitHiddenPrice, priceFactors.ShippingFee, prices.NormalPrice, prices.PromotionPrices))
 
-		promotionPrices := make([]int64, 0)
-		for _, pPromotionPrice := range query.PPromotionPrices {
-			localSipAPromotionPrice := calcutil.CalculateAffiPriceForLocalSip(ctx, calcutil.ToRealPrice(pPromotionPrice), realWeight, realItemMargin, realShopMargin, realHiddenPrice, realShippingFee, localPriceCfg, nil)
-			promotionPrices = append(promotionPrices, calcutil.ToDBPrice(calcutil.PriceRoundUpByCountry(query.ARegion, localSipAPromotionPrice)))
+	var roundingStrategy string
+	if len(prices.Traces) > 0 {
+		roundingStrategy = prices.Traces[0].RoundingRule
+	}
 
-			logging.GetLogger(ctx).Info(fmt.Sprintf("[Local SIP] Calc local sip promotion price, "+
-				"query=%+v, pPromotionPrice=%v, weight=%v, itemMargin=%v, shopMargin=%v, localpriceConfig=%v, realHiddenPrice=%v, realShippingFee=%v | result=%v",
-				query, pPromotionPrice, weight, itemMargin, shopMargin, cutil.JSONEncode(localPriceCfg), realHiddenPrice, realShippingFee, localSipAPromotionPrice))
+	return model.LocalSipCalculateAPriceResult{
+		NormalPrice:      prices.NormalPrice,
+		PromotionPrices:  prices.PromotionPrices,
+		AShopId:          query.AShopId,
+		ARegion:          query.ARegion,
+		AItemId:          query.AItemId,
+		AModelId:         query.AModelId,
+		PriceCalSnap:     buildLocalSipPriceFactorSnap(priceFactors),
+		Traces:           prices.Traces,
+		RoundingStrategy: roundingStrategy,
+	}
+}
+
+// checkLocalSipPriceGuardrail A prices are checked against P prices converted to A currency
+func checkLocalSipPriceGuardrail(ctx context.Context, query model.LocalSipCalculateAPriceQuery, priceFactors *model.LocalSipPriceFactors, result model.LocalSipCalculateAPriceResult) model.PriceGuardrailResult {
+	exchangeRate := calcutil.GetLocalSipExchangeRate(priceFactors.PriceConfig)
+	var guardrail model.PriceGuardrailResult
+	if query.PNormalPrice > 0 {
+		guardrail = guardrail.Merge(calcutil.CheckPriceGuardrail(ctx, query.ARegion, model.PriceNameNormal,
+			convertPPriceForLocalSip(query.PNormalPrice, exchangeRate), calcutil.ToRealPrice(result.NormalPrice)))
+	}
+	for i, pPromotionPrice := range query.PPromotionPrices {
+		if i >= len(result.PromotionPrices) {
+			break
 		}
+		guardrail = guardrail.Merge(calcutil.CheckPriceGuardrail(ctx, query.ARegion, model.PriceNamePromotion,
+			convertPPriceForLocalSip(pPromotionPrice, exchangeRate), calcutil.ToRealPrice(result.PromotionPrices[i])))
+	}
+	return guardrail
+}
 
-		finalResults[i] = model.LocalSipCalculateAPriceResult{
-			NormalPrice:     resultNormalPrice,
-			PromotionPrices: promotionPrices,
-			AShopId:         query.AShopId,
-			ARegion:         query.ARegion,
-			AItemId:         query.AItemId,
-			AModelId:        query.AModelId,
-			PriceCalSnap: &pb.LocalSipPriceFactorSnap{
-				Weight:          proto.Float64(realWeight),
-				ShopMargin:      proto.Float64(realShopMargin),
-				ItemMargin:      proto.Float64(realItemMargin),
-				ShippingFee:     proto.Float64(realShippingFee),
-				CountryMargin:   proto.Float64(realCountryMargin),
-				ExchangeRate:    proto.Float64(realExchangeRate),
-				InitHiddenPrice: proto.Float64(realHiddenPrice),
-			},
+// compareLocalSipAPriceResults compares the results of live version and candidate version query by query
+func compareLocalSipAPriceResults(results []model.LocalSipCalculateAPriceResult, candidateVersions []string, candidateResults []model.LocalSipCalculateAPriceResult) []logicutil.FormulaShadowResult {
+	shadowResults := make([]logicutil.FormulaShadowResult, 0, len(results))
+	for i, result := range results {
+		candidate := candidateResults[i]
+		shadowResult := logicutil.FormulaShadowResult{Region: result.ARegion, LiveVersion: result.FormulaVersion, CandidateVersion: candidateVersions[i]}
+		switch {
+		case (result.Err == nil || result.Guardrail.IsRejected()) != (candidate.Err == nil):
+			shadowResult.Diff = fmt.Sprintf("err: %v -> %v", result.Err, candidate.Err)
+		case result.NormalPrice != candidate.NormalPrice || !slice.EqualInt64(result.PromotionPrices, candidate.PromotionPrices):
+			shadowResult.Diff = fmt.Sprintf("normal: %v -> %v, promotion: %v -> %v",
+				result.NormalPrice, candidate.NormalPrice, result.PromotionPrices, candidate.PromotionPrices)
 		}
+		shadowResults = append(shadowResults, shadowResult)
+	}
+	return shadowResults
+}
+
+// convertPPriceForLocalSip converts db P price to real value in A currency, local SIP exchange rate is P currency per A currency
+func convertPPriceForLocalSip(pPrice int64, exchangeRate float64) float64 {
+	if exchangeRate <= 0 {
+		return 0
 	}
+	return calcutil.ToRealPrice(pPrice) / exchangeRate
+}
+
+func buildLocalSipPriceFactorSnap(priceFactors *model.LocalSipPriceFactors) *pb.LocalSipPriceFactorSnap {
+	return &pb.LocalSipPriceFactorSnap{
+		Weight:          proto.Float64(priceFactors.Weight),
+		ShopMargin:      proto.Float64(priceFactors.ShopMargin),
+		ItemMargin:      proto.Float64(priceFactors.ItemMargin),
+		ShippingFee:     proto.Float64(priceFactors.ShippingFee),
+		CountryMargin:   proto.Float64(calcutil.GetLocalSipCountryMargin(priceFactors.PriceConfig)),
+		ExchangeRate:    proto.Float64(calcutil.GetLocalSipExchangeRate(priceFactors.PriceConfig)),
+		InitHiddenPrice: proto.Float64(priceFactors.InitHiddenPrice),
 
-	return finalResults, nil
+		WeightSource:     proto.Uint32(uint32(priceFactors.WeightSource)),
+		VolumetricWeight: proto.Float64(priceFactors.VolumetricWeight),
+
+		PriceConfigMissing: proto.Bool(priceFactors.PriceConfig == nil),
+	}
 }
 
 func (l *LocalSipLogicImpl) CalculateAItemOPL(ctx context.Context, pRegion string, pItemId uint64, aShopId uint64, aRegion string) (*pb.CustomizedOPL, error) {
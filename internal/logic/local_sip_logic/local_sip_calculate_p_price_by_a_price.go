@@ -0,0 +1,72 @@
+package local_sip_logic
+
+import (
+	"context"
+	"fmt"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// CalculatePPriceByAPriceForLocalSip calculates the P normal price required to get the target A price of each A shop,
+// with the same factors used by CalculateAPriceByPItemForLocalSip
+func (l *LocalSipLogicImpl) CalculatePPriceByAPriceForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64, pRegion string, queries []model.LocalSipCalculatePPriceQuery, calculateForCreate bool) ([]model.LocalSipCalculatePPriceResult, error) {
+	pCurrency, err := config.GetCurrencyByRegion(pRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	factorQueries := make([]model.LocalSipCalculateAPriceQuery, 0, len(queries))
+	for _, query := range queries {
+		factorQueries = append(factorQueries, model.LocalSipCalculateAPriceQuery{
+			QueryId:              query.QueryId,
+			AShopId:              query.AShopId,
+			ARegion:              query.ARegion,
+			AItemId:              query.AItemId,
+			AModelId:             query.AModelId,
+			LeafCategoryId:       query.LeafCategoryId,
+			EnabledChannelIdList: query.EnabledChannelIdList,
+		})
+	}
+	priceFactorsList, err := l.getLocalSipPriceFactors(ctx, pShopId, pItemId, pRegion, factorQueries, calculateForCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.LocalSipCalculatePPriceResult, len(queries))
+	for i, query := range queries {
+		results[i] = model.LocalSipCalculatePPriceResult{
+			AShopId:  query.AShopId,
+			ARegion:  query.ARegion,
+			AItemId:  query.AItemId,
+			AModelId: query.AModelId,
+		}
+		priceFactors := priceFactorsList[i]
+		if priceFactors.Err != nil {
+			results[i].Err = priceFactors.Err
+			continue
+		}
+		results[i].PriceCalSnap = buildLocalSipPriceFactorSnap(priceFactors)
+
+		pPrice, aPrice, ok := calcutil.CalcPDBPriceForLocalSip(ctx, query.TargetAPrice, query.ARegion, pCurrency, priceFactors.Weight,
+			priceFactors.ItemMargin, priceFactors.ShopMargin, priceFactors.InitHiddenPrice, priceFactors.ShippingFee, priceFactors.PriceConfig)
+
+		logging.GetLogger(ctx).Info(fmt.Sprintf("[Local SIP] Calc P price by A price for local sip, "+
+			"query=%+v, pCurrency=%v, priceFactors=%+v | result: pPrice=%v, aPrice=%v, ok=%v",
+			query, pCurrency, priceFactors, pPrice, aPrice, ok))
+
+		if !ok {
+			results[i].Err = cerr.New(fmt.Sprintf("no positive p price can reach target a price=%v", query.TargetAPrice), uint32(pb.Constant_ERROR_TARGET_PRICE_UNREACHABLE))
+			continue
+		}
+		results[i].PPrice = pPrice
+		results[i].APrice = aPrice
+		results[i].IsExactMatch = aPrice == query.TargetAPrice
+	}
+
+	return results, nil
+}
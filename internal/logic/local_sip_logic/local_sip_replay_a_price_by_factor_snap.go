@@ -0,0 +1,44 @@
+package local_sip_logic
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logicutil"
+)
+
+// ReplayAPriceByFactorSnapForLocalSip recalculates A prices purely from the factor snap returned by CalculateAPriceByPItemForLocalSip,
+// with the formula version recorded in snap. no DB, cache or SPEX is called
+func (l *LocalSipLogicImpl) ReplayAPriceByFactorSnapForLocalSip(ctx context.Context, snap *pb.LocalSipPriceFactorSnap, queries []model.LocalSipCalculateAPriceQuery) []model.LocalSipCalculateAPriceResult {
+	priceFactors := newLocalSipPriceFactorsFromSnap(snap)
+
+	priceFactorsList := make([]*model.LocalSipPriceFactors, 0, len(queries))
+	for range queries {
+		priceFactorsList = append(priceFactorsList, priceFactors)
+	}
+
+	versions := logicutil.ReplayFormulaVersions(snap.GetFormulaVersion(), len(queries))
+	return calcLocalSipAPriceByFactors(ctx, versions, true, queries, priceFactorsList)
+}
+
+// newLocalSipPriceFactorsFromSnap is the reverse of buildLocalSipPriceFactorSnap, PriceConfig is nil if it was missing
+// in the calculation, so the P price is replayed as A price as it was
+func newLocalSipPriceFactorsFromSnap(snap *pb.LocalSipPriceFactorSnap) *model.LocalSipPriceFactors {
+	priceFactors := &model.LocalSipPriceFactors{
+		Weight:          snap.GetWeight(),
+		ItemMargin:      snap.GetItemMargin(),
+		ShopMargin:      snap.GetShopMargin(),
+		InitHiddenPrice: snap.GetInitHiddenPrice(),
+		ShippingFee:     snap.GetShippingFee(),
+	}
+	if !snap.GetPriceConfigMissing() {
+		priceFactors.PriceConfig = &model.CommonPriceConfig{
+			Buffer:       proto.Float64(snap.GetCountryMargin()),
+			ExchangeRate: proto.Float64(snap.GetExchangeRate()),
+		}
+	}
+	return priceFactors
+}
@@ -0,0 +1,43 @@
+package local_sip_logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logicutil"
+)
+
+func TestReplayAPriceByFactorSnapForLocalSip(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{})
+	queries := []model.LocalSipCalculateAPriceQuery{{AShopId: 1, ARegion: "MY", PNormalPrice: 1230000}}
+	l := &LocalSipLogicImpl{}
+
+	tests := []struct {
+		name         string
+		priceFactors *model.LocalSipPriceFactors
+	}{
+		{"price config missing", &model.LocalSipPriceFactors{Weight: 500, InitHiddenPrice: 1, ShippingFee: 2}},
+		{"price config found", &model.LocalSipPriceFactors{Weight: 500, InitHiddenPrice: 1, ShippingFee: 2, PriceConfig: &model.CommonPriceConfig{
+			Buffer:       proto.Float64(1.1),
+			ExchangeRate: proto.Float64(3.2),
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live := calcLocalSipAPriceByFactors(ctx, []string{logicutil.FormulaVersionV1}, false, queries, []*model.LocalSipPriceFactors{tt.priceFactors})
+			assert.NoError(t, live[0].Err)
+			assert.Equal(t, tt.priceFactors.PriceConfig == nil, live[0].PriceCalSnap.GetPriceConfigMissing())
+
+			replayed := l.ReplayAPriceByFactorSnapForLocalSip(ctx, live[0].PriceCalSnap, queries)
+			assert.NoError(t, replayed[0].Err)
+			assert.Equal(t, live[0].NormalPrice, replayed[0].NormalPrice)
+			assert.Equal(t, live[0].Traces[0].Formula, replayed[0].Traces[0].Formula)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package local_sip_logic
+
+import (
+	"context"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+)
+
+// SimulateAPriceByPItemForLocalSip calculates A prices with the fetched factors as baseline, and again with the overridden factors.
+// overridden factors only live in this calculation, they are never persisted or cached
+func (l *LocalSipLogicImpl) SimulateAPriceByPItemForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64, pRegion string, queries []model.LocalSipCalculateAPriceQuery, calculateForCreate bool, overrides *model.PriceFactorOverrides) (baseline []model.LocalSipCalculateAPriceResult, overridden []model.LocalSipCalculateAPriceResult, err error) {
+	priceFactorsList, err := l.getLocalSipPriceFactors(ctx, pShopId, pItemId, pRegion, queries, calculateForCreate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	overriddenFactorsList := make([]*model.LocalSipPriceFactors, 0, len(priceFactorsList))
+	for _, priceFactors := range priceFactorsList {
+		overriddenFactorsList = append(overriddenFactorsList, overrideLocalSipPriceFactors(priceFactors, overrides))
+	}
+
+	versions, _, _ := resolveLocalSipFormulaVersions(queries)
+	return calcLocalSipAPriceByFactors(ctx, versions, true, queries, priceFactorsList),
+		calcLocalSipAPriceByFactors(ctx, versions, true, queries, overriddenFactorsList), nil
+}
+
+// overrideLocalSipPriceFactors returns a copy of priceFactors with overrides applied, priceFactors and its price config are untouched
+func overrideLocalSipPriceFactors(priceFactors *model.LocalSipPriceFactors, overrides *model.PriceFactorOverrides) *model.LocalSipPriceFactors {
+	res := *priceFactors
+	if overrides == nil || res.Err != nil {
+		return &res
+	}
+
+	model.OverrideFactor(&res.ShopMargin, overrides.ShopMargin)
+	model.OverrideFactor(&res.ItemMargin, overrides.ItemMargin)
+	model.OverrideFactor(&res.InitHiddenPrice, overrides.HiddenFee)
+
+	if overrides.ExchangeRate != nil || overrides.CountryMargin != nil {
+		// price config is shared by queries of the same A region, override on a copy
+		priceConfig := model.CommonPriceConfig{}
+		if res.PriceConfig != nil {
+			priceConfig = *res.PriceConfig
+		}
+		if overrides.ExchangeRate != nil {
+			priceConfig.ExchangeRate = overrides.ExchangeRate
+		}
+		if overrides.CountryMargin != nil {
+			priceConfig.Buffer = overrides.CountryMargin
+		}
+		res.PriceConfig = &priceConfig
+	}
+	return &res
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"github.com/shopspring/decimal"
+
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 )
 
@@ -18,6 +20,34 @@ type CbSipCalculateAPriceByPItemRequest struct {
 	CalculateForCreate bool
 }
 
+type CbSipCalculatePPriceByAPriceRequest struct {
+	MerchantId     uint64
+	MerchantRegion string
+	PShopId        uint64
+	PRegion        string
+	PItemId        uint64
+
+	AShopId            uint64
+	ARegion            string
+	AItemId            uint64
+	Queries            []CbSipPPriceByAPriceQuery
+	CalculateForCreate bool
+}
+
+type CbSipPPriceByAPriceQuery struct {
+	AModelId           uint64
+	TargetANormalPrice int64
+}
+
+type CbSipCalculatePPriceByAPriceResult struct {
+	Err                error
+	PItemPrice         int64
+	PItemPriceCurrency string
+	ANormalPrice       int64 // A normal price calculated by PItemPrice
+	IsExactMatch       bool
+	Snap               *pb.CbSipPriceFactorSnap
+}
+
 type CbSipCalculateAOPLByPItemRequest struct {
 	PRegion string
 	PItemId uint64
@@ -34,11 +64,16 @@ type AItemCbSipQueryId struct {
 }
 
 type CbSipCalculateAPriceByPItemResult struct {
+	Err                      error // prices are not returned to caller if error
 	ANormalPrice             int64
 	APromotionPrice          int64
 	ASettlementPrice         int64
 	ASettlementPriceCurrency string
 	Snap                     *pb.CbSipPriceFactorSnap
+	Traces                   []*PriceTrace
+	RoundingStrategy         string // rounding strategy applied to A prices
+	Guardrail                PriceGuardrailResult
+	FormulaVersion           string
 }
 
 type CbSipCalculateAOPLByPItemResult struct {
@@ -68,8 +103,11 @@ type HiddenPriceConf struct {
 	WeightStep  int64
 	Adjustment  int64
 	// debug info
-	HpfnKey      string
-	HpfnCfgLevel hpfnConfigLevel
+	HpfnKey        string
+	HpfnCfgLevel   hpfnConfigLevel
+	WeightRange    int64  // weight bucket of the matched rate table row, the upper bound of weight
+	RateTableRowId int64  // id in hpfn_config_tab, 0 for default level
+	DescInfo       string // desc of the matched rate table row
 }
 
 type RateTableCfg struct {
@@ -113,9 +151,22 @@ type CbSipCalculateSipItemPriceSingleQuery struct {
 }
 
 type CbSipCalculateSipItemPriceResult struct {
-	ModelId        uint64
-	CbSipItemPrice int64
-	Currency       string
+	ModelId           uint64
+	CbSipItemPrice    int64
+	Currency          string
+	FormulaVersion    string
+	ChannelHiddenFees []*ChannelHiddenFee // hidden fee of each enabled channel, the chosen one is used in the price
+}
+
+// CbSipItemPriceFactors factors of CB SIP item price formula, fee rates are real ratios
+type CbSipItemPriceFactors struct {
+	ReferenceServiceFee decimal.Decimal
+	TransactionFee      decimal.Decimal
+	CommissionFee       decimal.Decimal
+	ExchangeRate        decimal.Decimal // merchant currency to region currency
+	HiddenPrice         decimal.Decimal
+	Currency            string // merchant currency
+	ChannelHiddenFees   []*ChannelHiddenFee
 }
 
 type CbSipGetRegionLevelConfigRequest struct {
@@ -172,6 +223,57 @@ const (
 	RuleRegionSetting       = CbSipAHiddenFeeInfoType(pb.Constant_RULE_HPFN_REGION_SETTING)
 )
 
+type CbSipResolveHiddenFeeConfigRequest struct {
+	PShopId        uint64
+	PItemId        uint64
+	PRegion        string
+	ARegion        string
+	MerchantRegion string
+	Weight         float64 // gram, weight of P item is used if not positive
+}
+
+type CbSipResolveHiddenFeeConfigResult struct {
+	Weight          float64 // gram
+	HiddenPrice     float64 // real value in A currency
+	HiddenFeeConfig *pb.HiddenFeeConfigInfo
+}
+
+type CbSipPreviewHiddenFeeRateTableRequest struct {
+	HpfnKey        string // P item is ignored if set
+	PShopId        uint64
+	PItemId        uint64
+	PRegion        string
+	ARegion        string
+	MerchantRegion string
+	WeightFrom     float64 // gram
+	WeightTo       float64 // gram, inclusive
+	WeightStep     float64 // gram
+}
+
+type CbSipHiddenFeePreviewPoint struct {
+	Weight          float64 // gram
+	HiddenPrice     float64 // real value in A currency
+	HiddenFeeConfig *pb.HiddenFeeConfigInfo
+}
+
+type CbSipValidateRateTableRequest struct {
+	PShopIds    []uint64
+	ItemPShopId uint64
+	PItemIds    []uint64 // P items of ItemPShopId
+}
+
+// RateTableViolation a problem found in a hidden fee rate table or a rate_table_cfg referencing it
+type RateTableViolation struct {
+	Source      pb.Constant_RateTableSource
+	Type        pb.Constant_RateTableViolationType
+	HpfnKey     string // key in hpfn_config_tab, or region of the default rate table
+	Region      string
+	OwnerId     uint64 // P shop id or P item id of rate_table_cfg
+	RowId       int64  // id in hpfn_config_tab, or index in the default rate table
+	WeightRange int64  // db weight
+	Detail      string
+}
+
 type CbSipGetAHiddenPriceConfigRequest struct {
 	InfoType  CbSipAHiddenFeeInfoType // refer to CbSipAHiddenFeeInfoType
 	PageIndex uint32                  // for RulesListWithPagination
@@ -201,3 +303,26 @@ type AHiddenFeeRuleRow struct {
 	Adjustment  int64
 	DescInfo    string
 }
+
+// CbSipPriceFactors factors of CB SIP A price formula, all are real values
+type CbSipPriceFactors struct {
+	Weight        float64
+	CountryMargin float64
+	ShopMargin    float64
+	ItemMargin    float64
+	ExchangeRate  float64
+	PriceRatio    float64
+	HiddenPrice   float64
+	SrcCurrency   string
+	ServiceFee    float64
+	CommissionFee float64
+	HandlingFee   float64
+	FinalFee      float64 // 1 + ServiceFee + CommissionFee + HandlingFee
+
+	HiddenFeeConfig *pb.HiddenFeeConfigInfo // rate table HiddenPrice is calculated with
+
+	WeightSource     pb.Constant_WeightSource // where Weight is taken from
+	VolumetricWeight float64                  // gram, 0 if volumetric weight is disabled or package dimension is unknown
+
+	ExchangeRatePath []string // currencies from SrcCurrency to A currency, with the pivot currency in between if triangulated
+}
@@ -1,5 +1,9 @@
 package model
 
+import (
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
 type MtskuMpskuPriceQuery struct {
 	// required
 	SourcePrice       int64
@@ -16,6 +20,11 @@ type MtskuMpskuPriceCalcResult struct {
 	DstPrice           int64
 	HidePrice          int64
 	HidePriceErrorCode int32
+	Trace              *PriceTrace
+	Guardrail          PriceGuardrailResult
+	FormulaVersion     string
+	ChannelHiddenFees  []*ChannelHiddenFee
+	HiddenFeeStrategy  pb.Constant_HiddenFeeAggregationStrategy
 }
 
 type SetCbscPriceFactorQuery struct {
@@ -40,6 +49,7 @@ type CbscPriceFactorLimit struct {
 type GetHidePriceForCbscRequest struct {
 	QueryId int
 
+	MerchantId     uint64 // to resolve hidden fee aggregation strategy, optional
 	Region         string
 	Weight         uint64
 	IsMtskuToMpsku bool
@@ -53,9 +63,11 @@ type GetHidePriceForCbscRequest struct {
 }
 
 type GetHidePriceForCbscResult struct {
-	QueryId   int
-	Err       error
-	HidePrice float64
+	QueryId           int
+	Err               error
+	HidePrice         float64
+	ChannelHiddenFees []*ChannelHiddenFee
+	HiddenFeeStrategy pb.Constant_HiddenFeeAggregationStrategy
 }
 
 type GetCommissionRateRequest struct {
@@ -109,3 +121,14 @@ type GetCbscProfitRateResult struct {
 	Err        error
 	ProfitRate float64
 }
+
+type CbscPriceFactors struct {
+	Err                error
+	HidePriceErrorCode int32
+	ExchangeRate       float64
+	ProfitRate         float64
+	HidePrice          float64
+	CbscPriceRate      float64
+	ChannelHiddenFees  []*ChannelHiddenFee
+	HiddenFeeStrategy  pb.Constant_HiddenFeeAggregationStrategy
+}
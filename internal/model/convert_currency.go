@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"time"
 
 	"git.garena.com/shopee/core-server/core-logic/cutil"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
@@ -26,18 +27,34 @@ type ConvertCurrencyRequest struct {
 	// for source seller platform
 	MerchantId  uint64
 	MpskuRegion string
+
+	// unix second, convert by the rate which applied at that time if set
+	AsOf int64
+	// fallback sources are configured by caller
+	Caller string
+	// seconds, for source order mart, the conversion fails if the rate is older
+	MaxRateAge int64
 }
 
 type ConvertCurrencyResult struct {
-	ExchangeRate float64
-	DstPrices    []int64
+	ExchangeRate       float64
+	DstPrices          []int64
+	ExchangeRateSource ExchangeRateSource // the source which answered
+	ExchangeRatePath   []string           // for source cb sip, currencies from src to dst, with the pivot currency in between if triangulated
+	ExchangeRateAge    int64              // seconds, for source order mart, UnknownRateAge if unknown
 }
 
+// UnknownRateAge the rate has neither fetch time nor a valid data date
+const UnknownRateAge = int64(-1)
+
+const orderMartExchangeRateDateLayout = "2006-01-02"
+
 type OrderMartExchangeRate struct {
 	Currency     string  `json:"currency"`
 	ExchangeRate float64 `json:"exchange_rate"`
 	Region       string  `json:"grass_region"`
 	Date         string  `json:"grass_date"`
+	FetchedAt    int64   `json:"fetched_at"` // unix second the row was fetched from data infra
 }
 
 func (r *OrderMartExchangeRate) Validate() error {
@@ -51,3 +68,18 @@ func (r *OrderMartExchangeRate) Validate() error {
 
 	return nil
 }
+
+// RateAge seconds from the older of the fetch time and the start of the data date in UTC to now, UnknownRateAge if
+// neither is known
+func (r *OrderMartExchangeRate) RateAge(now time.Time) int64 {
+	producedAt := r.FetchedAt
+	if date, err := time.ParseInLocation(orderMartExchangeRateDateLayout, r.Date, time.UTC); err == nil {
+		if producedAt == 0 || date.Unix() < producedAt {
+			producedAt = date.Unix()
+		}
+	}
+	if producedAt == 0 {
+		return UnknownRateAge
+	}
+	return now.Unix() - producedAt
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+// QuarantinedExchangeRate a refreshed rate which jumped too far from the previous rate of its key, PreviousRate is
+// served until it is approved
+type QuarantinedExchangeRate struct {
+	Id            uint64
+	Source        pb.Constant_ExchangeRateSource
+	RateKey       string
+	PreviousRate  string
+	PendingRate   string
+	ChangePercent float64
+	Status        pb.Constant_ExchangeRateQuarantineStatus
+	Operator      string
+	Ctime         int64
+	Mtime         int64
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"fmt"
+
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+// HpfnRateTableRow one row of HPFN rate table, values are db values as in hpfn_config_tab
+type HpfnRateTableRow struct {
+	WeightRange int64  `json:"weight_range"`
+	StartPrice  int64  `json:"start_price"`
+	StartWeight int64  `json:"start_weight"`
+	RoundSize   int64  `json:"round_size"`
+	Price       int64  `json:"price"`
+	WeightStep  int64  `json:"weight_step"`
+	Adjustment  int64  `json:"adjustment"`
+	DescInfo    string `json:"desc_info"`
+}
+
+// HpfnRateTableVersionContent content stored in a version, Rows for HPFN rate table and RateTableCfg for rate_table_cfg
+// targets, nil RateTableCfg means unassigned
+type HpfnRateTableVersionContent struct {
+	Rows         []*HpfnRateTableRow `json:"rows,omitempty"`
+	RateTableCfg *RateTableCfg       `json:"rate_table_cfg,omitempty"`
+}
+
+type HpfnRateTableVersion struct {
+	Id             uint64
+	Target         pb.Constant_RateTableVersionTarget
+	TargetKey      string
+	Content        HpfnRateTableVersionContent
+	EffectiveFrom  int64 // unix second
+	RollbackFromId uint64
+	Operator       string
+	Remark         string
+	Status         pb.Constant_RateTableVersionStatus
+	Ctime          int64
+}
+
+type CreateHpfnRateTableVersionRequest struct {
+	Target        pb.Constant_RateTableVersionTarget
+	TargetKey     string
+	Content       HpfnRateTableVersionContent
+	EffectiveFrom int64 // unix second, now if 0
+	Operator      string
+	Remark        string
+}
+
+type RollbackHpfnRateTableVersionRequest struct {
+	VersionId     uint64
+	EffectiveFrom int64 // unix second, now if 0
+	Operator      string
+	Remark        string
+}
+
+// BuildRegionRateTableCfgTargetKey target key of region rate table config version
+func BuildRegionRateTableCfgTargetKey(merchantRegion string, pRegion string) string {
+	return fmt.Sprintf("%s:%s", merchantRegion, pRegion)
+}
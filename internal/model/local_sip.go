@@ -60,14 +60,44 @@ func PickUniqAItemIdFromLocalSipCalculateAPriceQueries(queries []LocalSipCalcula
 }
 
 type LocalSipCalculateAPriceResult struct {
-	Err             error
-	NormalPrice     int64
-	PromotionPrices []int64
-	AShopId         uint64
-	ARegion         string
-	AItemId         uint64
-	AModelId        uint64
-	PriceCalSnap    *pb.LocalSipPriceFactorSnap
+	Err              error
+	NormalPrice      int64
+	PromotionPrices  []int64
+	AShopId          uint64
+	ARegion          string
+	AItemId          uint64
+	AModelId         uint64
+	PriceCalSnap     *pb.LocalSipPriceFactorSnap
+	Traces           []*PriceTrace
+	RoundingStrategy string // rounding strategy applied to A prices
+	Guardrail        PriceGuardrailResult
+	FormulaVersion   string
+}
+
+type LocalSipCalculatePPriceQuery struct {
+	QueryId int
+
+	AShopId      uint64
+	ARegion      string
+	AItemId      uint64 // required for non-create scenario
+	AModelId     uint64
+	TargetAPrice int64
+
+	// required for creation scenario
+	LeafCategoryId       uint64
+	EnabledChannelIdList []int64
+}
+
+type LocalSipCalculatePPriceResult struct {
+	Err          error
+	PPrice       int64
+	APrice       int64 // A price calculated by PPrice
+	IsExactMatch bool
+	AShopId      uint64
+	ARegion      string
+	AItemId      uint64
+	AModelId     uint64
+	PriceCalSnap *pb.LocalSipPriceFactorSnap
 }
 
 type LocalSipHiddenPriceQuery struct {
@@ -166,9 +196,20 @@ type GetLocalSipPriceFactorQuery struct {
 }
 
 type LocalSipPriceFactorInfo struct {
-	BasicInfo            *LocalSipFactorBasicInfo
-	LocalHiddenFeeInfo   []*LocalSipFactorHiddenFeeInfo
-	LocalShippingFeeInfo []*LocalSipFactorShippingFeeInfo
+	BasicInfo             *LocalSipFactorBasicInfo
+	LocalHiddenFeeInfo    []*LocalSipFactorHiddenFeeInfo
+	LocalHiddenFeeTable   *LocalSipFeeTableInfo
+	LocalShippingFeeInfo  []*LocalSipFactorShippingFeeInfo
+	LocalShippingFeeTable *LocalSipFeeTableInfo
+}
+
+// LocalSipFeeTableInfo how weights are matched to the rows of a local SIP fee table and the bounds of matched fee,
+// a bound of 0 is not checked
+type LocalSipFeeTableInfo struct {
+	Mode         pb.Constant_LocalSipFeeTableMode
+	PerExtraGram float64
+	MinFee       float64
+	MaxFee       float64
 }
 
 type LocalSipFactorBasicInfo struct {
@@ -202,3 +243,18 @@ type LocalSipFactorHiddenFeeInfo struct {
 	Weight      int64
 	HiddenPrice int64
 }
+
+// LocalSipPriceFactors factors of local SIP A price formula for one query, all are real values
+type LocalSipPriceFactors struct {
+	Err error
+
+	Weight          float64
+	ItemMargin      float64
+	ShopMargin      float64
+	InitHiddenPrice float64
+	ShippingFee     float64
+	PriceConfig     *CommonPriceConfig // country margin(buffer) and exchange rate
+
+	WeightSource     pb.Constant_WeightSource // where Weight is taken from
+	VolumetricWeight float64                  // gram, 0 if volumetric weight is disabled or package dimension is unknown
+}
@@ -87,6 +87,16 @@ type CalcHiddenFeeResult struct {
 	Message           string  `json:"message,omitempty"`
 }
 
+// ChannelHiddenFee hidden fee of one channel returned by SLS, Chosen marks the channels the aggregated hidden fee is
+// taken from
+type ChannelHiddenFee struct {
+	ChannelId   uint64
+	ChannelName string
+	HiddenFee   float64
+	ErrCode     int64
+	Chosen      bool
+}
+
 type Location struct {
 	LocationIds []uint64 `json:"location_ids,omitempty"`
 	PostCode    string   `json:"post_code,omitempty"`
@@ -113,4 +123,5 @@ type SlsHiddenPriceResult struct {
 type ChannelInfo struct {
 	ChannelId uint64 `json:"channel_id,omitempty"`
 	Tag       int32  `json:"tag,omitempty"`
+	Name      string `json:"name,omitempty"`
 }
@@ -0,0 +1,25 @@
+package model
+
+// PriceFactorOverrides replaces the fetched price factors in what-if simulation, nil field keeps the fetched factor.
+// values are real values in the same unit as the price factor snap.
+type PriceFactorOverrides struct {
+	ExchangeRate  *float64
+	CountryMargin *float64 // CB SIP and local SIP
+	ShopMargin    *float64 // CB SIP and local SIP
+	ItemMargin    *float64 // CB SIP and local SIP
+	HiddenFee     *float64 // CB SIP affi hidden price, local SIP init hidden price, CBSC hide price
+	PriceRatio    *float64 // CB SIP only
+	ServiceFee    *float64 // CB SIP only
+	CommissionFee *float64 // CB SIP only
+	HandlingFee   *float64 // CB SIP only
+
+	ProfitRate           *float64 // CBSC only
+	DenominatorPriceRate *float64 // CBSC only
+}
+
+// OverrideFactor sets factor to the override value if it's provided
+func OverrideFactor(factor *float64, override *float64) {
+	if override != nil {
+		*factor = *override
+	}
+}
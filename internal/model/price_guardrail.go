@@ -0,0 +1,24 @@
+package model
+
+import (
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+// PriceGuardrailResult outcome of guardrail check on calculated prices, zero value means passed
+type PriceGuardrailResult struct {
+	Status pb.Constant_PriceGuardrailStatus
+	Reason pb.Constant_PriceGuardrailReason
+	Detail string // the price and bound which failed the check
+}
+
+func (r PriceGuardrailResult) IsRejected() bool {
+	return r.Status == pb.Constant_PRICE_GUARDRAIL_REJECTED
+}
+
+// Merge returns the more severe one of two results, r is kept if they are equally severe
+func (r PriceGuardrailResult) Merge(other PriceGuardrailResult) PriceGuardrailResult {
+	if other.Status > r.Status {
+		return other
+	}
+	return r
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
+)
+
+const (
+	PriceNameNormal     = pricekernel.PriceNameNormal
+	PriceNamePromotion  = pricekernel.PriceNamePromotion
+	PriceNameSettlement = pricekernel.PriceNameSettlement
+	PriceNameDst        = pricekernel.PriceNameDst
+)
+
+// PriceTrace records how one price is derived from its factors, it is the trace of the offline pricing kernel
+type PriceTrace = pricekernel.Trace
+
+type PriceTerm = pricekernel.Term
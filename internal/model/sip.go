@@ -22,6 +22,20 @@ type CommonPriceConfig struct {
 	ShippingFeeToggle *int32   `json:"shipping_fee_toggle,omitempty"`
 }
 
+// ItemDimension package dimension of item in cm
+type ItemDimension struct {
+	Length float64
+	Width  float64
+	Height float64
+}
+
+// ChargeableWeight weight the fees of item are looked up with, weights are db weights
+type ChargeableWeight struct {
+	Weight           int64
+	Source           pb.Constant_WeightSource
+	VolumetricWeight int64 // 0 if volumetric weight is disabled or package dimension is unknown
+}
+
 type mstShopInnerFlag struct {
 	SipRateControlledByOps int
 	IsCnscShop             int
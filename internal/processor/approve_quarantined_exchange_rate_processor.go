@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) ApproveQuarantinedExchangeRate(ctx context.Context, request *priceSyncPriceCalculationPb.ApproveQuarantinedExchangeRateRequest, response *priceSyncPriceCalculationPb.ApproveQuarantinedExchangeRateResponse) uint32 {
+	p := &approveQuarantinedExchangeRateProcessor{
+		ctx:           ctx,
+		request:       request,
+		response:      response,
+		currencyLogic: s.currencyConvertLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type approveQuarantinedExchangeRateProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.ApproveQuarantinedExchangeRateRequest
+	response *priceSyncPriceCalculationPb.ApproveQuarantinedExchangeRateResponse
+
+	currencyLogic logic.CurrencyConvertLogic
+}
+
+func (r *approveQuarantinedExchangeRateProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	rate, err := r.currencyLogic.ApproveQuarantinedExchangeRate(r.ctx, r.request.GetId(), r.request.GetOperator())
+	if err != nil {
+		return err
+	}
+	r.response.Rate = convertQuarantinedExchangeRateToPb(rate)
+	return nil
+}
+
+func (r *approveQuarantinedExchangeRateProcessor) validateRequest() error {
+	req := r.request
+	if req.GetId() == 0 || len(req.GetOperator()) == 0 {
+		return cerr.New("id and operator are required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
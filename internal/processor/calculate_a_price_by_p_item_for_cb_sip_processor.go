@@ -46,6 +46,66 @@ func (c *calculateAPriceByPItemForCbSipProcessor) process() error {
 		return err
 	}
 
+	var priceResults, overriddenResults []model.CbSipCalculateAPriceByPItemResult
+	var err error
+	if c.request.Overrides != nil {
+		priceResults, overriddenResults, err = c.cbsipLogic.SimulateAPriceByPItemForCbSip(c.ctx, c.buildRequest(), convertPriceFactorOverrides(c.request.GetOverrides()))
+	} else {
+		priceResults, err = c.cbsipLogic.CalculateAPriceByPItemForCbSip(c.ctx, c.buildRequest())
+	}
+	if err != nil {
+		return err
+	}
+
+	oplResult, err := c.cbsipLogic.CalculateAItemOPL(c.ctx, &model.CbSipCalculateAOPLByPItemRequest{
+		PRegion: c.request.GetPRegion(),
+		PItemId: c.request.GetPItemId(),
+		AShopId: c.request.GetAShopId(),
+		ARegion: c.request.GetARegion(),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.response.Results = c.convertResultsToPb(priceResults)
+	if c.request.Overrides != nil {
+		c.response.OverriddenResults = c.convertResultsToPb(overriddenResults)
+	}
+	c.response.CustomizedOpl = oplResult.Opl
+
+	return nil
+}
+
+func (c *calculateAPriceByPItemForCbSipProcessor) convertResultsToPb(priceResults []model.CbSipCalculateAPriceByPItemResult) []*priceSyncPriceCalculationPb.AItemPriceResultInfo {
+	respResults := make([]*priceSyncPriceCalculationPb.AItemPriceResultInfo, 0, len(priceResults))
+	for _, result := range priceResults {
+		if result.Err != nil {
+			respResults = append(respResults, &priceSyncPriceCalculationPb.AItemPriceResultInfo{
+				ErrCode:         proto.Uint32(cerr.Code(result.Err)),
+				ErrMsg:          proto.String(result.Err.Error()),
+				Snap:            result.Snap,
+				GuardrailStatus: proto.Uint32(uint32(result.Guardrail.Status)),
+				GuardrailReason: proto.Uint32(uint32(result.Guardrail.Reason)),
+				FormulaVersion:  proto.String(result.FormulaVersion),
+			})
+			continue
+		}
+		respResults = append(respResults, &priceSyncPriceCalculationPb.AItemPriceResultInfo{
+			NormalPrice:             proto.Int64(result.ANormalPrice),
+			SettlementPrice:         proto.Int64(result.ASettlementPrice),
+			SettlementPriceCurrency: proto.String(result.ASettlementPriceCurrency),
+			PromotionPrice:          proto.Int64(result.APromotionPrice),
+			Snap:                    result.Snap,
+			RoundingStrategy:        proto.String(result.RoundingStrategy),
+			GuardrailStatus:         proto.Uint32(uint32(result.Guardrail.Status)),
+			GuardrailReason:         proto.Uint32(uint32(result.Guardrail.Reason)),
+			FormulaVersion:          proto.String(result.FormulaVersion),
+		})
+	}
+	return respResults
+}
+
+func (c *calculateAPriceByPItemForCbSipProcessor) buildRequest() model.CbSipCalculateAPriceByPItemRequest {
 	queries := make([]model.AItemCbSipQueryId, 0)
 	for _, q := range c.request.GetQueries() {
 		queries = append(queries, model.AItemCbSipQueryId{
@@ -55,7 +115,7 @@ func (c *calculateAPriceByPItemForCbSipProcessor) process() error {
 			PPromotionPrice: q.PPromotionPrice,
 		})
 	}
-	priceResults, err := c.cbsipLogic.CalculateAPriceByPItemForCbSip(c.ctx, model.CbSipCalculateAPriceByPItemRequest{
+	return model.CbSipCalculateAPriceByPItemRequest{
 		MerchantId:         c.request.GetMerchantId(),
 		MerchantRegion:     c.request.GetMerchantRegion(),
 		PShopId:            c.request.GetPShopId(),
@@ -66,36 +126,7 @@ func (c *calculateAPriceByPItemForCbSipProcessor) process() error {
 		AItemId:            c.request.GetAItemId(),
 		Queries:            queries,
 		CalculateForCreate: c.request.GetCalculateForCreate(),
-	})
-	if err != nil {
-		return err
-	}
-
-	respResults := make([]*priceSyncPriceCalculationPb.AItemPriceResultInfo, 0, len(priceResults))
-	for _, result := range priceResults {
-		respResults = append(respResults, &priceSyncPriceCalculationPb.AItemPriceResultInfo{
-			NormalPrice:             proto.Int64(result.ANormalPrice),
-			SettlementPrice:         proto.Int64(result.ASettlementPrice),
-			SettlementPriceCurrency: proto.String(result.ASettlementPriceCurrency),
-			PromotionPrice:          proto.Int64(result.APromotionPrice),
-			Snap:                    result.Snap,
-		})
 	}
-
-	oplResult, err := c.cbsipLogic.CalculateAItemOPL(c.ctx, &model.CbSipCalculateAOPLByPItemRequest{
-		PRegion: c.request.GetPRegion(),
-		PItemId: c.request.GetPItemId(),
-		AShopId: c.request.GetAShopId(),
-		ARegion: c.request.GetARegion(),
-	})
-	if err != nil {
-		return err
-	}
-
-	c.response.Results = respResults
-	c.response.CustomizedOpl = oplResult.Opl
-
-	return nil
 }
 
 func (c *calculateAPriceByPItemForCbSipProcessor) validateRequest() error {
@@ -144,5 +175,5 @@ func (c *calculateAPriceByPItemForCbSipProcessor) validateRequest() error {
 		}
 	}
 
-	return nil
+	return validatePriceFactorOverrides(req.GetOverrides())
 }
@@ -45,56 +45,16 @@ func (c *calculateAPriceByPItemForLocalSipProcessor) process() error {
 	if err := c.validateRequest(); err != nil {
 		return err
 	}
-	queries := make([]model.LocalSipCalculateAPriceQuery, 0, len(c.request.GetQueries()))
-	for i, query := range c.request.GetQueries() {
-		queries = append(queries, model.LocalSipCalculateAPriceQuery{
-			QueryId:              i,
-			AShopId:              query.GetAShopId(),
-			ARegion:              query.GetARegion(),
-			AItemId:              query.GetAItemId(),
-			AModelId:             query.GetAModelId(),
-			PNormalPrice:         query.GetPNormalPrice(),
-			PPromotionPrices:     query.GetPPromotionPrices(),
-			LeafCategoryId:       query.GetLeafCategoryId(),
-			EnabledChannelIdList: query.GetEnabledChannelIdList(),
-		})
+	var results, overriddenResults []model.LocalSipCalculateAPriceResult
+	var err error
+	if c.request.Overrides != nil {
+		results, overriddenResults, err = c.localSipLogic.SimulateAPriceByPItemForLocalSip(c.ctx, c.request.GetPShopId(), c.request.GetPItemId(), c.request.GetPRegion(), c.buildQueries(), c.request.GetCalculateForCreate(), convertPriceFactorOverrides(c.request.GetOverrides()))
+	} else {
+		results, err = c.localSipLogic.CalculateAPriceByPItemForLocalSip(c.ctx, c.request.GetPShopId(), c.request.GetPItemId(), c.request.GetPRegion(), c.buildQueries(), c.request.GetCalculateForCreate())
 	}
-	results, err := c.localSipLogic.CalculateAPriceByPItemForLocalSip(c.ctx, c.request.GetPShopId(), c.request.GetPItemId(), c.request.GetPRegion(), queries, c.request.GetCalculateForCreate())
 	if err != nil {
 		return err
 	}
-	respResults := make([]*priceSyncPriceCalculationPb.LocalSipAPriceInfo, 0, len(results))
-	for i, result := range results {
-		if result.Err != nil {
-			respResults = append(respResults, &priceSyncPriceCalculationPb.LocalSipAPriceInfo{
-				ErrCode: proto.Uint32(cerr.Code(result.Err)),
-				ErrMsg:  proto.String(result.Err.Error()),
-			})
-		} else {
-			var resNormalPrice *int64
-			if c.request.GetQueries()[i].PNormalPrice != nil {
-				resNormalPrice = proto.Int64(result.NormalPrice)
-			}
-			var aItemId, aModelId *uint64
-			if c.request.GetQueries()[i].AItemId != nil {
-				aItemId = proto.Uint64(result.AItemId)
-			}
-
-			if c.request.GetQueries()[i].AModelId != nil {
-				aModelId = proto.Uint64(result.AModelId)
-			}
-
-			respResults = append(respResults, &priceSyncPriceCalculationPb.LocalSipAPriceInfo{
-				NormalPrice:     resNormalPrice,
-				PromotionPrices: result.PromotionPrices,
-				AShopId:         proto.Uint64(result.AShopId),
-				ARegion:         proto.String(result.ARegion),
-				AItemId:         aItemId,
-				AModelId:        aModelId,
-				Snap:            result.PriceCalSnap,
-			})
-		}
-	}
 
 	aShopItemIdRegionMap := make(map[uint64]map[uint64]string)
 	for _, q := range c.request.GetQueries() {
@@ -119,11 +79,75 @@ func (c *calculateAPriceByPItemForLocalSipProcessor) process() error {
 		}
 	}
 
-	c.response.Results = respResults
+	c.response.Results = c.convertResultsToPb(results)
+	if c.request.Overrides != nil {
+		c.response.OverriddenResults = c.convertResultsToPb(overriddenResults)
+	}
 	c.response.AShopItemCustomizedOpls = aShopItemIdOPLList
 	return nil
 }
 
+func (c *calculateAPriceByPItemForLocalSipProcessor) convertResultsToPb(results []model.LocalSipCalculateAPriceResult) []*priceSyncPriceCalculationPb.LocalSipAPriceInfo {
+	respResults := make([]*priceSyncPriceCalculationPb.LocalSipAPriceInfo, 0, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			respResults = append(respResults, &priceSyncPriceCalculationPb.LocalSipAPriceInfo{
+				ErrCode:         proto.Uint32(cerr.Code(result.Err)),
+				ErrMsg:          proto.String(result.Err.Error()),
+				GuardrailStatus: proto.Uint32(uint32(result.Guardrail.Status)),
+				GuardrailReason: proto.Uint32(uint32(result.Guardrail.Reason)),
+				FormulaVersion:  proto.String(result.FormulaVersion),
+			})
+		} else {
+			var resNormalPrice *int64
+			if c.request.GetQueries()[i].PNormalPrice != nil {
+				resNormalPrice = proto.Int64(result.NormalPrice)
+			}
+			var aItemId, aModelId *uint64
+			if c.request.GetQueries()[i].AItemId != nil {
+				aItemId = proto.Uint64(result.AItemId)
+			}
+
+			if c.request.GetQueries()[i].AModelId != nil {
+				aModelId = proto.Uint64(result.AModelId)
+			}
+
+			respResults = append(respResults, &priceSyncPriceCalculationPb.LocalSipAPriceInfo{
+				NormalPrice:      resNormalPrice,
+				PromotionPrices:  result.PromotionPrices,
+				AShopId:          proto.Uint64(result.AShopId),
+				ARegion:          proto.String(result.ARegion),
+				AItemId:          aItemId,
+				AModelId:         aModelId,
+				Snap:             result.PriceCalSnap,
+				RoundingStrategy: proto.String(result.RoundingStrategy),
+				GuardrailStatus:  proto.Uint32(uint32(result.Guardrail.Status)),
+				GuardrailReason:  proto.Uint32(uint32(result.Guardrail.Reason)),
+				FormulaVersion:   proto.String(result.FormulaVersion),
+			})
+		}
+	}
+	return respResults
+}
+
+func (c *calculateAPriceByPItemForLocalSipProcessor) buildQueries() []model.LocalSipCalculateAPriceQuery {
+	queries := make([]model.LocalSipCalculateAPriceQuery, 0, len(c.request.GetQueries()))
+	for i, query := range c.request.GetQueries() {
+		queries = append(queries, model.LocalSipCalculateAPriceQuery{
+			QueryId:              i,
+			AShopId:              query.GetAShopId(),
+			ARegion:              query.GetARegion(),
+			AItemId:              query.GetAItemId(),
+			AModelId:             query.GetAModelId(),
+			PNormalPrice:         query.GetPNormalPrice(),
+			PPromotionPrices:     query.GetPPromotionPrices(),
+			LeafCategoryId:       query.GetLeafCategoryId(),
+			EnabledChannelIdList: query.GetEnabledChannelIdList(),
+		})
+	}
+	return queries
+}
+
 func (c *calculateAPriceByPItemForLocalSipProcessor) validateRequest() error {
 	req := c.request
 	if req.GetPShopId() == 0 {
@@ -172,5 +196,5 @@ func (c *calculateAPriceByPItemForLocalSipProcessor) validateRequest() error {
 			}
 		}
 	}
-	return nil
+	return validatePriceFactorOverrides(req.GetOverrides())
 }
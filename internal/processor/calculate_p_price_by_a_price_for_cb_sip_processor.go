@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/core-logic/cutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) CalculatePPriceByAPriceForCbSip(ctx context.Context, request *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForCbSipRequest, response *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForCbSipResponse) uint32 {
+	p := &calculatePPriceByAPriceForCbSipProcessor{
+		ctx:        ctx,
+		request:    request,
+		response:   response,
+		cbsipLogic: s.cbsipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type calculatePPriceByAPriceForCbSipProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForCbSipRequest
+	response *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForCbSipResponse
+
+	cbsipLogic logic.CbSipLogic
+}
+
+func (c *calculatePPriceByAPriceForCbSipProcessor) process() error {
+	if err := c.validateRequest(); err != nil {
+		return err
+	}
+
+	queries := make([]model.CbSipPPriceByAPriceQuery, 0, len(c.request.GetQueries()))
+	for _, q := range c.request.GetQueries() {
+		queries = append(queries, model.CbSipPPriceByAPriceQuery{
+			AModelId:           q.GetAModelId(),
+			TargetANormalPrice: q.GetTargetANormalPrice(),
+		})
+	}
+	results, err := c.cbsipLogic.CalculatePPriceByAPriceForCbSip(c.ctx, model.CbSipCalculatePPriceByAPriceRequest{
+		MerchantId:         c.request.GetMerchantId(),
+		MerchantRegion:     c.request.GetMerchantRegion(),
+		PShopId:            c.request.GetPShopId(),
+		PRegion:            c.request.GetPRegion(),
+		PItemId:            c.request.GetPItemId(),
+		AShopId:            c.request.GetAShopId(),
+		ARegion:            c.request.GetARegion(),
+		AItemId:            c.request.GetAItemId(),
+		Queries:            queries,
+		CalculateForCreate: c.request.GetCalculateForCreate(),
+	})
+	if err != nil {
+		return err
+	}
+
+	respResults := make([]*priceSyncPriceCalculationPb.PItemPriceResultInfo, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			respResults = append(respResults, &priceSyncPriceCalculationPb.PItemPriceResultInfo{
+				ErrCode: proto.Uint32(cerr.Code(result.Err)),
+				ErrMsg:  proto.String(result.Err.Error()),
+				Snap:    result.Snap,
+			})
+			continue
+		}
+		respResults = append(respResults, &priceSyncPriceCalculationPb.PItemPriceResultInfo{
+			PItemPrice:         proto.Int64(result.PItemPrice),
+			PItemPriceCurrency: proto.String(result.PItemPriceCurrency),
+			ANormalPrice:       proto.Int64(result.ANormalPrice),
+			IsExactMatch:       proto.Bool(result.IsExactMatch),
+			Snap:               result.Snap,
+		})
+	}
+
+	c.response.Results = respResults
+	return nil
+}
+
+func (c *calculatePPriceByAPriceForCbSipProcessor) validateRequest() error {
+	req := c.request
+	if req.GetMerchantId() == 0 {
+		return cerr.New("invalid MerchantId", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if len(req.GetMerchantRegion()) == 0 {
+		return cerr.New("invalid MerchantRegion", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetPShopId() == 0 {
+		return cerr.New("invalid PShopId", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if !cutil.IsValidCountry(req.GetPRegion()) {
+		return cerr.New(fmt.Sprintf("region %v is invalid", req.GetPRegion()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetPItemId() == 0 {
+		return cerr.New("invalid PItemId", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetAShopId() == 0 {
+		return cerr.New("invalid AShopId", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if !cutil.IsValidCountry(req.GetARegion()) {
+		return cerr.New(fmt.Sprintf("region %v is invalid", req.GetARegion()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+
+	if len(req.GetQueries()) == 0 {
+		return cerr.New("empty queries", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	for _, query := range req.GetQueries() {
+		if query.GetTargetANormalPrice() <= 0 {
+			return cerr.New("invalid TargetANormalPrice", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/core-logic/cutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) CalculatePPriceByAPriceForLocalSip(ctx context.Context, request *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForLocalSipRequest, response *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForLocalSipResponse) uint32 {
+	p := &calculatePPriceByAPriceForLocalSipProcessor{
+		ctx:           ctx,
+		request:       request,
+		response:      response,
+		localSipLogic: s.localSipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type calculatePPriceByAPriceForLocalSipProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForLocalSipRequest
+	response *priceSyncPriceCalculationPb.CalculatePPriceByAPriceForLocalSipResponse
+
+	localSipLogic logic.LocalSipLogic
+}
+
+func (c *calculatePPriceByAPriceForLocalSipProcessor) process() error {
+	if err := c.validateRequest(); err != nil {
+		return err
+	}
+
+	queries := make([]model.LocalSipCalculatePPriceQuery, 0, len(c.request.GetQueries()))
+	for i, query := range c.request.GetQueries() {
+		queries = append(queries, model.LocalSipCalculatePPriceQuery{
+			QueryId:              i,
+			AShopId:              query.GetAShopId(),
+			ARegion:              query.GetARegion(),
+			AItemId:              query.GetAItemId(),
+			AModelId:             query.GetAModelId(),
+			TargetAPrice:         query.GetTargetAPrice(),
+			LeafCategoryId:       query.GetLeafCategoryId(),
+			EnabledChannelIdList: query.GetEnabledChannelIdList(),
+		})
+	}
+	results, err := c.localSipLogic.CalculatePPriceByAPriceForLocalSip(c.ctx, c.request.GetPShopId(), c.request.GetPItemId(), c.request.GetPRegion(), queries, c.request.GetCalculateForCreate())
+	if err != nil {
+		return err
+	}
+
+	respResults := make([]*priceSyncPriceCalculationPb.LocalSipPPriceInfo, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			respResults = append(respResults, &priceSyncPriceCalculationPb.LocalSipPPriceInfo{
+				ErrCode:  proto.Uint32(cerr.Code(result.Err)),
+				ErrMsg:   proto.String(result.Err.Error()),
+				AShopId:  proto.Uint64(result.AShopId),
+				ARegion:  proto.String(result.ARegion),
+				AItemId:  proto.Uint64(result.AItemId),
+				AModelId: proto.Uint64(result.AModelId),
+				Snap:     result.PriceCalSnap,
+			})
+			continue
+		}
+		respResults = append(respResults, &priceSyncPriceCalculationPb.LocalSipPPriceInfo{
+			PPrice:       proto.Int64(result.PPrice),
+			APrice:       proto.Int64(result.APrice),
+			IsExactMatch: proto.Bool(result.IsExactMatch),
+			AShopId:      proto.Uint64(result.AShopId),
+			ARegion:      proto.String(result.ARegion),
+			AItemId:      proto.Uint64(result.AItemId),
+			AModelId:     proto.Uint64(result.AModelId),
+			Snap:         result.PriceCalSnap,
+		})
+	}
+
+	c.response.Results = respResults
+	return nil
+}
+
+func (c *calculatePPriceByAPriceForLocalSipProcessor) validateRequest() error {
+	req := c.request
+	if req.GetPShopId() == 0 {
+		return cerr.New("invalid PShopId", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if !cutil.IsValidCountry(req.GetPRegion()) {
+		return cerr.New(fmt.Sprintf("region %v is invalid", req.GetPRegion()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetPItemId() == 0 {
+		return cerr.New("invalid PItemId", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+
+	if len(req.GetQueries()) == 0 {
+		return cerr.New("empty queries", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+
+	for _, query := range req.GetQueries() {
+		if query.GetAShopId() == 0 {
+			return cerr.New("invalid AShopId", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		if !cutil.IsValidCountry(query.GetARegion()) {
+			return cerr.New(fmt.Sprintf("region %v is invalid", query.GetARegion()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		if !req.GetCalculateForCreate() && query.AItemId == nil {
+			return cerr.New("a_item_id is required for non-creation scenario", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		if query.GetTargetAPrice() <= 0 {
+			return cerr.New("invalid TargetAPrice", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+	}
+	return nil
+}
@@ -46,42 +46,67 @@ func (c *calculatePriceForCbscProcessor) process() error {
 		return err
 	}
 
-	queries := make([]model.MtskuMpskuPriceQuery, 0, len(c.request.GetQueries()))
-	for _, query := range c.request.GetQueries() {
-		queries = append(queries, model.MtskuMpskuPriceQuery{
-			SourcePrice:       query.GetSrcPrice(),
-			MpskuShopId:       query.GetMpskuShopId(),
-			MpskuRegion:       query.GetMpskuRegion(),
-			MpskuItemId:       query.GetMpskuItemId(),
-			Weight:            query.GetWeight(),
-			LeafCategoryId:    query.GetLeafCategoryId(),
-			EnabledChannelIds: query.GetEnabledChannelIdList(),
-		})
-
+	var results, overriddenResults []model.MtskuMpskuPriceCalcResult
+	var err error
+	if c.request.Overrides != nil {
+		results, overriddenResults, err = c.cbscLogic.SimulatePriceForCbsc(c.ctx, c.request.GetMerchantId(), c.request.GetIsMtskuToMpsku(), c.buildQueries(), convertPriceFactorOverrides(c.request.GetOverrides()))
+	} else {
+		results, err = c.cbscLogic.CalculatePriceForCbsc(c.ctx, c.request.GetMerchantId(), c.request.GetIsMtskuToMpsku(), c.buildQueries())
 	}
-	results, err := c.cbscLogic.CalculatePriceForCbsc(c.ctx, c.request.GetMerchantId(), c.request.GetIsMtskuToMpsku(), queries)
 	if err != nil {
 		return err
 	}
+
+	c.response.Results = c.convertResultsToPb(results)
+	if c.request.Overrides != nil {
+		c.response.OverriddenResults = c.convertResultsToPb(overriddenResults)
+	}
+	return nil
+}
+
+func (c *calculatePriceForCbscProcessor) convertResultsToPb(results []model.MtskuMpskuPriceCalcResult) []*priceSyncPriceCalculationPb.MtskuMpskuPriceQueryInfo {
 	respResults := make([]*priceSyncPriceCalculationPb.MtskuMpskuPriceQueryInfo, 0, len(results))
 	for _, result := range results {
 		if result.Err != nil {
 			respResults = append(respResults, &priceSyncPriceCalculationPb.MtskuMpskuPriceQueryInfo{
-				ErrCode:        proto.Uint32(cerr.Code(result.Err)),
-				ErrMsg:         proto.String(result.Err.Error()),
-				HidePriceError: proto.Int32(result.HidePriceErrorCode),
+				ErrCode:         proto.Uint32(cerr.Code(result.Err)),
+				ErrMsg:          proto.String(result.Err.Error()),
+				HidePriceError:  proto.Int32(result.HidePriceErrorCode),
+				GuardrailStatus: proto.Uint32(uint32(result.Guardrail.Status)),
+				GuardrailReason: proto.Uint32(uint32(result.Guardrail.Reason)),
+				FormulaVersion:  proto.String(result.FormulaVersion),
 			})
 		} else {
 			respResults = append(respResults, &priceSyncPriceCalculationPb.MtskuMpskuPriceQueryInfo{
-				DstPrice:       proto.Int64(result.DstPrice),
-				HidePrice:      proto.Int64(result.HidePrice),
-				HidePriceError: proto.Int32(result.HidePriceErrorCode),
+				DstPrice:          proto.Int64(result.DstPrice),
+				HidePrice:         proto.Int64(result.HidePrice),
+				HidePriceError:    proto.Int32(result.HidePriceErrorCode),
+				GuardrailStatus:   proto.Uint32(uint32(result.Guardrail.Status)),
+				GuardrailReason:   proto.Uint32(uint32(result.Guardrail.Reason)),
+				FormulaVersion:    proto.String(result.FormulaVersion),
+				ChannelHiddenFees: convertChannelHiddenFeesToPb(result.ChannelHiddenFees),
+				HiddenFeeStrategy: proto.Uint32(uint32(result.HiddenFeeStrategy)),
 			})
 		}
 	}
 
-	c.response.Results = respResults
-	return nil
+	return respResults
+}
+
+func (c *calculatePriceForCbscProcessor) buildQueries() []model.MtskuMpskuPriceQuery {
+	queries := make([]model.MtskuMpskuPriceQuery, 0, len(c.request.GetQueries()))
+	for _, query := range c.request.GetQueries() {
+		queries = append(queries, model.MtskuMpskuPriceQuery{
+			SourcePrice:       query.GetSrcPrice(),
+			MpskuShopId:       query.GetMpskuShopId(),
+			MpskuRegion:       query.GetMpskuRegion(),
+			MpskuItemId:       query.GetMpskuItemId(),
+			Weight:            query.GetWeight(),
+			LeafCategoryId:    query.GetLeafCategoryId(),
+			EnabledChannelIds: query.GetEnabledChannelIdList(),
+		})
+	}
+	return queries
 }
 
 func (c *calculatePriceForCbscProcessor) validateRequest() error {
@@ -108,5 +133,5 @@ func (c *calculatePriceForCbscProcessor) validateRequest() error {
 			return cerr.New(fmt.Sprintf("region %v is invalid", query.GetMpskuRegion()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
 		}
 	}
-	return nil
+	return validatePriceFactorOverrides(req.GetOverrides())
 }
@@ -69,9 +69,11 @@ func (c *calculateSipItemPriceForCbSipProcessor) process() error {
 	respResults := make([]*priceSyncPriceCalculationPb.CbSipItemPriceInfo, 0, len(results))
 	for _, result := range results {
 		respResults = append(respResults, &priceSyncPriceCalculationPb.CbSipItemPriceInfo{
-			ModelId:        proto.Uint64(result.ModelId),
-			CbSipItemPrice: proto.Int64(result.CbSipItemPrice),
-			Currency:       proto.String(result.Currency),
+			ModelId:           proto.Uint64(result.ModelId),
+			CbSipItemPrice:    proto.Int64(result.CbSipItemPrice),
+			Currency:          proto.String(result.Currency),
+			FormulaVersion:    proto.String(result.FormulaVersion),
+			ChannelHiddenFees: convertChannelHiddenFeesToPb(result.ChannelHiddenFees),
 		})
 	}
 
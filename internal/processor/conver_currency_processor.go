@@ -53,6 +53,9 @@ func (c *convertCurrencyProcessor) process() error {
 		DstCurrency:        c.request.GetDstCurrency(),
 		MerchantId:         c.request.GetMerchantId(),
 		MpskuRegion:        c.request.GetMpskuRegion(),
+		AsOf:               c.request.GetAsOf(),
+		Caller:             c.request.GetCaller(),
+		MaxRateAge:         c.request.GetMaxRateAge(),
 	})
 
 	if err != nil {
@@ -60,6 +63,11 @@ func (c *convertCurrencyProcessor) process() error {
 	}
 	c.response.DstPrices = result.DstPrices
 	c.response.ExchangeRate = proto.Float64(result.ExchangeRate)
+	c.response.ExchangeRateSource = proto.Uint32(result.ExchangeRateSource)
+	c.response.ExchangeRatePath = result.ExchangeRatePath
+	if result.ExchangeRateSource == model.ExchangeRateSourceOrderMart && result.ExchangeRateAge != model.UnknownRateAge {
+		c.response.ExchangeRateAge = proto.Int64(result.ExchangeRateAge)
+	}
 	return nil
 }
 
@@ -77,6 +85,14 @@ func (c *convertCurrencyProcessor) validateRequest() error {
 		return cerr.New("invalid ExchangeRateSource", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
 	}
 
+	if req.AsOf != nil && req.GetAsOf() <= 0 {
+		return cerr.New(fmt.Sprintf("invalid AsOf: %v", req.GetAsOf()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+
+	if req.MaxRateAge != nil && req.GetMaxRateAge() <= 0 {
+		return cerr.New(fmt.Sprintf("invalid MaxRateAge: %v", req.GetMaxRateAge()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+
 	if req.GetExchangeRateSource() == uint32(priceSyncPriceCalculationPb.Constant_SELLER_PLATFORM) {
 		if len(req.GetMpskuRegion()) == 0 || req.MerchantId == nil || !cutil.IsValidCountry(req.GetMpskuRegion()) {
 			return cerr.New(fmt.Sprintf("for exchangeRateSource=sellerPlatform, correct mpskuRegion and merchantId should be provided"), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
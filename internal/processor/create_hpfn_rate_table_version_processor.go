@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) CreateHpfnRateTableVersion(ctx context.Context, request *priceSyncPriceCalculationPb.CreateHpfnRateTableVersionRequest, response *priceSyncPriceCalculationPb.CreateHpfnRateTableVersionResponse) uint32 {
+	p := &createHpfnRateTableVersionProcessor{
+		ctx:        ctx,
+		request:    request,
+		response:   response,
+		cbsipLogic: s.cbsipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type createHpfnRateTableVersionProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.CreateHpfnRateTableVersionRequest
+	response *priceSyncPriceCalculationPb.CreateHpfnRateTableVersionResponse
+
+	cbsipLogic logic.CbSipLogic
+}
+
+func (r *createHpfnRateTableVersionProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	req := r.request
+	content := model.HpfnRateTableVersionContent{
+		Rows: convertHpfnRateTableRows(req.GetRows()),
+	}
+	if len(req.GetRateTableCfg()) > 0 {
+		content.RateTableCfg = &model.RateTableCfg{}
+		if err := json.Unmarshal([]byte(req.GetRateTableCfg()), content.RateTableCfg); err != nil {
+			return cerr.New(fmt.Sprintf("invalid rate_table_cfg, err=%v", err), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+	}
+
+	version, err := r.cbsipLogic.CreateHpfnRateTableVersion(r.ctx, model.CreateHpfnRateTableVersionRequest{
+		Target:        priceSyncPriceCalculationPb.Constant_RateTableVersionTarget(req.GetTarget()),
+		TargetKey:     req.GetTargetKey(),
+		Content:       content,
+		EffectiveFrom: req.GetEffectiveFrom(),
+		Operator:      req.GetOperator(),
+		Remark:        req.GetRemark(),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.response.Version = convertHpfnRateTableVersionToPb(version)
+	return nil
+}
+
+func (r *createHpfnRateTableVersionProcessor) validateRequest() error {
+	req := r.request
+	if req.GetTarget() == 0 || len(req.GetTargetKey()) == 0 {
+		return cerr.New("target and target_key are required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if len(req.GetOperator()) == 0 {
+		return cerr.New("operator is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetEffectiveFrom() < 0 {
+		return cerr.New("effective_from must not be negative", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) ExplainPrice(ctx context.Context, request *priceSyncPriceCalculationPb.ExplainPriceRequest, response *priceSyncPriceCalculationPb.ExplainPriceResponse) uint32 {
+	p := &explainPriceProcessor{
+		ctx:           ctx,
+		request:       request,
+		response:      response,
+		cbsipLogic:    s.cbsipLogic,
+		localSipLogic: s.localSipLogic,
+		cbscLogic:     s.cbscLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type explainPriceProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.ExplainPriceRequest
+	response *priceSyncPriceCalculationPb.ExplainPriceResponse
+
+	cbsipLogic    logic.CbSipLogic
+	localSipLogic logic.LocalSipLogic
+	cbscLogic     logic.CbscLogic
+}
+
+func (e *explainPriceProcessor) process() error {
+	if err := e.validateRequest(); err != nil {
+		return err
+	}
+
+	var explanations []*priceSyncPriceCalculationPb.PriceExplanation
+	var err error
+	switch e.request.GetPriceType() {
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_CB_SIP):
+		explanations, err = e.explainCbSipPrice()
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_LOCAL_SIP):
+		explanations, err = e.explainLocalSipPrice()
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_CBSC):
+		explanations, err = e.explainCbscPrice()
+	}
+	if err != nil {
+		return err
+	}
+
+	e.response.Explanations = explanations
+	return nil
+}
+
+func (e *explainPriceProcessor) explainCbSipPrice() ([]*priceSyncPriceCalculationPb.PriceExplanation, error) {
+	p := &calculateAPriceByPItemForCbSipProcessor{request: e.request.GetCbSipRequest()}
+	results, err := e.cbsipLogic.CalculateAPriceByPItemForCbSip(e.ctx, p.buildRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := make([]*priceSyncPriceCalculationPb.PriceExplanation, 0, len(results))
+	for _, result := range results {
+		explanations = append(explanations, newPriceExplanation(result.Err, result.Traces))
+	}
+	return explanations, nil
+}
+
+func (e *explainPriceProcessor) explainLocalSipPrice() ([]*priceSyncPriceCalculationPb.PriceExplanation, error) {
+	req := e.request.GetLocalSipRequest()
+	p := &calculateAPriceByPItemForLocalSipProcessor{request: req}
+	results, err := e.localSipLogic.CalculateAPriceByPItemForLocalSip(e.ctx, req.GetPShopId(), req.GetPItemId(), req.GetPRegion(), p.buildQueries(), req.GetCalculateForCreate())
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := make([]*priceSyncPriceCalculationPb.PriceExplanation, 0, len(results))
+	for _, result := range results {
+		explanations = append(explanations, newPriceExplanation(result.Err, result.Traces))
+	}
+	return explanations, nil
+}
+
+func (e *explainPriceProcessor) explainCbscPrice() ([]*priceSyncPriceCalculationPb.PriceExplanation, error) {
+	req := e.request.GetCbscRequest()
+	p := &calculatePriceForCbscProcessor{request: req}
+	results, err := e.cbscLogic.CalculatePriceForCbsc(e.ctx, req.GetMerchantId(), req.GetIsMtskuToMpsku(), p.buildQueries())
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := make([]*priceSyncPriceCalculationPb.PriceExplanation, 0, len(results))
+	for _, result := range results {
+		var traces []*model.PriceTrace
+		if result.Trace != nil {
+			traces = []*model.PriceTrace{result.Trace}
+		}
+		explanations = append(explanations, newPriceExplanation(result.Err, traces))
+	}
+	return explanations, nil
+}
+
+func (e *explainPriceProcessor) validateRequest() error {
+	req := e.request
+	switch req.GetPriceType() {
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_CB_SIP):
+		if req.CbSipRequest == nil {
+			return cerr.New("cb_sip_request is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		return (&calculateAPriceByPItemForCbSipProcessor{request: req.GetCbSipRequest()}).validateRequest()
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_LOCAL_SIP):
+		if req.LocalSipRequest == nil {
+			return cerr.New("local_sip_request is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		return (&calculateAPriceByPItemForLocalSipProcessor{request: req.GetLocalSipRequest()}).validateRequest()
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_CBSC):
+		if req.CbscRequest == nil {
+			return cerr.New("cbsc_request is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		return (&calculatePriceForCbscProcessor{request: req.GetCbscRequest()}).validateRequest()
+	default:
+		return cerr.New(fmt.Sprintf("invalid price_type=%v", req.GetPriceType()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+}
+
+// newPriceExplanation traces are kept on error, so prices rejected by guardrail can still be explained
+func newPriceExplanation(err error, traces []*model.PriceTrace) *priceSyncPriceCalculationPb.PriceExplanation {
+	if err != nil {
+		return &priceSyncPriceCalculationPb.PriceExplanation{
+			ErrCode: proto.Uint32(cerr.Code(err)),
+			ErrMsg:  proto.String(err.Error()),
+			Traces:  convertPriceTracesToPb(traces),
+		}
+	}
+	return &priceSyncPriceCalculationPb.PriceExplanation{
+		Traces: convertPriceTracesToPb(traces),
+	}
+}
+
+func convertPriceTracesToPb(traces []*model.PriceTrace) []*priceSyncPriceCalculationPb.PriceTrace {
+	res := make([]*priceSyncPriceCalculationPb.PriceTrace, 0, len(traces))
+	for _, trace := range traces {
+		res = append(res, &priceSyncPriceCalculationPb.PriceTrace{
+			PriceName:          proto.String(trace.PriceName),
+			Formula:            proto.String(trace.Formula),
+			Terms:              convertPriceTermsToPb(trace.Terms),
+			IntermediateValues: convertPriceTermsToPb(trace.IntermediateValues),
+			PreRoundingPrice:   proto.Float64(trace.PreRoundingPrice),
+			RoundingRule:       proto.String(trace.RoundingRule),
+			FinalPrice:         proto.Int64(trace.FinalPrice),
+		})
+	}
+	return res
+}
+
+func convertPriceTermsToPb(terms []model.PriceTerm) []*priceSyncPriceCalculationPb.PriceTerm {
+	res := make([]*priceSyncPriceCalculationPb.PriceTerm, 0, len(terms))
+	for _, term := range terms {
+		res = append(res, &priceSyncPriceCalculationPb.PriceTerm{
+			Name:  proto.String(term.Name),
+			Value: proto.Float64(term.Value),
+		})
+	}
+	return res
+}
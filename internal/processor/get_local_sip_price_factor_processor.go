@@ -123,10 +123,22 @@ func (g *getLocalSipPriceFactorProcessor) process() error {
 			respRes.HiddenFeeInfo = &priceSyncPriceCalculationPb.LocalSipPriceFactorHiddenFeeInfo{
 				LocalHiddenFeeRules: hiddenFeeInfos,
 			}
+			if table := factor.LocalHiddenFeeTable; table != nil {
+				respRes.HiddenFeeInfo.Mode = proto.Uint32(uint32(table.Mode))
+				respRes.HiddenFeeInfo.PerExtraGram = proto.Float64(table.PerExtraGram)
+				respRes.HiddenFeeInfo.MinFee = proto.Float64(table.MinFee)
+				respRes.HiddenFeeInfo.MaxFee = proto.Float64(table.MaxFee)
+			}
 		case uint32(priceSyncPriceCalculationPb.Constant_SHIPPING_FEE):
 			respRes.ShippingFeeInfo = &priceSyncPriceCalculationPb.LocalSipPriceFactorShippingFeeInfo{
 				LocalShippingFeeRules: shippingFeeInfos,
 			}
+			if table := factor.LocalShippingFeeTable; table != nil {
+				respRes.ShippingFeeInfo.Mode = proto.Uint32(uint32(table.Mode))
+				respRes.ShippingFeeInfo.PerExtraGram = proto.Float64(table.PerExtraGram)
+				respRes.ShippingFeeInfo.MinFee = proto.Float64(table.MinFee)
+				respRes.ShippingFeeInfo.MaxFee = proto.Float64(table.MaxFee)
+			}
 		}
 		respResults = append(respResults, respRes)
 	}
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) ListHpfnRateTableVersions(ctx context.Context, request *priceSyncPriceCalculationPb.ListHpfnRateTableVersionsRequest, response *priceSyncPriceCalculationPb.ListHpfnRateTableVersionsResponse) uint32 {
+	p := &listHpfnRateTableVersionsProcessor{
+		ctx:        ctx,
+		request:    request,
+		response:   response,
+		cbsipLogic: s.cbsipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type listHpfnRateTableVersionsProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.ListHpfnRateTableVersionsRequest
+	response *priceSyncPriceCalculationPb.ListHpfnRateTableVersionsResponse
+
+	cbsipLogic logic.CbSipLogic
+}
+
+func (r *listHpfnRateTableVersionsProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	req := r.request
+	versions, err := r.cbsipLogic.ListHpfnRateTableVersions(r.ctx, priceSyncPriceCalculationPb.Constant_RateTableVersionTarget(req.GetTarget()), req.GetTargetKey())
+	if err != nil {
+		return err
+	}
+
+	r.response.Versions = make([]*priceSyncPriceCalculationPb.HpfnRateTableVersion, 0, len(versions))
+	for _, version := range versions {
+		r.response.Versions = append(r.response.Versions, convertHpfnRateTableVersionToPb(version))
+	}
+	return nil
+}
+
+func (r *listHpfnRateTableVersionsProcessor) validateRequest() error {
+	req := r.request
+	if req.GetTarget() == 0 || len(req.GetTargetKey()) == 0 {
+		return cerr.New("target and target_key are required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) ListQuarantinedExchangeRates(ctx context.Context, request *priceSyncPriceCalculationPb.ListQuarantinedExchangeRatesRequest, response *priceSyncPriceCalculationPb.ListQuarantinedExchangeRatesResponse) uint32 {
+	p := &listQuarantinedExchangeRatesProcessor{
+		ctx:           ctx,
+		request:       request,
+		response:      response,
+		currencyLogic: s.currencyConvertLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type listQuarantinedExchangeRatesProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.ListQuarantinedExchangeRatesRequest
+	response *priceSyncPriceCalculationPb.ListQuarantinedExchangeRatesResponse
+
+	currencyLogic logic.CurrencyConvertLogic
+}
+
+func (r *listQuarantinedExchangeRatesProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	status := priceSyncPriceCalculationPb.Constant_ExchangeRateQuarantineStatus(r.request.GetStatus())
+	rates, err := r.currencyLogic.ListQuarantinedExchangeRates(r.ctx, status)
+	if err != nil {
+		return err
+	}
+
+	r.response.Rates = make([]*priceSyncPriceCalculationPb.QuarantinedExchangeRate, 0, len(rates))
+	for _, rate := range rates {
+		r.response.Rates = append(r.response.Rates, convertQuarantinedExchangeRateToPb(rate))
+	}
+	return nil
+}
+
+func (r *listQuarantinedExchangeRatesProcessor) validateRequest() error {
+	if _, ok := priceSyncPriceCalculationPb.Constant_ExchangeRateQuarantineStatus_name[int32(r.request.GetStatus())]; !ok {
+		return cerr.New("invalid status", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+const maxHiddenFeePreviewPoints = 1000
+
+func (s *CalculationServiceImpl) PreviewCbSipHiddenFeeRateTable(ctx context.Context, request *priceSyncPriceCalculationPb.PreviewCbSipHiddenFeeRateTableRequest, response *priceSyncPriceCalculationPb.PreviewCbSipHiddenFeeRateTableResponse) uint32 {
+	p := &previewCbSipHiddenFeeRateTableProcessor{
+		ctx:        ctx,
+		request:    request,
+		response:   response,
+		cbsipLogic: s.cbsipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type previewCbSipHiddenFeeRateTableProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.PreviewCbSipHiddenFeeRateTableRequest
+	response *priceSyncPriceCalculationPb.PreviewCbSipHiddenFeeRateTableResponse
+
+	cbsipLogic logic.CbSipLogic
+}
+
+func (r *previewCbSipHiddenFeeRateTableProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	req := r.request
+	points, err := r.cbsipLogic.PreviewHiddenFeeRateTableForCbSip(r.ctx, model.CbSipPreviewHiddenFeeRateTableRequest{
+		HpfnKey:        req.GetHpfnKey(),
+		PShopId:        req.GetPShopId(),
+		PItemId:        req.GetPItemId(),
+		PRegion:        req.GetPRegion(),
+		ARegion:        req.GetARegion(),
+		MerchantRegion: req.GetMerchantRegion(),
+		WeightFrom:     req.GetWeightFrom(),
+		WeightTo:       req.GetWeightTo(),
+		WeightStep:     req.GetWeightStep(),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.response.Points = make([]*priceSyncPriceCalculationPb.HiddenFeePreviewPoint, 0, len(points))
+	for _, point := range points {
+		r.response.Points = append(r.response.Points, &priceSyncPriceCalculationPb.HiddenFeePreviewPoint{
+			Weight:          proto.Float64(point.Weight),
+			HiddenPrice:     proto.Float64(point.HiddenPrice),
+			HiddenFeeConfig: point.HiddenFeeConfig,
+		})
+	}
+	return nil
+}
+
+func (r *previewCbSipHiddenFeeRateTableProcessor) validateRequest() error {
+	req := r.request
+	if len(req.GetHpfnKey()) == 0 {
+		if req.GetPShopId() == 0 || req.GetPItemId() == 0 {
+			return cerr.New("hpfn_key or p_shop_id and p_item_id are required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		if len(req.GetPRegion()) == 0 || len(req.GetARegion()) == 0 {
+			return cerr.New("p_region and a_region are required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+	}
+	if req.GetWeightFrom() < 0 || req.GetWeightTo() < req.GetWeightFrom() {
+		return cerr.New(fmt.Sprintf("invalid weight range [%v, %v]", req.GetWeightFrom(), req.GetWeightTo()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetWeightStep() <= 0 {
+		return cerr.New("weight_step must be positive", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if (req.GetWeightTo()-req.GetWeightFrom())/req.GetWeightStep() >= maxHiddenFeePreviewPoints {
+		return cerr.New(fmt.Sprintf("too many weights in range, max=%v", maxHiddenFeePreviewPoints), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
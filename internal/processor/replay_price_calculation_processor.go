@@ -0,0 +1,216 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/core-logic/cutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/slice"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) ReplayPriceCalculation(ctx context.Context, request *priceSyncPriceCalculationPb.ReplayPriceCalculationRequest, response *priceSyncPriceCalculationPb.ReplayPriceCalculationResponse) uint32 {
+	p := &replayPriceCalculationProcessor{
+		ctx:           ctx,
+		request:       request,
+		response:      response,
+		cbsipLogic:    s.cbsipLogic,
+		localSipLogic: s.localSipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type replayPriceCalculationProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.ReplayPriceCalculationRequest
+	response *priceSyncPriceCalculationPb.ReplayPriceCalculationResponse
+
+	cbsipLogic    logic.CbSipLogic
+	localSipLogic logic.LocalSipLogic
+}
+
+func (r *replayPriceCalculationProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	var results []*priceSyncPriceCalculationPb.ReplayPriceResult
+	var err error
+	switch r.request.GetPriceType() {
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_CB_SIP):
+		results, err = r.replayCbSipPrice()
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_LOCAL_SIP):
+		results = r.replayLocalSipPrice()
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, result := range results {
+		historicalPrices := r.request.GetQueries()[i].HistoricalPrices
+		if historicalPrices == nil {
+			continue
+		}
+		result.MismatchedPriceNames = getMismatchedPriceNames(historicalPrices, result.GetPrices())
+		result.IsMismatch = proto.Bool(len(result.MismatchedPriceNames) > 0)
+		if result.GetIsMismatch() {
+			logging.GetLogger(r.ctx).Warn(fmt.Sprintf("replayed prices mismatch with historical prices, priceType=%v, aRegion=%v, query=%v, replayed=%v, mismatched=%v",
+				r.request.GetPriceType(), r.request.GetARegion(), cutil.JSONEncode(r.request.GetQueries()[i]), cutil.JSONEncode(result.GetPrices()), result.MismatchedPriceNames))
+		}
+	}
+
+	r.response.Results = results
+	return nil
+}
+
+func (r *replayPriceCalculationProcessor) replayCbSipPrice() ([]*priceSyncPriceCalculationPb.ReplayPriceResult, error) {
+	queries := make([]model.AItemCbSipQueryId, 0, len(r.request.GetQueries()))
+	for _, query := range r.request.GetQueries() {
+		var pPromotionPrice *int64
+		if len(query.GetPPromotionPrices()) > 0 {
+			pPromotionPrice = proto.Int64(query.GetPPromotionPrices()[0])
+		}
+		queries = append(queries, model.AItemCbSipQueryId{
+			PItemPrice:      query.GetPItemPrice(),
+			PNormalPrice:    query.GetPNormalPrice(),
+			PPromotionPrice: pPromotionPrice,
+		})
+	}
+
+	priceResults, err := r.cbsipLogic.ReplayAPriceByFactorSnapForCbSip(r.ctx, r.request.GetARegion(), r.request.GetCbSipSnap(), queries)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*priceSyncPriceCalculationPb.ReplayPriceResult, 0, len(priceResults))
+	for _, result := range priceResults {
+		prices := &priceSyncPriceCalculationPb.ReplayedPrices{
+			NormalPrice:     proto.Int64(result.ANormalPrice),
+			SettlementPrice: proto.Int64(result.ASettlementPrice),
+		}
+		if result.APromotionPrice >= 0 {
+			prices.PromotionPrices = []int64{result.APromotionPrice}
+		}
+		results = append(results, &priceSyncPriceCalculationPb.ReplayPriceResult{
+			Prices: prices,
+			Traces: convertPriceTracesToPb(result.Traces),
+		})
+	}
+	return results, nil
+}
+
+func (r *replayPriceCalculationProcessor) replayLocalSipPrice() []*priceSyncPriceCalculationPb.ReplayPriceResult {
+	queries := make([]model.LocalSipCalculateAPriceQuery, 0, len(r.request.GetQueries()))
+	for i, query := range r.request.GetQueries() {
+		queries = append(queries, model.LocalSipCalculateAPriceQuery{
+			QueryId:          i,
+			ARegion:          r.request.GetARegion(),
+			PNormalPrice:     query.GetPNormalPrice(),
+			PPromotionPrices: query.GetPPromotionPrices(),
+		})
+	}
+
+	priceResults := r.localSipLogic.ReplayAPriceByFactorSnapForLocalSip(r.ctx, r.request.GetLocalSipSnap(), queries)
+
+	results := make([]*priceSyncPriceCalculationPb.ReplayPriceResult, 0, len(priceResults))
+	for i, result := range priceResults {
+		prices := &priceSyncPriceCalculationPb.ReplayedPrices{
+			PromotionPrices: result.PromotionPrices,
+		}
+		if r.request.GetQueries()[i].PNormalPrice != nil {
+			prices.NormalPrice = proto.Int64(result.NormalPrice)
+		}
+		results = append(results, &priceSyncPriceCalculationPb.ReplayPriceResult{
+			Prices: prices,
+			Traces: convertPriceTracesToPb(result.Traces),
+		})
+	}
+	return results
+}
+
+// getMismatchedPriceNames compares the prices provided in historical with the replayed ones
+func getMismatchedPriceNames(historical *priceSyncPriceCalculationPb.ReplayedPrices, replayed *priceSyncPriceCalculationPb.ReplayedPrices) []string {
+	mismatched := make([]string, 0)
+	if historical.NormalPrice != nil && historical.GetNormalPrice() != replayed.GetNormalPrice() {
+		mismatched = append(mismatched, model.PriceNameNormal)
+	}
+	if len(historical.GetPromotionPrices()) > 0 && !slice.EqualInt64(historical.GetPromotionPrices(), replayed.GetPromotionPrices()) {
+		mismatched = append(mismatched, model.PriceNamePromotion)
+	}
+	if historical.SettlementPrice != nil && historical.GetSettlementPrice() != replayed.GetSettlementPrice() {
+		mismatched = append(mismatched, model.PriceNameSettlement)
+	}
+	return mismatched
+}
+
+func (r *replayPriceCalculationProcessor) validateRequest() error {
+	req := r.request
+	if len(req.GetARegion()) == 0 {
+		return cerr.New("invalid ARegion", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if !cutil.IsValidCountry(req.GetARegion()) {
+		return cerr.New(fmt.Sprintf("region %v is invalid", req.GetARegion()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if len(req.GetQueries()) == 0 {
+		return cerr.New("empty queries", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+
+	switch req.GetPriceType() {
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_CB_SIP):
+		if req.CbSipSnap == nil {
+			return cerr.New("cb_sip_snap is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		if len(req.GetCbSipSnap().GetSrcCurrency()) == 0 {
+			return cerr.New("invalid cb_sip_snap.SrcCurrency", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		for _, query := range req.GetQueries() {
+			if query.GetPItemPrice() <= 0 {
+				return cerr.New("invalid PItemPrice", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+			}
+			if query.GetPNormalPrice() <= 0 {
+				return cerr.New("invalid PNormalPrice", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+			}
+			if len(query.GetPPromotionPrices()) > 1 {
+				return cerr.New("at most one PPromotionPrices for cb sip", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+			}
+		}
+	case uint32(priceSyncPriceCalculationPb.Constant_PRICE_TYPE_LOCAL_SIP):
+		if req.LocalSipSnap == nil {
+			return cerr.New("local_sip_snap is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+		}
+		for _, query := range req.GetQueries() {
+			if query.PNormalPrice == nil && len(query.GetPPromotionPrices()) == 0 {
+				return cerr.New("PNormalPrice and PPromotionPrices should be provided at least one", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+			}
+			if query.PNormalPrice != nil && query.GetPNormalPrice() <= 0 {
+				return cerr.New("invalid PNormalPrice", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+			}
+		}
+	default:
+		return cerr.New(fmt.Sprintf("invalid price_type=%v", req.GetPriceType()), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+
+	for _, query := range req.GetQueries() {
+		for _, price := range query.GetPPromotionPrices() {
+			if price <= 0 {
+				return cerr.New("invalid p promotion price", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) ResolveCbSipHiddenFeeConfig(ctx context.Context, request *priceSyncPriceCalculationPb.ResolveCbSipHiddenFeeConfigRequest, response *priceSyncPriceCalculationPb.ResolveCbSipHiddenFeeConfigResponse) uint32 {
+	p := &resolveCbSipHiddenFeeConfigProcessor{
+		ctx:        ctx,
+		request:    request,
+		response:   response,
+		cbsipLogic: s.cbsipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type resolveCbSipHiddenFeeConfigProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.ResolveCbSipHiddenFeeConfigRequest
+	response *priceSyncPriceCalculationPb.ResolveCbSipHiddenFeeConfigResponse
+
+	cbsipLogic logic.CbSipLogic
+}
+
+func (r *resolveCbSipHiddenFeeConfigProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	req := r.request
+	result, err := r.cbsipLogic.ResolveHiddenFeeConfigForCbSip(r.ctx, model.CbSipResolveHiddenFeeConfigRequest{
+		PShopId:        req.GetPShopId(),
+		PItemId:        req.GetPItemId(),
+		PRegion:        req.GetPRegion(),
+		ARegion:        req.GetARegion(),
+		MerchantRegion: req.GetMerchantRegion(),
+		Weight:         req.GetWeight(),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.response.Weight = proto.Float64(result.Weight)
+	r.response.HiddenPrice = proto.Float64(result.HiddenPrice)
+	r.response.HiddenFeeConfig = result.HiddenFeeConfig
+	return nil
+}
+
+func (r *resolveCbSipHiddenFeeConfigProcessor) validateRequest() error {
+	req := r.request
+	if req.GetPShopId() == 0 || req.GetPItemId() == 0 {
+		return cerr.New("p_shop_id and p_item_id are required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if len(req.GetPRegion()) == 0 || len(req.GetARegion()) == 0 {
+		return cerr.New("p_region and a_region are required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetWeight() < 0 {
+		return cerr.New("weight must not be negative", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+func (s *CalculationServiceImpl) RollbackHpfnRateTableVersion(ctx context.Context, request *priceSyncPriceCalculationPb.RollbackHpfnRateTableVersionRequest, response *priceSyncPriceCalculationPb.RollbackHpfnRateTableVersionResponse) uint32 {
+	p := &rollbackHpfnRateTableVersionProcessor{
+		ctx:        ctx,
+		request:    request,
+		response:   response,
+		cbsipLogic: s.cbsipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type rollbackHpfnRateTableVersionProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.RollbackHpfnRateTableVersionRequest
+	response *priceSyncPriceCalculationPb.RollbackHpfnRateTableVersionResponse
+
+	cbsipLogic logic.CbSipLogic
+}
+
+func (r *rollbackHpfnRateTableVersionProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	req := r.request
+	version, err := r.cbsipLogic.RollbackHpfnRateTableVersion(r.ctx, model.RollbackHpfnRateTableVersionRequest{
+		VersionId:     req.GetVersionId(),
+		EffectiveFrom: req.GetEffectiveFrom(),
+		Operator:      req.GetOperator(),
+		Remark:        req.GetRemark(),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.response.Version = convertHpfnRateTableVersionToPb(version)
+	return nil
+}
+
+func (r *rollbackHpfnRateTableVersionProcessor) validateRequest() error {
+	req := r.request
+	if req.GetVersionId() == 0 {
+		return cerr.New("version_id is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if len(req.GetOperator()) == 0 {
+		return cerr.New("operator is required", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if req.GetEffectiveFrom() < 0 {
+		return cerr.New("effective_from must not be negative", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
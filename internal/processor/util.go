@@ -1,7 +1,12 @@
 package processor
 
 import (
+	"encoding/json"
+
+	"github.com/golang/protobuf/proto"
+
 	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 	spcommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
 )
@@ -17,3 +22,121 @@ func GetErrorCode(err error) uint32 {
 	}
 	return code
 }
+
+func validatePriceFactorOverrides(overrides *pb.PriceFactorOverrides) error {
+	if overrides == nil {
+		return nil
+	}
+	if overrides.ExchangeRate != nil && overrides.GetExchangeRate() <= 0 {
+		return cerr.New("invalid overrides.ExchangeRate", uint32(pb.Constant_ERROR_PARAMS))
+	}
+	if overrides.HiddenFee != nil && overrides.GetHiddenFee() < 0 {
+		return cerr.New("invalid overrides.HiddenFee", uint32(pb.Constant_ERROR_PARAMS))
+	}
+	if overrides.PriceRatio != nil && overrides.GetPriceRatio() <= 0 {
+		return cerr.New("invalid overrides.PriceRatio", uint32(pb.Constant_ERROR_PARAMS))
+	}
+	if overrides.ProfitRate != nil && overrides.GetProfitRate() <= 0 {
+		return cerr.New("invalid overrides.ProfitRate", uint32(pb.Constant_ERROR_PARAMS))
+	}
+	if overrides.DenominatorPriceRate != nil && overrides.GetDenominatorPriceRate() <= 0 {
+		return cerr.New("invalid overrides.DenominatorPriceRate", uint32(pb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
+
+func convertPriceFactorOverrides(overrides *pb.PriceFactorOverrides) *model.PriceFactorOverrides {
+	return &model.PriceFactorOverrides{
+		ExchangeRate:         overrides.ExchangeRate,
+		CountryMargin:        overrides.CountryMargin,
+		ShopMargin:           overrides.ShopMargin,
+		ItemMargin:           overrides.ItemMargin,
+		HiddenFee:            overrides.HiddenFee,
+		PriceRatio:           overrides.PriceRatio,
+		ServiceFee:           overrides.ServiceFee,
+		CommissionFee:        overrides.CommissionFee,
+		HandlingFee:          overrides.HandlingFee,
+		ProfitRate:           overrides.ProfitRate,
+		DenominatorPriceRate: overrides.DenominatorPriceRate,
+	}
+}
+
+func convertHpfnRateTableRows(rows []*pb.AHiddenFeeRuleRow) []*model.HpfnRateTableRow {
+	res := make([]*model.HpfnRateTableRow, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, &model.HpfnRateTableRow{
+			WeightRange: row.GetWeightRange(),
+			StartPrice:  row.GetStartPrice(),
+			StartWeight: row.GetStartWeight(),
+			RoundSize:   row.GetRoundSize(),
+			Price:       row.GetPrice(),
+			WeightStep:  row.GetWeightStep(),
+			Adjustment:  row.GetAdjustment(),
+			DescInfo:    row.GetDescInfo(),
+		})
+	}
+	return res
+}
+
+func convertHpfnRateTableVersionToPb(version *model.HpfnRateTableVersion) *pb.HpfnRateTableVersion {
+	rows := make([]*pb.AHiddenFeeRuleRow, 0, len(version.Content.Rows))
+	for _, row := range version.Content.Rows {
+		rows = append(rows, &pb.AHiddenFeeRuleRow{
+			WeightRange: proto.Int64(row.WeightRange),
+			StartPrice:  proto.Int64(row.StartPrice),
+			StartWeight: proto.Int64(row.StartWeight),
+			RoundSize:   proto.Int64(row.RoundSize),
+			Price:       proto.Int64(row.Price),
+			WeightStep:  proto.Int64(row.WeightStep),
+			Adjustment:  proto.Int64(row.Adjustment),
+			DescInfo:    proto.String(row.DescInfo),
+		})
+	}
+	rateTableCfg := ""
+	if version.Content.RateTableCfg != nil {
+		s, _ := json.Marshal(version.Content.RateTableCfg)
+		rateTableCfg = string(s)
+	}
+	return &pb.HpfnRateTableVersion{
+		VersionId:             proto.Uint64(version.Id),
+		Target:                proto.Uint32(uint32(version.Target)),
+		TargetKey:             proto.String(version.TargetKey),
+		Rows:                  rows,
+		RateTableCfg:          proto.String(rateTableCfg),
+		EffectiveFrom:         proto.Int64(version.EffectiveFrom),
+		RollbackFromVersionId: proto.Uint64(version.RollbackFromId),
+		Operator:              proto.String(version.Operator),
+		Remark:                proto.String(version.Remark),
+		Status:                proto.Uint32(uint32(version.Status)),
+		Ctime:                 proto.Int64(version.Ctime),
+	}
+}
+
+func convertQuarantinedExchangeRateToPb(rate *model.QuarantinedExchangeRate) *pb.QuarantinedExchangeRate {
+	return &pb.QuarantinedExchangeRate{
+		Id:                 proto.Uint64(rate.Id),
+		ExchangeRateSource: proto.Uint32(uint32(rate.Source)),
+		RateKey:            proto.String(rate.RateKey),
+		PreviousRate:       proto.String(rate.PreviousRate),
+		PendingRate:        proto.String(rate.PendingRate),
+		ChangePercent:      proto.Float64(rate.ChangePercent),
+		Status:             proto.Uint32(uint32(rate.Status)),
+		Operator:           proto.String(rate.Operator),
+		Ctime:              proto.Int64(rate.Ctime),
+		Mtime:              proto.Int64(rate.Mtime),
+	}
+}
+
+func convertChannelHiddenFeesToPb(fees []*model.ChannelHiddenFee) []*pb.ChannelHiddenFeeInfo {
+	infos := make([]*pb.ChannelHiddenFeeInfo, 0, len(fees))
+	for _, fee := range fees {
+		infos = append(infos, &pb.ChannelHiddenFeeInfo{
+			ChannelId:   proto.Uint64(fee.ChannelId),
+			ChannelName: proto.String(fee.ChannelName),
+			HiddenFee:   proto.Float64(fee.HiddenFee),
+			ErrCode:     proto.Int64(fee.ErrCode),
+			Chosen:      proto.Bool(fee.Chosen),
+		})
+	}
+	return infos
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/logic"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	spCommon "git.garena.com/shopee/sp_protocol/golang/common.pb"
+)
+
+const maxRateTableValidateBatchSize = 100
+
+func (s *CalculationServiceImpl) ValidateCbSipHiddenFeeRateTable(ctx context.Context, request *priceSyncPriceCalculationPb.ValidateCbSipHiddenFeeRateTableRequest, response *priceSyncPriceCalculationPb.ValidateCbSipHiddenFeeRateTableResponse) uint32 {
+	p := &validateCbSipHiddenFeeRateTableProcessor{
+		ctx:        ctx,
+		request:    request,
+		response:   response,
+		cbsipLogic: s.cbsipLogic,
+	}
+
+	err := p.process()
+	if err != nil {
+		response.DebugMsg = proto.String(err.Error())
+		logging.GetLogger(ctx).Error("response error", ulog.Error(err))
+		return GetErrorCode(err)
+	}
+	return uint32(spCommon.Constant_SUCCESS)
+}
+
+type validateCbSipHiddenFeeRateTableProcessor struct {
+	ctx      context.Context
+	request  *priceSyncPriceCalculationPb.ValidateCbSipHiddenFeeRateTableRequest
+	response *priceSyncPriceCalculationPb.ValidateCbSipHiddenFeeRateTableResponse
+
+	cbsipLogic logic.CbSipLogic
+}
+
+func (r *validateCbSipHiddenFeeRateTableProcessor) process() error {
+	if err := r.validateRequest(); err != nil {
+		return err
+	}
+
+	req := r.request
+	violations, err := r.cbsipLogic.ValidateHiddenFeeRateTableForCbSip(r.ctx, model.CbSipValidateRateTableRequest{
+		PShopIds:    req.GetPShopIds(),
+		ItemPShopId: req.GetItemPShopId(),
+		PItemIds:    req.GetPItemIds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.response.Violations = make([]*priceSyncPriceCalculationPb.RateTableViolation, 0, len(violations))
+	for _, v := range violations {
+		r.response.Violations = append(r.response.Violations, &priceSyncPriceCalculationPb.RateTableViolation{
+			Source:      proto.Uint32(uint32(v.Source)),
+			Type:        proto.Uint32(uint32(v.Type)),
+			HpfnKey:     proto.String(v.HpfnKey),
+			Region:      proto.String(v.Region),
+			OwnerId:     proto.Uint64(v.OwnerId),
+			RowId:       proto.Int64(v.RowId),
+			WeightRange: proto.Int64(v.WeightRange),
+			Detail:      proto.String(v.Detail),
+		})
+	}
+	return nil
+}
+
+func (r *validateCbSipHiddenFeeRateTableProcessor) validateRequest() error {
+	req := r.request
+	if len(req.GetPShopIds()) > maxRateTableValidateBatchSize || len(req.GetPItemIds()) > maxRateTableValidateBatchSize {
+		return cerr.New(fmt.Sprintf("too many p_shop_ids or p_item_ids, max=%v", maxRateTableValidateBatchSize), uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	if len(req.GetPItemIds()) > 0 && req.GetItemPShopId() == 0 {
+		return cerr.New("item_p_shop_id is required if p_item_ids is set", uint32(priceSyncPriceCalculationPb.Constant_ERROR_PARAMS))
+	}
+	return nil
+}
@@ -36,6 +36,18 @@ type CalculationService interface {
 	SetAItemRealWeight(context.Context, *SetAItemRealWeightRequest, *SetAItemRealWeightResponse) uint32
 	CreateCbSipAShopSellerDiscountPromotion(context.Context, *CreateCBSIPAShopSellerDiscountPromotionRequest, *CreateCBSIPAShopSellerDiscountPromotionResponse) uint32
 	GetCbSipAShopSellerDiscountPromotion(context.Context, *GetCBSIPAShopSellerDiscountPromotionRequest, *GetCBSIPAShopSellerDiscountPromotionResponse) uint32
+	ExplainPrice(context.Context, *ExplainPriceRequest, *ExplainPriceResponse) uint32
+	CalculatePPriceByAPriceForCbSip(context.Context, *CalculatePPriceByAPriceForCbSipRequest, *CalculatePPriceByAPriceForCbSipResponse) uint32
+	CalculatePPriceByAPriceForLocalSip(context.Context, *CalculatePPriceByAPriceForLocalSipRequest, *CalculatePPriceByAPriceForLocalSipResponse) uint32
+	ReplayPriceCalculation(context.Context, *ReplayPriceCalculationRequest, *ReplayPriceCalculationResponse) uint32
+	ResolveCbSipHiddenFeeConfig(context.Context, *ResolveCbSipHiddenFeeConfigRequest, *ResolveCbSipHiddenFeeConfigResponse) uint32
+	PreviewCbSipHiddenFeeRateTable(context.Context, *PreviewCbSipHiddenFeeRateTableRequest, *PreviewCbSipHiddenFeeRateTableResponse) uint32
+	ValidateCbSipHiddenFeeRateTable(context.Context, *ValidateCbSipHiddenFeeRateTableRequest, *ValidateCbSipHiddenFeeRateTableResponse) uint32
+	CreateHpfnRateTableVersion(context.Context, *CreateHpfnRateTableVersionRequest, *CreateHpfnRateTableVersionResponse) uint32
+	RollbackHpfnRateTableVersion(context.Context, *RollbackHpfnRateTableVersionRequest, *RollbackHpfnRateTableVersionResponse) uint32
+	ListHpfnRateTableVersions(context.Context, *ListHpfnRateTableVersionsRequest, *ListHpfnRateTableVersionsResponse) uint32
+	ListQuarantinedExchangeRates(context.Context, *ListQuarantinedExchangeRatesRequest, *ListQuarantinedExchangeRatesResponse) uint32
+	ApproveQuarantinedExchangeRate(context.Context, *ApproveQuarantinedExchangeRateRequest, *ApproveQuarantinedExchangeRateResponse) uint32
 }
 
 type CalculationServer struct {
@@ -354,6 +366,150 @@ func (s *CalculationServer) _Calculation_GetCbSipAShopSellerDiscountPromotionHan
 	return s.service.GetCbSipAShopSellerDiscountPromotion(ctx, req, resp)
 }
 
+func (s *CalculationServer) _Calculation_ExplainPriceHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*ExplainPriceRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*ExplainPriceResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.ExplainPrice(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_CalculatePPriceByAPriceForCbSipHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*CalculatePPriceByAPriceForCbSipRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*CalculatePPriceByAPriceForCbSipResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.CalculatePPriceByAPriceForCbSip(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_CalculatePPriceByAPriceForLocalSipHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*CalculatePPriceByAPriceForLocalSipRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*CalculatePPriceByAPriceForLocalSipResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.CalculatePPriceByAPriceForLocalSip(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_ReplayPriceCalculationHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*ReplayPriceCalculationRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*ReplayPriceCalculationResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.ReplayPriceCalculation(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_ResolveCbSipHiddenFeeConfigHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*ResolveCbSipHiddenFeeConfigRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*ResolveCbSipHiddenFeeConfigResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.ResolveCbSipHiddenFeeConfig(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_PreviewCbSipHiddenFeeRateTableHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*PreviewCbSipHiddenFeeRateTableRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*PreviewCbSipHiddenFeeRateTableResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.PreviewCbSipHiddenFeeRateTable(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_ValidateCbSipHiddenFeeRateTableHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*ValidateCbSipHiddenFeeRateTableRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*ValidateCbSipHiddenFeeRateTableResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.ValidateCbSipHiddenFeeRateTable(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_CreateHpfnRateTableVersionHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*CreateHpfnRateTableVersionRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*CreateHpfnRateTableVersionResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.CreateHpfnRateTableVersion(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_RollbackHpfnRateTableVersionHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*RollbackHpfnRateTableVersionRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*RollbackHpfnRateTableVersionResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.RollbackHpfnRateTableVersion(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_ListHpfnRateTableVersionsHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*ListHpfnRateTableVersionsRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*ListHpfnRateTableVersionsResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.ListHpfnRateTableVersions(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_ListQuarantinedExchangeRatesHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*ListQuarantinedExchangeRatesRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*ListQuarantinedExchangeRatesResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.ListQuarantinedExchangeRates(ctx, req, resp)
+}
+
+func (s *CalculationServer) _Calculation_ApproveQuarantinedExchangeRateHandler(ctx context.Context, request interface{}, response interface{}) uint32 {
+	req, ok := request.(*ApproveQuarantinedExchangeRateRequest)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	resp, ok := response.(*ApproveQuarantinedExchangeRateResponse)
+	if !ok {
+		return uint32(common.Constant_ERROR_SP_BODY)
+	}
+	return s.service.ApproveQuarantinedExchangeRate(ctx, req, resp)
+}
+
 func NewCalculationServer(service CalculationService) *CalculationServer {
 	return &CalculationServer{service: service}
 }
@@ -516,6 +672,78 @@ func (s *CalculationServer) ProcessorConfigs() []*sps.ProcessorConfig {
 			Req:       &GetCBSIPAShopSellerDiscountPromotionRequest{},
 			Resp:      &GetCBSIPAShopSellerDiscountPromotionResponse{},
 		},
+		{
+			Command:   CmdExplainPrice,
+			Processor: s._Calculation_ExplainPriceHandler,
+			Req:       &ExplainPriceRequest{},
+			Resp:      &ExplainPriceResponse{},
+		},
+		{
+			Command:   CmdCalculatePPriceByAPriceForCbSip,
+			Processor: s._Calculation_CalculatePPriceByAPriceForCbSipHandler,
+			Req:       &CalculatePPriceByAPriceForCbSipRequest{},
+			Resp:      &CalculatePPriceByAPriceForCbSipResponse{},
+		},
+		{
+			Command:   CmdCalculatePPriceByAPriceForLocalSip,
+			Processor: s._Calculation_CalculatePPriceByAPriceForLocalSipHandler,
+			Req:       &CalculatePPriceByAPriceForLocalSipRequest{},
+			Resp:      &CalculatePPriceByAPriceForLocalSipResponse{},
+		},
+		{
+			Command:   CmdReplayPriceCalculation,
+			Processor: s._Calculation_ReplayPriceCalculationHandler,
+			Req:       &ReplayPriceCalculationRequest{},
+			Resp:      &ReplayPriceCalculationResponse{},
+		},
+		{
+			Command:   CmdResolveCbSipHiddenFeeConfig,
+			Processor: s._Calculation_ResolveCbSipHiddenFeeConfigHandler,
+			Req:       &ResolveCbSipHiddenFeeConfigRequest{},
+			Resp:      &ResolveCbSipHiddenFeeConfigResponse{},
+		},
+		{
+			Command:   CmdPreviewCbSipHiddenFeeRateTable,
+			Processor: s._Calculation_PreviewCbSipHiddenFeeRateTableHandler,
+			Req:       &PreviewCbSipHiddenFeeRateTableRequest{},
+			Resp:      &PreviewCbSipHiddenFeeRateTableResponse{},
+		},
+		{
+			Command:   CmdValidateCbSipHiddenFeeRateTable,
+			Processor: s._Calculation_ValidateCbSipHiddenFeeRateTableHandler,
+			Req:       &ValidateCbSipHiddenFeeRateTableRequest{},
+			Resp:      &ValidateCbSipHiddenFeeRateTableResponse{},
+		},
+		{
+			Command:   CmdCreateHpfnRateTableVersion,
+			Processor: s._Calculation_CreateHpfnRateTableVersionHandler,
+			Req:       &CreateHpfnRateTableVersionRequest{},
+			Resp:      &CreateHpfnRateTableVersionResponse{},
+		},
+		{
+			Command:   CmdRollbackHpfnRateTableVersion,
+			Processor: s._Calculation_RollbackHpfnRateTableVersionHandler,
+			Req:       &RollbackHpfnRateTableVersionRequest{},
+			Resp:      &RollbackHpfnRateTableVersionResponse{},
+		},
+		{
+			Command:   CmdListHpfnRateTableVersions,
+			Processor: s._Calculation_ListHpfnRateTableVersionsHandler,
+			Req:       &ListHpfnRateTableVersionsRequest{},
+			Resp:      &ListHpfnRateTableVersionsResponse{},
+		},
+		{
+			Command:   CmdListQuarantinedExchangeRates,
+			Processor: s._Calculation_ListQuarantinedExchangeRatesHandler,
+			Req:       &ListQuarantinedExchangeRatesRequest{},
+			Resp:      &ListQuarantinedExchangeRatesResponse{},
+		},
+		{
+			Command:   CmdApproveQuarantinedExchangeRate,
+			Processor: s._Calculation_ApproveQuarantinedExchangeRateHandler,
+			Req:       &ApproveQuarantinedExchangeRateRequest{},
+			Resp:      &ApproveQuarantinedExchangeRateResponse{},
+		},
 	}
 	return processors
 }
@@ -547,4 +775,16 @@ const (
 	CmdSetAItemRealWeight                      = "price.sync_price.calculation.set_a_item_real_weight"
 	CmdCreateCbSipAShopSellerDiscountPromotion = "price.sync_price.calculation.create_cb_sip_a_shop_seller_discount_promotion"
 	CmdGetCbSipAShopSellerDiscountPromotion    = "price.sync_price.calculation.get_cb_sip_a_shop_seller_discount_promotion"
+	CmdExplainPrice                            = "price.sync_price.calculation.explain_price"
+	CmdCalculatePPriceByAPriceForCbSip         = "price.sync_price.calculation.calculate_p_price_by_a_price_for_cb_sip"
+	CmdCalculatePPriceByAPriceForLocalSip      = "price.sync_price.calculation.calculate_p_price_by_a_price_for_local_sip"
+	CmdReplayPriceCalculation                  = "price.sync_price.calculation.replay_price_calculation"
+	CmdResolveCbSipHiddenFeeConfig             = "price.sync_price.calculation.resolve_cb_sip_hidden_fee_config"
+	CmdPreviewCbSipHiddenFeeRateTable          = "price.sync_price.calculation.preview_cb_sip_hidden_fee_rate_table"
+	CmdValidateCbSipHiddenFeeRateTable         = "price.sync_price.calculation.validate_cb_sip_hidden_fee_rate_table"
+	CmdCreateHpfnRateTableVersion              = "price.sync_price.calculation.create_hpfn_rate_table_version"
+	CmdRollbackHpfnRateTableVersion            = "price.sync_price.calculation.rollback_hpfn_rate_table_version"
+	CmdListHpfnRateTableVersions               = "price.sync_price.calculation.list_hpfn_rate_table_versions"
+	CmdListQuarantinedExchangeRates            = "price.sync_price.calculation.list_quarantined_exchange_rates"
+	CmdApproveQuarantinedExchangeRate          = "price.sync_price.calculation.approve_quarantined_exchange_rate"
 )
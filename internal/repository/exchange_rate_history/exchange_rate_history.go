@@ -0,0 +1,211 @@
+package exchange_rate_history
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/infra/snowflake"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+const (
+	observationQueueSize = 1024
+	// a key not seen for the duration is dropped from lastStored, it is checked against the DB again when seen
+	lastStoredExpire        = 24 * time.Hour
+	lastStoredSweepInterval = time.Hour
+)
+
+type ExchangeRateHistoryRepo interface {
+	// Observe records the rates of source seen now in background, a rate is stored only when it differs from the last
+	// stored one of its key. errors are logged and never returned, the rates are dropped if the queue is full, so
+	// callers are not blocked by the history
+	Observe(ctx context.Context, source pb.Constant_ExchangeRateSource, rates map[string]string)
+	// GetExchangeRateAsOf the rate of key which applied at asOf unix second, ok is false if the service had not seen
+	// any rate of key by then
+	GetExchangeRateAsOf(ctx context.Context, source pb.Constant_ExchangeRateSource, rateKey string, asOf int64) (rate string, ok bool, err error)
+	// GuardJump observes refreshed rates of source and returns the rates to serve. a rate which moved more than the
+	// configured percent from the last served rate of its key is quarantined, the last served rate is served instead
+	// until the quarantine is approved
+	GuardJump(ctx context.Context, source pb.Constant_ExchangeRateSource, rates map[string]string) map[string]string
+	// ListQuarantinedExchangeRates the latest quarantines of status, of all status if unknown
+	ListQuarantinedExchangeRates(ctx context.Context, status pb.Constant_ExchangeRateQuarantineStatus) ([]*model.QuarantinedExchangeRate, error)
+	// ApproveQuarantinedExchangeRate approves a pending quarantine, the rate is served from the next refresh of its source
+	ApproveQuarantinedExchangeRate(ctx context.Context, id uint64, operator string) (*model.QuarantinedExchangeRate, error)
+}
+
+type historyKey struct {
+	source  pb.Constant_ExchangeRateSource
+	rateKey string
+}
+
+type observation struct {
+	source pb.Constant_ExchangeRateSource
+	rates  map[string]string
+	seenAt int64
+}
+
+type lastStoredRate struct {
+	rate   string
+	seenAt time.Time
+}
+
+// ExchangeRateHistoryRepoImpl stores history into exchange_rate_history_tab by a background worker, the last stored
+// rate of each recently seen key is kept in memory to skip the unchanged rates. jumps are quarantined into
+// exchange_rate_quarantine_tab, judged against the last rate served by this instance
+type ExchangeRateHistoryRepoImpl struct {
+	observations chan *observation
+	// lastStored is only accessed by the worker
+	lastStored map[historyKey]*lastStoredRate
+
+	quarantineMu *sync.Mutex
+	quarantines  map[quarantineKey]*quarantineState
+	lastServed   map[historyKey]string
+
+	sipRepo sip_db.SipRepo
+}
+
+func NewExchangeRateHistoryRepoImpl(sipRepo sip_db.SipRepo) *ExchangeRateHistoryRepoImpl {
+	e := newExchangeRateHistoryRepoImpl(sipRepo)
+	go e.recordObservations()
+	return e
+}
+
+func newExchangeRateHistoryRepoImpl(sipRepo sip_db.SipRepo) *ExchangeRateHistoryRepoImpl {
+	return &ExchangeRateHistoryRepoImpl{
+		observations: make(chan *observation, observationQueueSize),
+		lastStored:   map[historyKey]*lastStoredRate{},
+
+		quarantineMu: &sync.Mutex{},
+		quarantines:  map[quarantineKey]*quarantineState{},
+		lastServed:   map[historyKey]string{},
+
+		sipRepo: sipRepo,
+	}
+}
+
+// CbSipRateKey key of a row of SIP exchange_rate_tab, in the direction stored in the row
+func CbSipRateKey(sourceCurrency, targetCurrency string) string {
+	return strings.ToUpper(sourceCurrency) + ":" + strings.ToUpper(targetCurrency)
+}
+
+// SellerPlatformRateKey key of the merchant rate to region
+func SellerPlatformRateKey(merchantId uint64, region string) string {
+	return strconv.FormatUint(merchantId, 10) + ":" + strings.ToUpper(region)
+}
+
+// OrderMartRateKey key of the order mart rate of currency
+func OrderMartRateKey(currency string) string {
+	return strings.ToUpper(currency)
+}
+
+// FormatRate formats a float rate for Observe, the same rate is always formatted the same
+func FormatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+
+func (e *ExchangeRateHistoryRepoImpl) Observe(ctx context.Context, source pb.Constant_ExchangeRateSource, rates map[string]string) {
+	if len(rates) == 0 {
+		return
+	}
+	select {
+	case e.observations <- &observation{source: source, rates: rates, seenAt: time.Now().Unix()}:
+	default:
+		logging.GetLogger(ctx).Warn(fmt.Sprintf("[Exchange Rate History] queue is full, rates are not recorded, source=%v, count=%v",
+			source, len(rates)))
+	}
+}
+
+func (e *ExchangeRateHistoryRepoImpl) recordObservations() {
+	ctx := context.TODO()
+	tick := time.NewTicker(lastStoredSweepInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case o := <-e.observations:
+			e.record(ctx, o)
+		case <-tick.C:
+			e.expireLastStored()
+		}
+	}
+}
+
+func (e *ExchangeRateHistoryRepoImpl) record(ctx context.Context, o *observation) {
+	for rateKey, rate := range o.rates {
+		key := historyKey{source: o.source, rateKey: rateKey}
+		last, ok := e.lastStored[key]
+		if ok && last.rate == rate {
+			last.seenAt = time.Now()
+			continue
+		}
+
+		session := e.sipRepo.DbSession()
+		if !ok {
+			// first seen by this instance, the rate may be stored by other instances or before restart
+			latest, err := e.sipRepo.GetExchangeRateHistoryAsOf(ctx, session, uint32(o.source), rateKey, o.seenAt)
+			if err != nil {
+				logging.GetLogger(ctx).Error(fmt.Sprintf("[Exchange Rate History] failed to get latest history, source=%v, rateKey=%v, err=%v",
+					o.source, rateKey, err))
+				continue
+			}
+			if latest != nil && latest.ExchangeRate == rate {
+				e.lastStored[key] = &lastStoredRate{rate: rate, seenAt: time.Now()}
+				continue
+			}
+		}
+
+		id, err := snowflake.GetGenIDWorker().NextId(ctx)
+		if err != nil {
+			logging.GetLogger(ctx).Error(fmt.Sprintf("[Exchange Rate History] error generating next history id, err=%v", err))
+			continue
+		}
+		record := &sip_db.ExchangeRateHistory{
+			Id:           id,
+			Source:       uint32(o.source),
+			RateKey:      rateKey,
+			ExchangeRate: rate,
+			SeenFrom:     o.seenAt,
+		}
+		if err := e.sipRepo.CreateExchangeRateHistory(ctx, session, record); err != nil {
+			logging.GetLogger(ctx).Error(fmt.Sprintf("[Exchange Rate History] %v", err))
+			continue
+		}
+		e.lastStored[key] = &lastStoredRate{rate: rate, seenAt: time.Now()}
+		from := ""
+		if ok {
+			from = last.rate
+		}
+		logging.GetLogger(ctx).Info(fmt.Sprintf("[Exchange Rate History] rate changed, source=%v, rateKey=%v, from=%v, to=%v",
+			o.source, rateKey, from, rate))
+	}
+}
+
+func (e *ExchangeRateHistoryRepoImpl) expireLastStored() {
+	for key, last := range e.lastStored {
+		if time.Since(last.seenAt) > lastStoredExpire {
+			delete(e.lastStored, key)
+		}
+	}
+}
+
+func (e *ExchangeRateHistoryRepoImpl) GetExchangeRateAsOf(ctx context.Context, source pb.Constant_ExchangeRateSource, rateKey string, asOf int64) (string, bool, error) {
+	if asOf <= 0 {
+		return "", false, cerr.New(fmt.Sprintf("invalid as of time: %v", asOf), uint32(pb.Constant_ERROR_PARAMS))
+	}
+	session := e.sipRepo.DbSession()
+	record, err := e.sipRepo.GetExchangeRateHistoryAsOf(ctx, session, uint32(source), rateKey, asOf)
+	if err != nil {
+		return "", false, err
+	}
+	if record == nil {
+		return "", false, nil
+	}
+	return record.ExchangeRate, true, nil
+}
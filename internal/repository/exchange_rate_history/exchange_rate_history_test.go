@@ -0,0 +1,209 @@
+package exchange_rate_history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/infra/snowflake"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/orm"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+)
+
+type fakeSipRepo struct {
+	sip_db.SipRepo
+	histories   []*sip_db.ExchangeRateHistory
+	quarantines []*sip_db.ExchangeRateQuarantine
+	// err is returned by the history and quarantine queries if set
+	err error
+}
+
+func (f *fakeSipRepo) DbSession() orm.DbSession {
+	return nil
+}
+
+func (f *fakeSipRepo) GetExchangeRateHistoryAsOf(ctx context.Context, session orm.DbSession, source uint32, rateKey string, asOf int64) (*sip_db.ExchangeRateHistory, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var latest *sip_db.ExchangeRateHistory
+	for _, history := range f.histories {
+		if history.Source == source && history.RateKey == rateKey && history.SeenFrom <= asOf {
+			latest = history
+		}
+	}
+	return latest, nil
+}
+
+func (f *fakeSipRepo) CreateExchangeRateHistory(ctx context.Context, session orm.DbSession, history *sip_db.ExchangeRateHistory) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.histories = append(f.histories, history)
+	return nil
+}
+
+func (f *fakeSipRepo) GetExchangeRateQuarantinesByPendingRate(ctx context.Context, session orm.DbSession, source uint32, rateKey string, pendingRate string) ([]*sip_db.ExchangeRateQuarantine, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var res []*sip_db.ExchangeRateQuarantine
+	for _, quarantine := range f.quarantines {
+		if quarantine.Source == source && quarantine.RateKey == rateKey && quarantine.PendingRate == pendingRate {
+			res = append(res, quarantine)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeSipRepo) GetExchangeRateQuarantineById(ctx context.Context, session orm.DbSession, id int64) (*sip_db.ExchangeRateQuarantine, error) {
+	for _, quarantine := range f.quarantines {
+		if quarantine.Id == id {
+			return quarantine, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeSipRepo) GetExchangeRateQuarantines(ctx context.Context, session orm.DbSession, status uint32, limit int) ([]*sip_db.ExchangeRateQuarantine, error) {
+	var res []*sip_db.ExchangeRateQuarantine
+	for _, quarantine := range f.quarantines {
+		if status == 0 || quarantine.Status == status {
+			res = append(res, quarantine)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeSipRepo) CreateExchangeRateQuarantine(ctx context.Context, session orm.DbSession, quarantine *sip_db.ExchangeRateQuarantine) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.quarantines = append(f.quarantines, quarantine)
+	return nil
+}
+
+func (f *fakeSipRepo) ApproveExchangeRateQuarantine(ctx context.Context, session orm.DbSession, id int64, operator string) error {
+	for _, quarantine := range f.quarantines {
+		if quarantine.Id == id && quarantine.Status == uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_PENDING) {
+			quarantine.Status = uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_APPROVED)
+			quarantine.Operator = operator
+		}
+	}
+	return nil
+}
+
+// recordObserved records the queued observations as the worker does
+func recordObserved(ctx context.Context, repo *ExchangeRateHistoryRepoImpl) {
+	for {
+		select {
+		case o := <-repo.observations:
+			repo.record(ctx, o)
+		default:
+			return
+		}
+	}
+}
+
+func TestExchangeRateHistoryRepoImpl(t *testing.T) {
+	ctx := context.Background()
+	snowflake.NewGenIDWorker()
+	sipRepo := &fakeSipRepo{histories: []*sip_db.ExchangeRateHistory{
+		{Id: 1, Source: uint32(pb.Constant_ORDER_MART_EXCHANGE_RATE), RateKey: "SGD", ExchangeRate: "1.35", SeenFrom: 100},
+	}}
+	repo := newExchangeRateHistoryRepoImpl(sipRepo)
+
+	// the rate stored before restart is not stored again
+	repo.Observe(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, map[string]string{"SGD": "1.35"})
+	recordObserved(ctx, repo)
+	assert.Len(t, sipRepo.histories, 1)
+
+	repo.Observe(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, map[string]string{"SGD": "1.36", "MYR": "4.7"})
+	repo.Observe(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, map[string]string{"SGD": "1.36", "MYR": "4.7"})
+	repo.Observe(ctx, pb.Constant_CB_SIP_EXCHANGE_RATE, map[string]string{"SGD": "1.36"})
+	recordObserved(ctx, repo)
+	assert.Len(t, sipRepo.histories, 4)
+
+	rate, ok, err := repo.GetExchangeRateAsOf(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, "SGD", 100)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1.35", rate)
+	_, ok, err = repo.GetExchangeRateAsOf(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, "SGD", 99)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, _, err = repo.GetExchangeRateAsOf(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, "SGD", 0)
+	assert.Error(t, err)
+
+	// keys not seen recently are dropped from memory and checked against the DB when seen again
+	repo.lastStored[historyKey{source: pb.Constant_ORDER_MART_EXCHANGE_RATE, rateKey: "MYR"}].seenAt = time.Now().Add(-lastStoredExpire - time.Minute)
+	repo.expireLastStored()
+	assert.Len(t, repo.lastStored, 2)
+	repo.Observe(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, map[string]string{"MYR": "4.7"})
+	recordObserved(ctx, repo)
+	assert.Len(t, sipRepo.histories, 4)
+
+	// rates are dropped instead of blocking the caller when the queue is full
+	for i := 0; i <= observationQueueSize; i++ {
+		repo.Observe(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, map[string]string{"SGD": "1.37"})
+	}
+	assert.Len(t, repo.observations, observationQueueSize)
+}
+
+func TestGuardJump(t *testing.T) {
+	ctx := context.Background()
+	snowflake.NewGenIDWorker()
+	config.SetGlobal(&config.Config{CommonCfg: &config.CommonConfig{ExchangeRateJumpPercent: 20}})
+	sipRepo := &fakeSipRepo{histories: []*sip_db.ExchangeRateHistory{
+		{Id: 1, Source: uint32(pb.Constant_ORDER_MART_EXCHANGE_RATE), RateKey: "IDR", ExchangeRate: "15000", SeenFrom: 100},
+	}}
+	repo := newExchangeRateHistoryRepoImpl(sipRepo)
+	source := pb.Constant_ORDER_MART_EXCHANGE_RATE
+
+	// small moves and first seen keys are served
+	served := repo.GuardJump(ctx, source, map[string]string{"IDR": "15500", "SGD": "1.35"})
+	recordObserved(ctx, repo)
+	assert.Equal(t, map[string]string{"IDR": "15500", "SGD": "1.35"}, served)
+	assert.Len(t, sipRepo.histories, 3)
+
+	// the jump is quarantined once and the previous rate is served
+	for i := 0; i < 2; i++ {
+		served = repo.GuardJump(ctx, source, map[string]string{"IDR": "155", "SGD": "1.36"})
+		recordObserved(ctx, repo)
+		assert.Equal(t, map[string]string{"IDR": "15500", "SGD": "1.36"}, served)
+	}
+	assert.Len(t, sipRepo.quarantines, 1)
+	assert.Len(t, sipRepo.histories, 4)
+
+	pending, err := repo.ListQuarantinedExchangeRates(ctx, pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_PENDING)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "15500", pending[0].PreviousRate)
+	assert.Equal(t, "155", pending[0].PendingRate)
+	assert.InDelta(t, 99, pending[0].ChangePercent, 1e-9)
+
+	approved, err := repo.ApproveQuarantinedExchangeRate(ctx, pending[0].Id, "ops")
+	assert.NoError(t, err)
+	assert.Equal(t, pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_APPROVED, approved.Status)
+	_, err = repo.ApproveQuarantinedExchangeRate(ctx, pending[0].Id, "ops")
+	assert.Equal(t, uint32(pb.Constant_ERROR_EXCHANGE_RATE_QUARANTINE_NOT_PENDING), cerr.Code(err))
+
+	// approved rate is served from the next refresh
+	served = repo.GuardJump(ctx, source, map[string]string{"IDR": "155"})
+	recordObserved(ctx, repo)
+	assert.Equal(t, "155", served["IDR"])
+	assert.Len(t, sipRepo.histories, 5)
+
+	// a rate which is not a number is quarantined
+	served = repo.GuardJump(ctx, source, map[string]string{"SGD": ""})
+	assert.Equal(t, "1.36", served["SGD"])
+
+	// a jump of a served key is rejected while the DB is down, a key never served can not be judged
+	sipRepo.err = cerr.New("db down", uint32(pb.Constant_ERROR_DATABASE))
+	served = repo.GuardJump(ctx, source, map[string]string{"IDR": "1", "MYR": "4.7"})
+	assert.Equal(t, map[string]string{"IDR": "155", "MYR": "4.7"}, served)
+}
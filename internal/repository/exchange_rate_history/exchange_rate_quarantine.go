@@ -0,0 +1,240 @@
+package exchange_rate_history
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"git.garena.com/shopee/platform/service-governance/observability/metric"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/infra/snowflake"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+const (
+	exchangeRateJumpMetricName = "exchange_rate_jump"
+
+	// a pending quarantine is checked again after the interval, so approvals by other instances are picked up
+	quarantineRecheckInterval = 10 * time.Second
+	quarantineListLimit       = 200
+)
+
+type quarantineKey struct {
+	historyKey
+	pendingRate string
+}
+
+type quarantineState struct {
+	approved  bool
+	checkedAt time.Time
+}
+
+func (e *ExchangeRateHistoryRepoImpl) GuardJump(ctx context.Context, source pb.Constant_ExchangeRateSource, rates map[string]string) map[string]string {
+	served := make(map[string]string, len(rates))
+	jumpPercent := config.GetExchangeRateJumpPercent()
+	for rateKey, rate := range rates {
+		served[rateKey] = rate
+		if jumpPercent <= 0 {
+			continue
+		}
+		key := historyKey{source: source, rateKey: rateKey}
+		previous, ok := e.getLastAccepted(ctx, key)
+		if !ok || previous == rate {
+			continue
+		}
+		changePercent, ok := exchangeRateChangePercent(previous, rate)
+		if !ok || changePercent <= jumpPercent {
+			continue
+		}
+		if !e.isJumpApproved(ctx, quarantineKey{historyKey: key, pendingRate: rate}, previous, changePercent) {
+			served[rateKey] = previous
+		}
+	}
+	e.quarantineMu.Lock()
+	for rateKey, rate := range served {
+		e.lastServed[historyKey{source: source, rateKey: rateKey}] = rate
+	}
+	e.quarantineMu.Unlock()
+	e.Observe(ctx, source, served)
+	return served
+}
+
+func (e *ExchangeRateHistoryRepoImpl) ListQuarantinedExchangeRates(ctx context.Context, status pb.Constant_ExchangeRateQuarantineStatus) ([]*model.QuarantinedExchangeRate, error) {
+	session := e.sipRepo.DbSession()
+	records, err := e.sipRepo.GetExchangeRateQuarantines(ctx, session, uint32(status), quarantineListLimit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.QuarantinedExchangeRate, 0, len(records))
+	for _, record := range records {
+		res = append(res, convertExchangeRateQuarantine(record))
+	}
+	return res, nil
+}
+
+func (e *ExchangeRateHistoryRepoImpl) ApproveQuarantinedExchangeRate(ctx context.Context, id uint64, operator string) (*model.QuarantinedExchangeRate, error) {
+	session := e.sipRepo.DbSession()
+	record, err := e.sipRepo.GetExchangeRateQuarantineById(ctx, session, int64(id))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, cerr.New(fmt.Sprintf("exchange rate quarantine not found, id=%v", id), uint32(pb.Constant_ERROR_NOT_FOUND))
+	}
+	if record.Status != uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_PENDING) {
+		return nil, cerr.New(fmt.Sprintf("exchange rate quarantine is not pending, id=%v, status=%v, operator=%v", id, record.Status, record.Operator),
+			uint32(pb.Constant_ERROR_EXCHANGE_RATE_QUARANTINE_NOT_PENDING))
+	}
+	if err := e.sipRepo.ApproveExchangeRateQuarantine(ctx, session, int64(id), operator); err != nil {
+		return nil, err
+	}
+	record, err = e.sipRepo.GetExchangeRateQuarantineById(ctx, session, int64(id))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, cerr.New(fmt.Sprintf("exchange rate quarantine not found after approval, id=%v", id), uint32(pb.Constant_ERROR_NOT_FOUND))
+	}
+
+	// approved rate is served by this instance from the next refresh without waiting for recheck
+	e.quarantineMu.Lock()
+	e.quarantines[quarantineKey{
+		historyKey:  historyKey{source: pb.Constant_ExchangeRateSource(record.Source), rateKey: record.RateKey},
+		pendingRate: record.PendingRate,
+	}] = &quarantineState{approved: true, checkedAt: time.Now()}
+	e.quarantineMu.Unlock()
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[Exchange Rate History] quarantined rate approved, id=%v, source=%v, rateKey=%v, from=%v, to=%v, operator=%v",
+		id, record.Source, record.RateKey, record.PreviousRate, record.PendingRate, operator))
+	return convertExchangeRateQuarantine(record), nil
+}
+
+// getLastAccepted the last rate of key served by this instance, or observed by any instance if this instance has not
+// served key yet. ok is false if key is never seen or history is not available
+func (e *ExchangeRateHistoryRepoImpl) getLastAccepted(ctx context.Context, key historyKey) (string, bool) {
+	// the served rate is kept even if history can not be stored, so a jump is still judged when the DB is down
+	e.quarantineMu.Lock()
+	last, ok := e.lastServed[key]
+	e.quarantineMu.Unlock()
+	if ok {
+		return last, true
+	}
+
+	session := e.sipRepo.DbSession()
+	latest, err := e.sipRepo.GetExchangeRateHistoryAsOf(ctx, session, uint32(key.source), key.rateKey, time.Now().Unix())
+	if err != nil {
+		logging.GetLogger(ctx).Error(fmt.Sprintf("[Exchange Rate History] failed to get latest history for jump detection, source=%v, rateKey=%v, err=%v",
+			key.source, key.rateKey, err))
+		return "", false
+	}
+	if latest == nil {
+		return "", false
+	}
+	return latest.ExchangeRate, true
+}
+
+// isJumpApproved quarantines the jump if it is not yet, the jump is rejected while the quarantine is pending or can
+// not be checked. the DB is checked without holding quarantineMu, callers of the same key reject the jump meanwhile
+func (e *ExchangeRateHistoryRepoImpl) isJumpApproved(ctx context.Context, key quarantineKey, previous string, changePercent float64) bool {
+	e.quarantineMu.Lock()
+	state, ok := e.quarantines[key]
+	if ok && (state.approved || time.Since(state.checkedAt) < quarantineRecheckInterval) {
+		e.quarantineMu.Unlock()
+		return state.approved
+	}
+	e.quarantines[key] = &quarantineState{checkedAt: time.Now()}
+	e.quarantineMu.Unlock()
+
+	if !ok {
+		logging.GetLogger(ctx).Error(fmt.Sprintf("[Exchange Rate History] rate jumped, previous rate is served until approved, source=%v, rateKey=%v, from=%v, to=%v, changePercent=%.2f",
+			key.source, key.rateKey, previous, key.pendingRate, changePercent))
+		_ = metric.RPCCustomReporter.ReportCounter(exchangeRateJumpMetricName, map[string]string{
+			"source":   key.source.String(),
+			"rate_key": key.rateKey,
+		}, 1)
+	}
+
+	approved, err := e.checkQuarantine(ctx, key, previous)
+	if err != nil {
+		logging.GetLogger(ctx).Error(fmt.Sprintf("[Exchange Rate History] %v", err))
+		return false
+	}
+
+	e.quarantineMu.Lock()
+	defer e.quarantineMu.Unlock()
+	// an approval made by this instance meanwhile is kept
+	if state, ok := e.quarantines[key]; ok && state.approved {
+		return true
+	}
+	e.quarantines[key] = &quarantineState{approved: approved, checkedAt: time.Now()}
+	return approved
+}
+
+// checkQuarantine whether the quarantine of key is approved, a pending quarantine is created if there is none
+func (e *ExchangeRateHistoryRepoImpl) checkQuarantine(ctx context.Context, key quarantineKey, previous string) (bool, error) {
+	session := e.sipRepo.DbSession()
+	records, err := e.sipRepo.GetExchangeRateQuarantinesByPendingRate(ctx, session, uint32(key.source), key.rateKey, key.pendingRate)
+	if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if record.Status == uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_APPROVED) {
+			return true, nil
+		}
+	}
+	if len(records) > 0 {
+		return false, nil
+	}
+
+	id, err := snowflake.GetGenIDWorker().NextId(ctx)
+	if err != nil {
+		return false, cerr.Wrap(err, "error generating next quarantine id", uint32(pb.Constant_ERROR_INTERNAL))
+	}
+	record := &sip_db.ExchangeRateQuarantine{
+		Id:           id,
+		Source:       uint32(key.source),
+		RateKey:      key.rateKey,
+		PreviousRate: previous,
+		PendingRate:  key.pendingRate,
+		Status:       uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_PENDING),
+	}
+	if err := e.sipRepo.CreateExchangeRateQuarantine(ctx, session, record); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// exchangeRateChangePercent ok is false if previous is not a positive number, a rate which is not a number changes 100%
+func exchangeRateChangePercent(previous string, rate string) (float64, bool) {
+	previousRate, err := strconv.ParseFloat(previous, 64)
+	if err != nil || previousRate <= 0 {
+		return 0, false
+	}
+	newRate, err := strconv.ParseFloat(rate, 64)
+	if err != nil || newRate < 0 {
+		newRate = 0
+	}
+	return math.Abs(newRate-previousRate) / previousRate * 100, true
+}
+
+func convertExchangeRateQuarantine(record *sip_db.ExchangeRateQuarantine) *model.QuarantinedExchangeRate {
+	changePercent, _ := exchangeRateChangePercent(record.PreviousRate, record.PendingRate)
+	return &model.QuarantinedExchangeRate{
+		Id:            uint64(record.Id),
+		Source:        pb.Constant_ExchangeRateSource(record.Source),
+		RateKey:       record.RateKey,
+		PreviousRate:  record.PreviousRate,
+		PendingRate:   record.PendingRate,
+		ChangePercent: changePercent,
+		Status:        pb.Constant_ExchangeRateQuarantineStatus(record.Status),
+		Operator:      record.Operator,
+		Ctime:         record.Ctime,
+		Mtime:         record.Mtime,
+	}
+}
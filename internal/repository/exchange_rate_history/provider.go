@@ -0,0 +1,10 @@
+package exchange_rate_history
+
+import (
+	"github.com/google/wire"
+)
+
+var ProviderSet = wire.NewSet(
+	NewExchangeRateHistoryRepoImpl,
+	wire.Bind(new(ExchangeRateHistoryRepo), new(*ExchangeRateHistoryRepoImpl)),
+)
@@ -14,6 +14,7 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_v2_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/service"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
 )
 
 type CalculationFactorsRepo interface {
@@ -35,18 +36,22 @@ type CalculationFactorsRepo interface {
 	// LocalSIP
 	GetAllLocalSipPriceConfig(ctx context.Context) (map[string]map[string]*model.CommonPriceConfig, error)
 	GetPItemDataForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64) (service.PrimaryItemData, error)
+	GetPItemDimensionForLocalSip(ctx context.Context, pItemId uint64, pRegion string) *model.ItemDimension
 	GetLocalSipConfigByRegionBatch(ctx context.Context, pRegion string, aRegions []string) (map[string]*model.CommonPriceConfig, error)
 	GetAShopDataForLocalSip(ctx context.Context, aShopIds []uint64) (map[uint64]*internal.AShopData, error)
 	GetAItemDataBatchForLocalSip(ctx context.Context, pShopId uint64, pItemId uint64, aShopIds []uint64) (map[uint64]*internal.AItemData, error)
 	GetShippingFeeForLocalSip(ctx context.Context, pRegion string, queries []model.LocalSipShippingFeeQuery, calcForCreate bool) ([]model.LocalSipShippingFeeResult, error)
-	GetInitialHiddenPriceForLocalSip(ctx context.Context, pItemId uint64, pShopId uint64, pRegion string, queries []model.LocalSipHiddenPriceQuery) ([]model.LocalSipHiddenPriceResult, error)
+	GetInitialHiddenPriceForLocalSip(ctx context.Context, pItemId uint64, pShopId uint64, pRegion string, queries []model.LocalSipHiddenPriceQuery, calcForCreate bool) ([]model.LocalSipHiddenPriceResult, error)
 
 	// CB SIP
-	GetHiddenPriceForCbSip(ctx context.Context, pItem *sip_v2_db.MstItemRecord, pShop *sip_db.MstShop, merchantRegion string, pRegion string, aRegion string, weight float64, psite bool) float64
+	GetHiddenPriceForCbSip(ctx context.Context, pItem *sip_v2_db.MstItemRecord, pShop *sip_db.MstShop, merchantRegion string, pRegion string, aRegion string, weight float64, psite bool) (float64, *model.HiddenPriceConf)
+	GetHiddenPriceByHpfnKeyForCbSip(ctx context.Context, hpfnKey string, weight float64) (float64, *model.HiddenPriceConf)
 	GetShopServiceFeeForCbSip(ctx context.Context, region string, shopId int64) (float64, error)
 	GetShopCommissionFeeForCbSip(ctx context.Context, region string, shopId int64) (float64, error)
 	GetHandlingFeeForCbSip(ctx context.Context) (float64, error)
-	GetExchangeRateForCbSip(ctx context.Context, sourceCurrency, targetCurrency string, needProcessPrecision bool) (float64, error)
+	// GetExchangeRateForCbSip path is the currencies from source to target, with the pivot currency in between if the
+	// rate is triangulated
+	GetExchangeRateForCbSip(ctx context.Context, sourceCurrency, targetCurrency string, needProcessPrecision bool) (exchangeRate float64, path []string, err error)
 	GetCurrencyForCbSip(ctx context.Context, merchantId uint64, aRegion string, pItemInfo *ib.ProductInfo) (srcCurrency, dstCurrency string, err error)
 	GetCountryMarginForCbSip(ctx context.Context, pRegion string, aRegion string) (float64, error)
 
@@ -56,7 +61,8 @@ type CalculationFactorsRepo interface {
 
 	// price factor
 	GetMerchantExchangeRateInfo(ctx context.Context, merchantId uint64) (*internalExchangeRatePb.ExchangeRateInfo, error)
-	GetOrderMartExchangeRate(ctx context.Context, srcCurrency string, dstCurrency string) (float64, error)
+	// GetOrderMartExchangeRate rateAge is in seconds, model.UnknownRateAge if unknown
+	GetOrderMartExchangeRate(ctx context.Context, srcCurrency string, dstCurrency string) (exchangeRate float64, rateAge int64, err error)
 }
 
 func GetCalcWeightByAItemAndPItemWeight(aRealWeight int64, pWeight int64) int64 {
@@ -66,3 +72,27 @@ func GetCalcWeightByAItemAndPItemWeight(aRealWeight int64, pWeight int64) int64
 
 	return pWeight
 }
+
+// GetChargeableWeight weight the fees of item are looked up with. volumetric weight of package dimension is charged
+// instead of the weight picked by GetCalcWeightByAItemAndPItemWeight if it is enabled in A region and heavier
+func GetChargeableWeight(aRealWeight int64, pWeight int64, dimension *model.ItemDimension, aRegion string) model.ChargeableWeight {
+	res := model.ChargeableWeight{
+		Weight: GetCalcWeightByAItemAndPItemWeight(aRealWeight, pWeight),
+		Source: pb.Constant_WEIGHT_SOURCE_P_WEIGHT,
+	}
+	if aRealWeight > 0 {
+		res.Source = pb.Constant_WEIGHT_SOURCE_A_REAL_WEIGHT
+	}
+
+	setting, ok := config.GetVolumetricWeightSetting(aRegion)
+	if !ok || dimension == nil {
+		return res
+	}
+	volumetricKg := dimension.Length * dimension.Width * dimension.Height / setting.Divisor
+	res.VolumetricWeight = calcutil.GramToDbWeight(volumetricKg * 1000)
+	if res.VolumetricWeight > res.Weight {
+		res.Weight = res.VolumetricWeight
+		res.Source = pb.Constant_WEIGHT_SOURCE_VOLUMETRIC_WEIGHT
+	}
+	return res
+}
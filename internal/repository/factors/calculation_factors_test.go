@@ -0,0 +1,48 @@
+package factors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+func TestGetChargeableWeight(t *testing.T) {
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: &config.SIPMigrationConfig{
+			VolumetricWeightConfig: map[string]config.VolumetricWeightSetting{
+				"MY": {Enable: true, Divisor: 6000},
+				"TH": {Enable: false, Divisor: 6000},
+			},
+		},
+	})
+	// 6kg by volume
+	bulky := &model.ItemDimension{Length: 60, Width: 50, Height: 12}
+
+	cases := []struct {
+		name       string
+		aWeight    int64
+		pWeight    int64
+		dimension  *model.ItemDimension
+		aRegion    string
+		weight     int64
+		source     pb.Constant_WeightSource
+		volumetric int64
+	}{
+		{"a real weight", 100000, 200000, nil, "MY", 100000, pb.Constant_WEIGHT_SOURCE_A_REAL_WEIGHT, 0},
+		{"p weight", 0, 200000, nil, "MY", 200000, pb.Constant_WEIGHT_SOURCE_P_WEIGHT, 0},
+		{"volumetric heavier", 100000, 200000, bulky, "MY", 600000, pb.Constant_WEIGHT_SOURCE_VOLUMETRIC_WEIGHT, 600000},
+		{"actual heavier", 700000, 200000, bulky, "MY", 700000, pb.Constant_WEIGHT_SOURCE_A_REAL_WEIGHT, 600000},
+		{"region disabled", 100000, 200000, bulky, "TH", 100000, pb.Constant_WEIGHT_SOURCE_A_REAL_WEIGHT, 0},
+		{"region not configured", 0, 200000, bulky, "SG", 200000, pb.Constant_WEIGHT_SOURCE_P_WEIGHT, 0},
+	}
+	for _, c := range cases {
+		res := GetChargeableWeight(c.aWeight, c.pWeight, c.dimension, c.aRegion)
+		assert.Equal(t, c.weight, res.Weight, c.name)
+		assert.Equal(t, c.source, res.Source, c.name)
+		assert.Equal(t, c.volumetric, res.VolumetricWeight, c.name)
+	}
+}
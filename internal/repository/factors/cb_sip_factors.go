@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +19,7 @@ import (
 	ib "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/item_business.pb"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_common_definition.pb"
 	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_v2_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
@@ -83,24 +83,34 @@ func (c *CalculationFactorsRepoImpl) GetAllExchangeRateMapForCbSip(ctx context.C
 		return nil, err
 	}
 
+	seenRates := make(map[string]string, len(allExchangeRate))
+	for _, exchangeRate := range allExchangeRate {
+		seenRates[exchange_rate_history.CbSipRateKey(exchangeRate.SourceCurrency, exchangeRate.TargetCurrency)] = exchangeRate.ExchangeRate
+	}
+	// quarantined rates are replaced by the previous rates
+	servedRates := c.exchangeRateHistoryRepo.GuardJump(ctx, priceSyncPriceCalculationPb.Constant_CB_SIP_EXCHANGE_RATE, seenRates)
+
+	// the currencies are upper case in map, FindExchangeRateInMapForCbSip looks them up in upper case
 	result := make(map[string]map[string]string)
 	for _, exchangeRate := range allExchangeRate {
-		if _, ok := result[exchangeRate.SourceCurrency]; ok {
-			result[exchangeRate.SourceCurrency][exchangeRate.TargetCurrency] = exchangeRate.ExchangeRate
+		rate := servedRates[exchange_rate_history.CbSipRateKey(exchangeRate.SourceCurrency, exchangeRate.TargetCurrency)]
+		sourceCurrency, targetCurrency := strings.ToUpper(exchangeRate.SourceCurrency), strings.ToUpper(exchangeRate.TargetCurrency)
+		if _, ok := result[sourceCurrency]; ok {
+			result[sourceCurrency][targetCurrency] = rate
 		} else {
-			result[exchangeRate.SourceCurrency] = make(map[string]string)
-			result[exchangeRate.SourceCurrency][exchangeRate.TargetCurrency] = exchangeRate.ExchangeRate
+			result[sourceCurrency] = make(map[string]string)
+			result[sourceCurrency][targetCurrency] = rate
 		}
-		value, err := strconv.ParseFloat(exchangeRate.ExchangeRate, 64)
+		value, err := strconv.ParseFloat(rate, 64)
 		if err != nil {
-			logging.GetLogger(ctx).Error(fmt.Sprintf("fail to ParseFloat(%s),err:%v", exchangeRate.ExchangeRate, err))
+			logging.GetLogger(ctx).Error(fmt.Sprintf("fail to ParseFloat(%s),err:%v", rate, err))
 			return nil, err
 		}
-		if _, ok := result[exchangeRate.TargetCurrency]; ok {
-			result[exchangeRate.TargetCurrency][exchangeRate.SourceCurrency] = convutil.FloatFormat(1.0/value, 10)
+		if _, ok := result[targetCurrency]; ok {
+			result[targetCurrency][sourceCurrency] = convutil.FloatFormat(1.0/value, 10)
 		} else {
-			result[exchangeRate.TargetCurrency] = make(map[string]string)
-			result[exchangeRate.TargetCurrency][exchangeRate.SourceCurrency] = convutil.FloatFormat(1.0/value, 10)
+			result[targetCurrency] = make(map[string]string)
+			result[targetCurrency][sourceCurrency] = convutil.FloatFormat(1.0/value, 10)
 		}
 	}
 	for sourceCurrency := range result {
@@ -216,42 +226,27 @@ func (c *CalculationFactorsRepoImpl) GetShopServiceFeeForCbSip(ctx context.Conte
 	return c.integratedFeeService.GetShopFee(ctx, region, shopId, model.ShopFeeTypeServiceFee)
 }
 
-func (c *CalculationFactorsRepoImpl) GetHiddenPriceForCbSip(ctx context.Context, pItem *sip_v2_db.MstItemRecord, pShop *sip_db.MstShop, merchantRegion string, pRegion string, aRegion string, weight float64, psite bool) float64 {
+// GetHiddenPriceForCbSip returns the hidden price and the rate table it is calculated with, the config is nil if no rate table matched
+func (c *CalculationFactorsRepoImpl) GetHiddenPriceForCbSip(ctx context.Context, pItem *sip_v2_db.MstItemRecord, pShop *sip_db.MstShop, merchantRegion string, pRegion string, aRegion string, weight float64, psite bool) (float64, *model.HiddenPriceConf) {
 	conf := c.getHiddenPriceConfigForCbSip(ctx, pItem, pShop, merchantRegion, pRegion, aRegion, weight, psite)
 	if conf == nil {
-		return 0
+		return 0, nil
 	}
-	return c.calcHiddenPriceByHiddenPriceConfig(ctx, weight, conf)
+	return c.calcHiddenPriceByHiddenPriceConfig(ctx, weight, conf), conf
 }
 
-func (c *CalculationFactorsRepoImpl) calcHiddenPriceByHiddenPriceConfig(ctx context.Context, weight float64, conf *model.HiddenPriceConf) float64 {
-	if weight <= 0 {
-		return 0
-	}
-
-	startPrice := calcutil.ToRealPrice(conf.StartPrice)
-	price := calcutil.ToRealPrice(conf.Price)
-	startWeight := calcutil.DbWeightToGram(conf.StartWeight)
-	weightStep := calcutil.DbWeightToGram(conf.WeightStep)
-	adjustment := calcutil.ToRealPrice(conf.Adjustment)
-
-	var roundSize int64
-	if conf.RoundSize <= 0 {
-		roundSize = int64(math.Pow10(-int(conf.RoundSize)))
-	} else {
-		pow := math.Pow10(int(conf.RoundSize))
-		startWeight *= pow
-		roundSize = 1
-		weight *= pow
-		weightStep *= pow
+// GetHiddenPriceByHpfnKeyForCbSip returns the hidden price of the rate table hpfnKey at weight, the config is nil if
+// hpfnKey has no row for weight
+func (c *CalculationFactorsRepoImpl) GetHiddenPriceByHpfnKeyForCbSip(ctx context.Context, hpfnKey string, weight float64) (float64, *model.HiddenPriceConf) {
+	conf := c.getHpfnConfigByKey(ctx, hpfnKey, weight)
+	if conf == nil {
+		return 0, nil
 	}
+	return c.calcHiddenPriceByHiddenPriceConfig(ctx, weight, conf), conf
+}
 
-	res := startPrice + float64((calcutil.RoundUp(int(startWeight), int(roundSize), weight)-int(startWeight))/int(weightStep))*price + adjustment
-	if res > 0 {
-		return res
-	} else {
-		return 0
-	}
+func (c *CalculationFactorsRepoImpl) calcHiddenPriceByHiddenPriceConfig(ctx context.Context, weight float64, conf *model.HiddenPriceConf) float64 {
+	return calcutil.CalcHiddenPriceByHiddenPriceConf(weight, conf)
 }
 
 func (c *CalculationFactorsRepoImpl) getHiddenPriceConfigForCbSip(ctx context.Context, pItem *sip_v2_db.MstItemRecord, pShop *sip_db.MstShop, merchantRegion string, pRegion string, aRegion string, weight float64, psite bool) *model.HiddenPriceConf {
@@ -316,6 +311,10 @@ func (c *CalculationFactorsRepoImpl) getHpfnConfigByRateTableConfig(ctx context.
 		logging.GetLogger(ctx).Error(fmt.Sprintf("rate table=%v, key is empty", rateTableCfg))
 		return nil
 	}
+	return c.getHpfnConfigByKey(ctx, key, weight)
+}
+
+func (c *CalculationFactorsRepoImpl) getHpfnConfigByKey(ctx context.Context, key string, weight float64) *model.HiddenPriceConf {
 	hpfnRate, err := c.hpfnConfigRepo.GetOne(ctx, key, int(calcutil.GramToDbWeight(weight)))
 	if hpfnRate == nil {
 		logging.GetLogger(ctx).Error(fmt.Sprintf("hpfnkey=%s for weight=%f not found  err=%v", key, weight, err))
@@ -329,6 +328,10 @@ func (c *CalculationFactorsRepoImpl) getHpfnConfigByRateTableConfig(ctx context.
 		WeightStep:  hpfnRate.WeightStep,
 		Adjustment:  hpfnRate.Adjustment,
 		HpfnKey:     key,
+
+		WeightRange:    hpfnRate.WeightRange,
+		RateTableRowId: hpfnRate.Id,
+		DescInfo:       hpfnRate.DescInfo,
 	}
 	return res
 }
@@ -337,7 +340,11 @@ func (c *CalculationFactorsRepoImpl) getItemLevelHiddenPriceConfig(ctx context.C
 	if pItem == nil {
 		return nil
 	}
-	res := c.getHpfnConfigByRateTableConfig(ctx, pItem.RateTableCfg, pRegion, aRegion, weight, psite)
+	var rateTableCfg interface{} = pItem.RateTableCfg
+	if cfg, ok := c.hpfnConfigRepo.GetRateTableCfgOverride(ctx, priceSyncPriceCalculationPb.Constant_RATE_TABLE_VERSION_TARGET_ITEM_RATE_TABLE_CFG, strconv.FormatUint(pItem.ItemId, 10)); ok {
+		rateTableCfg = cfg
+	}
+	res := c.getHpfnConfigByRateTableConfig(ctx, rateTableCfg, pRegion, aRegion, weight, psite)
 	if res == nil {
 		return nil
 	}
@@ -353,7 +360,11 @@ func (c *CalculationFactorsRepoImpl) getShopLevelHiddenPriceConfig(ctx context.C
 		return nil
 	}
 
-	res := c.getHpfnConfigByRateTableConfig(ctx, pShop.RateTableCfg, pShop.Country, aRegion, weight, psite)
+	var rateTableCfg interface{} = pShop.RateTableCfg
+	if cfg, ok := c.hpfnConfigRepo.GetRateTableCfgOverride(ctx, priceSyncPriceCalculationPb.Constant_RATE_TABLE_VERSION_TARGET_SHOP_RATE_TABLE_CFG, strconv.FormatInt(pShop.ShopId, 10)); ok {
+		rateTableCfg = cfg
+	}
+	res := c.getHpfnConfigByRateTableConfig(ctx, rateTableCfg, pShop.Country, aRegion, weight, psite)
 	if res == nil {
 		return nil
 	}
@@ -365,15 +376,19 @@ func (c *CalculationFactorsRepoImpl) getShopLevelHiddenPriceConfig(ctx context.C
 }
 
 func (c *CalculationFactorsRepoImpl) getRegionLevelHiddenPriceConfig(ctx context.Context, merchantRegion, pRegion, aRegion string, weight float64, psite bool) *model.HiddenPriceConf {
+	if merchantRegion == "" {
+		merchantRegion = "CN"
+		logging.GetLogger(ctx).Info("merchentRegion is empty, use CN as default merchantRegion")
+	}
 	syscfg := c.regionRateTableConfigRepo.GetRegionRateTableConfig()
+	if cfg, ok := c.hpfnConfigRepo.GetRateTableCfgOverride(ctx, priceSyncPriceCalculationPb.Constant_RATE_TABLE_VERSION_TARGET_REGION_RATE_TABLE_CFG,
+		model.BuildRegionRateTableCfgTargetKey(merchantRegion, pRegion)); ok {
+		syscfg = map[string]map[string]*model.RateTableCfg{merchantRegion: {pRegion: cfg}}
+	}
 	if syscfg == nil {
 		logging.GetLogger(ctx).Debug("region rate table not configured")
 		return nil
 	}
-	if merchantRegion == "" {
-		merchantRegion = "CN"
-		logging.GetLogger(ctx).Info("merchentRegion is empty, use CN as default merchantRegion")
-	}
 	if merchantCfg, ok := syscfg[merchantRegion]; ok {
 		if mstCfg, ok := merchantCfg[pRegion]; ok {
 			res := c.getHpfnConfigByRateTableConfig(ctx, mstCfg, pRegion, aRegion, weight, psite)
@@ -410,13 +425,108 @@ func (c *CalculationFactorsRepoImpl) getDefaultLevelHiddenPriceConfig(ctx contex
 				Adjustment:   cfg.Adjustment,
 				HpfnKey:      "-", // default
 				HpfnCfgLevel: model.HpfnDefaultLevelCfg,
+				WeightRange:  cfg.WeightRange,
+				DescInfo:     cfg.DescInfo,
 			}
 		}
 	}
 	return nil
 }
 
-func (c *CalculationFactorsRepoImpl) GetExchangeRateForCbSip(ctx context.Context, sourceCurrency, targetCurrency string, needProcessPrecision bool) (float64, error) {
+func (c *CalculationFactorsRepoImpl) GetExchangeRateForCbSip(ctx context.Context, sourceCurrency, targetCurrency string, needProcessPrecision bool) (float64, []string, error) {
+	// the currencies and pivot currencies are compared in upper case, the same as FindExchangeRateInMapForCbSip
+	sourceCurrency, targetCurrency = strings.ToUpper(sourceCurrency), strings.ToUpper(targetCurrency)
+	if sourceCurrency == targetCurrency {
+		return 1, []string{sourceCurrency, targetCurrency}, nil
+	}
+
+	// only a missing pair is triangulated, other errors are returned as they are
+	exchangeRate, err := c.getDirectExchangeRateForCbSip(ctx, sourceCurrency, targetCurrency, needProcessPrecision)
+	if err == nil {
+		return exchangeRate, []string{sourceCurrency, targetCurrency}, nil
+	}
+	if !isExchangeRateNotFound(err) {
+		return 0, nil, err
+	}
+	for _, pivotCurrency := range config.GetExchangeRatePivotCurrencies() {
+		pivotCurrency = strings.ToUpper(pivotCurrency)
+		if pivotCurrency == sourceCurrency || pivotCurrency == targetCurrency {
+			continue
+		}
+		toPivot, pivotErr := c.getDirectExchangeRateForCbSip(ctx, sourceCurrency, pivotCurrency, needProcessPrecision)
+		if pivotErr != nil {
+			if !isExchangeRateNotFound(pivotErr) {
+				return 0, nil, pivotErr
+			}
+			continue
+		}
+		fromPivot, pivotErr := c.getDirectExchangeRateForCbSip(ctx, pivotCurrency, targetCurrency, needProcessPrecision)
+		if pivotErr != nil {
+			if !isExchangeRateNotFound(pivotErr) {
+				return 0, nil, pivotErr
+			}
+			continue
+		}
+		exchangeRate, pivotErr = triangulateExchangeRate(toPivot, fromPivot, needProcessPrecision)
+		if pivotErr != nil {
+			return 0, nil, pivotErr
+		}
+		logging.GetLogger(ctx).Info(fmt.Sprintf("triangulate exchange rate, sourceCurrency=%v, pivotCurrency=%v, targetCurrency=%v, exchangeRate=%v",
+			sourceCurrency, pivotCurrency, targetCurrency, exchangeRate))
+		return exchangeRate, []string{sourceCurrency, pivotCurrency, targetCurrency}, nil
+	}
+	return 0, nil, err
+}
+
+func isExchangeRateNotFound(err error) bool {
+	return cerr.Code(err) == uint32(priceSyncPriceCalculationPb.Constant_ERROR_NOT_FOUND)
+}
+
+// FindExchangeRateInMapForCbSip finds the rate in the map of GetAllExchangeRateMapForCbSip, it is triangulated through
+// the pivot currencies if the pair is not in the map. path is the currencies from source to target in upper case
+func FindExchangeRateInMapForCbSip(exchangeRateMap map[string]map[string]string, sourceCurrency, targetCurrency string) (string, []string, bool) {
+	sourceCurrency, targetCurrency = strings.ToUpper(sourceCurrency), strings.ToUpper(targetCurrency)
+	if exchangeRate, ok := exchangeRateMap[sourceCurrency][targetCurrency]; ok {
+		return exchangeRate, []string{sourceCurrency, targetCurrency}, true
+	}
+	for _, pivotCurrency := range config.GetExchangeRatePivotCurrencies() {
+		pivotCurrency = strings.ToUpper(pivotCurrency)
+		if pivotCurrency == sourceCurrency || pivotCurrency == targetCurrency {
+			continue
+		}
+		toPivotStr, ok := exchangeRateMap[sourceCurrency][pivotCurrency]
+		if !ok {
+			continue
+		}
+		fromPivotStr, ok := exchangeRateMap[pivotCurrency][targetCurrency]
+		if !ok {
+			continue
+		}
+		toPivot, err := strconv.ParseFloat(toPivotStr, 64)
+		if err != nil {
+			continue
+		}
+		fromPivot, err := strconv.ParseFloat(fromPivotStr, 64)
+		if err != nil {
+			continue
+		}
+		// rounded to 10 decimals the same as the reversed rates in map
+		return convutil.FloatFormat(toPivot*fromPivot, 10), []string{sourceCurrency, pivotCurrency, targetCurrency}, true
+	}
+	return "", nil, false
+}
+
+// triangulateExchangeRate the precision is processed the same as a reversed rate
+func triangulateExchangeRate(toPivot, fromPivot float64, needProcessPrecision bool) (float64, error) {
+	exchangeRate := toPivot * fromPivot
+	if !needProcessPrecision {
+		return exchangeRate, nil
+	}
+	return strconv.ParseFloat(convutil.FloatFormat(exchangeRate, 10), 64)
+}
+
+// getDirectExchangeRateForCbSip rate of the row of currency pair, reversed if the row is of the other direction
+func (c *CalculationFactorsRepoImpl) getDirectExchangeRateForCbSip(ctx context.Context, sourceCurrency, targetCurrency string, needProcessPrecision bool) (float64, error) {
 	if strings.EqualFold(sourceCurrency, targetCurrency) {
 		return 1, nil
 	}
@@ -434,9 +544,14 @@ func (c *CalculationFactorsRepoImpl) GetExchangeRateForCbSip(ctx context.Context
 			return 0, err
 		}
 
+		rateKey := exchange_rate_history.CbSipRateKey(exchangeRateConfigDB.SourceCurrency, exchangeRateConfigDB.TargetCurrency)
+		servedRates := c.exchangeRateHistoryRepo.GuardJump(ctx, priceSyncPriceCalculationPb.Constant_CB_SIP_EXCHANGE_RATE, map[string]string{
+			rateKey: exchangeRateConfigDB.ExchangeRate,
+		})
+
 		exchangeRateConfig.SourceCurrency = exchangeRateConfigDB.SourceCurrency
 		exchangeRateConfig.TargetCurrency = exchangeRateConfigDB.TargetCurrency
-		exchangeRateConfig.ExchangeRate = exchangeRateConfigDB.ExchangeRate
+		exchangeRateConfig.ExchangeRate = servedRates[rateKey]
 		exchangeRateConfigBytes, _ := json.Marshal(exchangeRateConfig)
 		_ = c.cache.Set(ctx, cacheKey, string(exchangeRateConfigBytes), 60)
 	} else {
@@ -452,7 +567,7 @@ func (c *CalculationFactorsRepoImpl) GetExchangeRateForCbSip(ctx context.Context
 		return 0, err
 	}
 
-	if exchangeRateConfig.SourceCurrency == sourceCurrency && exchangeRateConfig.TargetCurrency == targetCurrency {
+	if strings.EqualFold(exchangeRateConfig.SourceCurrency, sourceCurrency) && strings.EqualFold(exchangeRateConfig.TargetCurrency, targetCurrency) {
 		return exchangeRate, nil
 	} else {
 		// TODO: due to legacy code issue, sip item price & a price calculation has different exchange rate process logic
@@ -0,0 +1,169 @@
+package factors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/cache"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/orm"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+)
+
+// fakeCommonCache always misses
+type fakeCommonCache struct {
+	cache.CommonCache
+}
+
+func (f *fakeCommonCache) GetString(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeCommonCache) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	return nil
+}
+
+type fakeSipRepo struct {
+	sip_db.SipRepo
+	exchangeRates map[string]*sip_db.ExchangeRate
+	// errs by currency pair
+	errs map[string]error
+}
+
+func (f *fakeSipRepo) DbSession() orm.DbSession {
+	return nil
+}
+
+func (f *fakeSipRepo) GetExchangeRateByCurrency(ctx context.Context, session orm.DbSession, currencyPair string) (*sip_db.ExchangeRate, error) {
+	if err, ok := f.errs[currencyPair]; ok {
+		return nil, err
+	}
+	if exchangeRate, ok := f.exchangeRates[currencyPair]; ok {
+		return exchangeRate, nil
+	}
+	return nil, cerr.New("exchange rate not found", uint32(pb.Constant_ERROR_NOT_FOUND))
+}
+
+type fakeExchangeRateHistoryRepo struct {
+	exchange_rate_history.ExchangeRateHistoryRepo
+}
+
+func (f *fakeExchangeRateHistoryRepo) GuardJump(ctx context.Context, source pb.Constant_ExchangeRateSource, rates map[string]string) map[string]string {
+	return rates
+}
+
+func TestFindExchangeRateInMapForCbSip(t *testing.T) {
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: &config.SIPMigrationConfig{
+			ExchangeRatePivotCurrencies: []string{"USD", "CNY"},
+		},
+	})
+	exchangeRateMap := map[string]map[string]string{
+		"SGD": {"SGD": "1", "USD": "0.74", "MYR": "3.5"},
+		"USD": {"USD": "1", "SGD": "1.3513513514", "VND": "25000"},
+		"CNY": {"CNY": "1", "BRL": "0.7"},
+		"MYR": {"MYR": "1", "SGD": "0.2857142857", "CNY": "1.5"},
+	}
+
+	rate, path, ok := FindExchangeRateInMapForCbSip(exchangeRateMap, "SGD", "MYR")
+	assert.True(t, ok)
+	assert.Equal(t, "3.5", rate)
+	assert.Equal(t, []string{"SGD", "MYR"}, path)
+
+	rate, path, ok = FindExchangeRateInMapForCbSip(exchangeRateMap, "SGD", "VND")
+	assert.True(t, ok)
+	assert.Equal(t, "18500", rate)
+	assert.Equal(t, []string{"SGD", "USD", "VND"}, path)
+
+	// USD has no rate to BRL, CNY is tried next
+	rate, path, ok = FindExchangeRateInMapForCbSip(exchangeRateMap, "MYR", "BRL")
+	assert.True(t, ok)
+	assert.Equal(t, "1.05", rate)
+	assert.Equal(t, []string{"MYR", "CNY", "BRL"}, path)
+
+	_, _, ok = FindExchangeRateInMapForCbSip(exchangeRateMap, "VND", "BRL")
+	assert.False(t, ok)
+}
+
+func TestGetExchangeRateForCbSip(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: &config.SIPMigrationConfig{
+			ExchangeRatePivotCurrencies: []string{"USD"},
+		},
+	})
+	newExchangeRate := func(source, target, rate string) *sip_db.ExchangeRate {
+		return &sip_db.ExchangeRate{CurrencyPair: model.BuildCurrencyPair(source, target), SourceCurrency: source, TargetCurrency: target, ExchangeRate: rate}
+	}
+	sipRepo := &fakeSipRepo{exchangeRates: map[string]*sip_db.ExchangeRate{
+		model.BuildCurrencyPair("SGD", "USD"): newExchangeRate("SGD", "USD", "0.75"),
+		model.BuildCurrencyPair("USD", "VND"): newExchangeRate("USD", "VND", "25000"),
+		model.BuildCurrencyPair("USD", "MYR"): newExchangeRate("USD", "MYR", "4.5"),
+	}}
+	c := &CalculationFactorsRepoImpl{cache: &fakeCommonCache{}, sipRepo: sipRepo, exchangeRateHistoryRepo: &fakeExchangeRateHistoryRepo{}}
+
+	// a missing pair is triangulated
+	rate, path, err := c.GetExchangeRateForCbSip(ctx, "SGD", "VND", false)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(18750), rate)
+	assert.Equal(t, []string{"SGD", "USD", "VND"}, path)
+
+	// other errors of the pair or a leg are returned as they are
+	sipRepo.errs = map[string]error{model.BuildCurrencyPair("SGD", "VND"): cerr.New("db down", uint32(pb.Constant_ERROR_DATABASE))}
+	_, _, err = c.GetExchangeRateForCbSip(ctx, "SGD", "VND", false)
+	assert.Equal(t, uint32(pb.Constant_ERROR_DATABASE), cerr.Code(err))
+	sipRepo.errs = map[string]error{model.BuildCurrencyPair("USD", "MYR"): cerr.New("db down", uint32(pb.Constant_ERROR_DATABASE))}
+	_, _, err = c.GetExchangeRateForCbSip(ctx, "SGD", "MYR", false)
+	assert.Equal(t, uint32(pb.Constant_ERROR_DATABASE), cerr.Code(err))
+
+	sipRepo.errs = nil
+	_, _, err = c.GetExchangeRateForCbSip(ctx, "SGD", "BRL", false)
+	assert.Equal(t, uint32(pb.Constant_ERROR_NOT_FOUND), cerr.Code(err))
+}
+
+func TestExchangeRateForCbSipMixedCase(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: &config.SIPMigrationConfig{
+			ExchangeRatePivotCurrencies: []string{"usd"},
+		},
+	})
+	exchangeRateMap := map[string]map[string]string{
+		"SGD": {"SGD": "1", "USD": "0.75"},
+		"USD": {"USD": "1", "SGD": "1.3333333333", "VND": "25000"},
+	}
+	sipRepo := &fakeSipRepo{exchangeRates: map[string]*sip_db.ExchangeRate{
+		model.BuildCurrencyPair("SGD", "USD"): {SourceCurrency: "SGD", TargetCurrency: "USD", ExchangeRate: "0.75"},
+		model.BuildCurrencyPair("USD", "VND"): {SourceCurrency: "USD", TargetCurrency: "VND", ExchangeRate: "25000"},
+	}}
+	c := &CalculationFactorsRepoImpl{cache: &fakeCommonCache{}, sipRepo: sipRepo, exchangeRateHistoryRepo: &fakeExchangeRateHistoryRepo{}}
+
+	// both paths triangulate through the lower case pivot and return the path in upper case
+	rateStr, path, ok := FindExchangeRateInMapForCbSip(exchangeRateMap, "sgd", "Vnd")
+	assert.True(t, ok)
+	assert.Equal(t, "18750", rateStr)
+	assert.Equal(t, []string{"SGD", "USD", "VND"}, path)
+
+	rate, path, err := c.GetExchangeRateForCbSip(ctx, "sgd", "Vnd", false)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(18750), rate)
+	assert.Equal(t, []string{"SGD", "USD", "VND"}, path)
+
+	// a pivot currency is not triangulated through itself
+	rateStr, path, ok = FindExchangeRateInMapForCbSip(exchangeRateMap, "usd", "sgd")
+	assert.True(t, ok)
+	assert.Equal(t, "1.3333333333", rateStr)
+	assert.Equal(t, []string{"USD", "SGD"}, path)
+
+	rate, path, err = c.GetExchangeRateForCbSip(ctx, "usd", "sgd", false)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0/0.75, rate, 1e-9)
+	assert.Equal(t, []string{"USD", "SGD"}, path)
+}
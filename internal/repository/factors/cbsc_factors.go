@@ -23,7 +23,9 @@ import (
 	internalMerchantConstraintsPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/internal_merchant_constraints.pb"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/account_service"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/hpfn_config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/local_sip_fee_config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/region_rate_table_config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/service"
@@ -45,6 +47,8 @@ type CalculationFactorsRepoImpl struct {
 	sipRepo                   sip_db.SipRepo
 	hpfnConfigRepo            hpfn_config.HpfnConfigRepo
 	regionRateTableConfigRepo region_rate_table_config.RegionRateTableConfigRepo
+	localSipFeeConfigRepo     local_sip_fee_config.LocalSipFeeConfigRepo
+	exchangeRateHistoryRepo   exchange_rate_history.ExchangeRateHistoryRepo
 
 	shopCoreService             service.ShopCoreService
 	exchangeRateService         service.ExchangeRateService
@@ -65,6 +69,8 @@ type CalculationFactorsRepoOpts struct {
 	SipRepo                     sip_db.SipRepo
 	HpfnConfigRepo              hpfn_config.HpfnConfigRepo
 	RegionRateTableConfigRepo   region_rate_table_config.RegionRateTableConfigRepo
+	LocalSipFeeConfigRepo       local_sip_fee_config.LocalSipFeeConfigRepo
+	ExchangeRateHistoryRepo     exchange_rate_history.ExchangeRateHistoryRepo
 	ShopCoreService             service.ShopCoreService
 	ExchangeRateService         service.ExchangeRateService
 	IntegratedFeeService        service.OrderAccountIntegratedFeeService
@@ -85,6 +91,8 @@ func NewCalculationFactorsRepoImpl(deps *CalculationFactorsRepoOpts) *Calculatio
 		sipRepo:                     deps.SipRepo,
 		hpfnConfigRepo:              deps.HpfnConfigRepo,
 		regionRateTableConfigRepo:   deps.RegionRateTableConfigRepo,
+		localSipFeeConfigRepo:       deps.LocalSipFeeConfigRepo,
+		exchangeRateHistoryRepo:     deps.ExchangeRateHistoryRepo,
 		shopCoreService:             deps.ShopCoreService,
 		exchangeRateService:         deps.ExchangeRateService,
 		integratedFeeService:        deps.IntegratedFeeService,
@@ -560,7 +568,7 @@ func (c *CalculationFactorsRepoImpl) GetHidePriceForCbsc(ctx context.Context, qu
 		}
 	}
 
-	slsHiddenPriceResults, err := c.getHidePriceForCbscFromSls(ctx, slsHiddenPriceQueries)
+	slsHiddenPriceResults, err := c.getHidePriceForCbscFromSls(ctx, slsHiddenPriceQueries, regionChannelMap)
 	if err != nil {
 		return nil, err
 	}
@@ -584,7 +592,9 @@ func (c *CalculationFactorsRepoImpl) GetHidePriceForCbsc(ctx context.Context, qu
 	return result, nil
 }
 
-func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Context, queries []model.GetHidePriceForCbscRequest) ([]model.GetHidePriceForCbscResult, error) {
+// getHidePriceForCbscFromSls channel names of the breakdown are resolved from regionChannelMap
+func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Context, queries []model.GetHidePriceForCbscRequest,
+	regionChannelMap map[string]map[uint64]*model.ChannelInfo) ([]model.GetHidePriceForCbscResult, error) {
 	if len(queries) == 0 {
 		return nil, nil
 	}
@@ -613,6 +623,7 @@ func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Cont
 	}
 
 	hiddenFeeQueriesGroupByRegion, rawIndexes := model.GroupHiddenFeeQueriesByRegion(hiddenFeeQueries)
+	defaultChannelLoader := &shopDefaultChannelLoader{repo: c, loaded: make(map[uint64]map[uint64]bool)}
 
 	for region, queryList := range hiddenFeeQueriesGroupByRegion {
 		regionDomainUrl := config.GetShopeeRegionDomainUrl(region)
@@ -626,9 +637,9 @@ func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Cont
 			List: queryList,
 		}
 
-		resp, err := http.BatchCalcHiddenFee(c.httpCli, ctxWithCID, regionDomainUrl, &req)
+		resp, err := http.BatchCalcHiddenFeeWithCache(c.httpCli, ctxWithCID, c.cache, region, regionDomainUrl, &req)
 		if err != nil {
-			for i := range resp.Data {
+			for i := range queryList {
 				rawIndex := rawIndexes[region][i]
 				finalResults[rawIndex] = model.GetHidePriceForCbscResult{
 					QueryId: queries[rawIndex].QueryId,
@@ -644,7 +655,7 @@ func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Cont
 				resp.RetCode, resp.Message)
 			logging.GetLogger(ctx).Error(errMsg)
 
-			for i := range resp.Data {
+			for i := range queryList {
 				rawIndex := rawIndexes[region][i]
 				finalResults[rawIndex] = model.GetHidePriceForCbscResult{
 					QueryId: queries[rawIndex].QueryId,
@@ -656,8 +667,9 @@ func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Cont
 
 		for i, channelResults := range resp.Data {
 			rawIndex := rawIndexes[region][i]
-			ignoreChannelErr := queries[rawIndex].IgnoreChannelErr
-			var maxHiddenFee float64
+			query := queries[rawIndex]
+			ignoreChannelErr := query.IgnoreChannelErr
+			channelHiddenFees := make([]*model.ChannelHiddenFee, 0, len(channelResults))
 			var iErr error
 
 			for _, result := range channelResults {
@@ -668,6 +680,11 @@ func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Cont
 					logging.GetLogger(ctx).Error(errMsg)
 
 					if ignoreChannelErr {
+						channelHiddenFees = append(channelHiddenFees, &model.ChannelHiddenFee{
+							ChannelId:   uint64(result.ProductID),
+							ChannelName: channelName(regionChannelMap, query.Region, result.ProductID),
+							ErrCode:     result.RetCode,
+						})
 						continue
 					} else {
 						iErr = cerr.New(errMsg, uint32(pb.Constant_CALCULATE_HIDDEN_FEE_ERROR))
@@ -675,21 +692,36 @@ func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Cont
 					}
 				}
 
-				if result.HiddenFee > maxHiddenFee {
-					maxHiddenFee = result.HiddenFee
-				}
+				channelHiddenFees = append(channelHiddenFees, &model.ChannelHiddenFee{
+					ChannelId:   uint64(result.ProductID),
+					ChannelName: channelName(regionChannelMap, query.Region, result.ProductID),
+					HiddenFee:   result.HiddenFee,
+				})
 			}
 
 			if iErr != nil {
 				finalResults[rawIndex] = model.GetHidePriceForCbscResult{
-					QueryId: queries[rawIndex].QueryId,
+					QueryId: query.QueryId,
 					Err:     iErr,
 				}
-			} else {
-				finalResults[rawIndex] = model.GetHidePriceForCbscResult{
-					QueryId:   queries[rawIndex].QueryId,
-					HidePrice: maxHiddenFee,
-				}
+				continue
+			}
+
+			setting := config.GetCbscHiddenFeeAggregationConfig(query.MerchantId, query.Region)
+			var defaultChannelIds map[uint64]bool
+			if hiddenFeeAggregationStrategy(setting) == pb.Constant_HIDDEN_FEE_AGGREGATION_SHOP_DEFAULT_CHANNEL {
+				defaultChannelIds = defaultChannelLoader.get(ctx, query.ShopId, query.Region)
+			}
+			hiddenFee, strategy := aggregateChannelHiddenFees(channelHiddenFees, setting, defaultChannelIds)
+			if setting != nil && strategy != hiddenFeeAggregationStrategy(setting) {
+				logging.GetLogger(ctx).Warn(fmt.Sprintf("hidden fee aggregation strategy=%s can not be applied, fall back to max, "+
+					"merchantId=%v, shopId=%v, itemId=%v, region=%s", setting.Strategy, query.MerchantId, query.ShopId, query.ItemId, query.Region))
+			}
+			finalResults[rawIndex] = model.GetHidePriceForCbscResult{
+				QueryId:           query.QueryId,
+				HidePrice:         hiddenFee,
+				ChannelHiddenFees: channelHiddenFees,
+				HiddenFeeStrategy: strategy,
 			}
 		}
 	}
@@ -697,20 +729,37 @@ func (c *CalculationFactorsRepoImpl) getHidePriceForCbscFromSls(ctx context.Cont
 	return finalResults, nil
 }
 
-func (c *CalculationFactorsRepoImpl) GetOrderMartExchangeRate(ctx context.Context, srcCurrency string, dstCurrency string) (float64, error) {
+// channelName empty if the channel is not found in the channels of region
+func channelName(regionChannelMap map[string]map[uint64]*model.ChannelInfo, region string, channelId int64) string {
+	if channelInfo, ok := regionChannelMap[region][uint64(channelId)]; ok {
+		return channelInfo.Name
+	}
+	return ""
+}
+
+func (c *CalculationFactorsRepoImpl) GetOrderMartExchangeRate(ctx context.Context, srcCurrency string, dstCurrency string) (float64, int64, error) {
 	if srcCurrency == dstCurrency {
-		return 1, nil
+		return 1, 0, nil
 	}
 
 	srcExchangeRateInfo, err := c.cache.GetOrderMartExchangeRate(ctx, constant.GetOrderMartExchangeRateCacheKey(srcCurrency))
 	if err != nil {
-		return 0, cerr.New(fmt.Sprintf("failed to get exchange rate for srcCurrency=%s", srcCurrency), uint32(pb.Constant_ERROR_CACHE))
+		return 0, 0, cerr.New(fmt.Sprintf("failed to get exchange rate for srcCurrency=%s", srcCurrency), uint32(pb.Constant_ERROR_CACHE))
 	}
 
 	dstExchangeRateInfo, err := c.cache.GetOrderMartExchangeRate(ctx, constant.GetOrderMartExchangeRateCacheKey(dstCurrency))
 	if err != nil {
-		return 0, cerr.New(fmt.Sprintf("failed to get exchange rate for dstCurrency=%s", dstCurrency), uint32(pb.Constant_ERROR_CACHE))
+		return 0, 0, cerr.New(fmt.Sprintf("failed to get exchange rate for dstCurrency=%s", dstCurrency), uint32(pb.Constant_ERROR_CACHE))
 	}
 
-	return dstExchangeRateInfo.ExchangeRate / srcExchangeRateInfo.ExchangeRate, nil
+	// the rate is as old as the older one of the two currencies
+	now := time.Now()
+	srcRateAge, dstRateAge := srcExchangeRateInfo.RateAge(now), dstExchangeRateInfo.RateAge(now)
+	rateAge := srcRateAge
+	if srcRateAge == model.UnknownRateAge || dstRateAge == model.UnknownRateAge {
+		rateAge = model.UnknownRateAge
+	} else if dstRateAge > srcRateAge {
+		rateAge = dstRateAge
+	}
+	return dstExchangeRateInfo.ExchangeRate / srcExchangeRateInfo.ExchangeRate, rateAge, nil
 }
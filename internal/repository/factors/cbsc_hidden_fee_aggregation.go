@@ -0,0 +1,133 @@
+package factors
+
+import (
+	"context"
+	"fmt"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// hiddenFeeAggregationStrategy strategy of the setting, max if not configured
+func hiddenFeeAggregationStrategy(setting *config.CbscHiddenFeeAggregationConfig) pb.Constant_HiddenFeeAggregationStrategy {
+	if setting == nil {
+		return pb.Constant_HIDDEN_FEE_AGGREGATION_MAX
+	}
+	switch setting.Strategy {
+	case config.HiddenFeeAggregationMin:
+		return pb.Constant_HIDDEN_FEE_AGGREGATION_MIN
+	case config.HiddenFeeAggregationShopDefaultChannel:
+		return pb.Constant_HIDDEN_FEE_AGGREGATION_SHOP_DEFAULT_CHANNEL
+	case config.HiddenFeeAggregationWeightedAverage:
+		return pb.Constant_HIDDEN_FEE_AGGREGATION_WEIGHTED_AVERAGE
+	case config.HiddenFeeAggregationPinnedChannel:
+		return pb.Constant_HIDDEN_FEE_AGGREGATION_PINNED_CHANNEL
+	default:
+		return pb.Constant_HIDDEN_FEE_AGGREGATION_MAX
+	}
+}
+
+// aggregateChannelHiddenFees aggregates hidden fees of the channels calculated successfully into one hidden fee and
+// marks the chosen channels. it falls back to max if the strategy can not be applied, the strategy actually applied
+// is returned. defaultChannelIds is only used by shop default channel strategy
+func aggregateChannelHiddenFees(fees []*model.ChannelHiddenFee, setting *config.CbscHiddenFeeAggregationConfig,
+	defaultChannelIds map[uint64]bool) (float64, pb.Constant_HiddenFeeAggregationStrategy) {
+	strategy := hiddenFeeAggregationStrategy(setting)
+
+	var chosen []*model.ChannelHiddenFee
+	hiddenFee := float64(0)
+	switch strategy {
+	case pb.Constant_HIDDEN_FEE_AGGREGATION_MIN:
+		for _, fee := range fees {
+			if fee.ErrCode != 0 {
+				continue
+			}
+			if len(chosen) == 0 || fee.HiddenFee < chosen[0].HiddenFee {
+				chosen = []*model.ChannelHiddenFee{fee}
+			}
+		}
+	case pb.Constant_HIDDEN_FEE_AGGREGATION_SHOP_DEFAULT_CHANNEL:
+		// the highest one if the shop has several default channels enabled
+		for _, fee := range fees {
+			if fee.ErrCode != 0 || !defaultChannelIds[fee.ChannelId] {
+				continue
+			}
+			if len(chosen) == 0 || fee.HiddenFee > chosen[0].HiddenFee {
+				chosen = []*model.ChannelHiddenFee{fee}
+			}
+		}
+	case pb.Constant_HIDDEN_FEE_AGGREGATION_WEIGHTED_AVERAGE:
+		var totalWeight, total float64
+		for _, fee := range fees {
+			weight := setting.ChannelWeights[uint32(fee.ChannelId)]
+			if fee.ErrCode != 0 || weight <= 0 {
+				continue
+			}
+			totalWeight += weight
+			total += weight * fee.HiddenFee
+			chosen = append(chosen, fee)
+		}
+		if totalWeight > 0 {
+			hiddenFee = total / totalWeight
+		}
+	case pb.Constant_HIDDEN_FEE_AGGREGATION_PINNED_CHANNEL:
+		for _, fee := range fees {
+			if fee.ErrCode == 0 && fee.ChannelId == uint64(setting.PinnedChannelId) {
+				chosen = []*model.ChannelHiddenFee{fee}
+				break
+			}
+		}
+	}
+	if strategy != pb.Constant_HIDDEN_FEE_AGGREGATION_WEIGHTED_AVERAGE && len(chosen) > 0 {
+		hiddenFee = chosen[0].HiddenFee
+	}
+
+	if len(chosen) == 0 {
+		strategy = pb.Constant_HIDDEN_FEE_AGGREGATION_MAX
+		for _, fee := range fees {
+			if fee.ErrCode != 0 {
+				continue
+			}
+			if len(chosen) == 0 || fee.HiddenFee > chosen[0].HiddenFee {
+				chosen = []*model.ChannelHiddenFee{fee}
+			}
+		}
+		if len(chosen) > 0 {
+			hiddenFee = chosen[0].HiddenFee
+		}
+	}
+
+	for _, fee := range chosen {
+		fee.Chosen = true
+	}
+	return hiddenFee, strategy
+}
+
+// shopDefaultChannelLoader loads default channels of shops at most once per shop
+type shopDefaultChannelLoader struct {
+	repo   *CalculationFactorsRepoImpl
+	loaded map[uint64]map[uint64]bool
+}
+
+func (l *shopDefaultChannelLoader) get(ctx context.Context, shopId uint64, region string) map[uint64]bool {
+	if channelIds, ok := l.loaded[shopId]; ok {
+		return channelIds
+	}
+
+	channelIds := make(map[uint64]bool)
+	infos, err := l.repo.logisticService.GetShopDefaultChannel(ctx, shopId, region)
+	if err != nil {
+		// falls back to max hidden fee
+		logging.GetLogger(ctx).Warn(fmt.Sprintf("failed to get shop default channel for hidden fee aggregation, "+
+			"shopId=%v, region=%s, err=%v", shopId, region, err))
+	}
+	for _, info := range infos {
+		if info.GetChannelid() != 0 {
+			channelIds[uint64(info.GetChannelid())] = true
+		}
+	}
+	l.loaded[shopId] = channelIds
+	return channelIds
+}
@@ -0,0 +1,65 @@
+package factors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+func TestAggregateChannelHiddenFees(t *testing.T) {
+	newFees := func() []*model.ChannelHiddenFee {
+		return []*model.ChannelHiddenFee{
+			{ChannelId: 1, HiddenFee: 3},
+			{ChannelId: 2, HiddenFee: 1},
+			{ChannelId: 3, HiddenFee: 5, ErrCode: 100},
+			{ChannelId: 4, HiddenFee: 2},
+		}
+	}
+	chosenIds := func(fees []*model.ChannelHiddenFee) []uint64 {
+		ids := make([]uint64, 0)
+		for _, fee := range fees {
+			if fee.Chosen {
+				ids = append(ids, fee.ChannelId)
+			}
+		}
+		return ids
+	}
+
+	cases := []struct {
+		name              string
+		setting           *config.CbscHiddenFeeAggregationConfig
+		defaultChannelIds map[uint64]bool
+		hiddenFee         float64
+		strategy          pb.Constant_HiddenFeeAggregationStrategy
+		chosen            []uint64
+	}{
+		{"not configured", nil, nil, 3, pb.Constant_HIDDEN_FEE_AGGREGATION_MAX, []uint64{1}},
+		{"min", &config.CbscHiddenFeeAggregationConfig{Strategy: config.HiddenFeeAggregationMin}, nil,
+			1, pb.Constant_HIDDEN_FEE_AGGREGATION_MIN, []uint64{2}},
+		{"shop default channel", &config.CbscHiddenFeeAggregationConfig{Strategy: config.HiddenFeeAggregationShopDefaultChannel},
+			map[uint64]bool{3: true, 4: true}, 2, pb.Constant_HIDDEN_FEE_AGGREGATION_SHOP_DEFAULT_CHANNEL, []uint64{4}},
+		{"shop default channel not enabled", &config.CbscHiddenFeeAggregationConfig{Strategy: config.HiddenFeeAggregationShopDefaultChannel},
+			map[uint64]bool{5: true}, 3, pb.Constant_HIDDEN_FEE_AGGREGATION_MAX, []uint64{1}},
+		{"weighted average", &config.CbscHiddenFeeAggregationConfig{Strategy: config.HiddenFeeAggregationWeightedAverage,
+			ChannelWeights: map[uint32]float64{1: 1, 2: 3, 3: 10}}, nil, 1.5, pb.Constant_HIDDEN_FEE_AGGREGATION_WEIGHTED_AVERAGE, []uint64{1, 2}},
+		{"pinned channel", &config.CbscHiddenFeeAggregationConfig{Strategy: config.HiddenFeeAggregationPinnedChannel, PinnedChannelId: 4}, nil,
+			2, pb.Constant_HIDDEN_FEE_AGGREGATION_PINNED_CHANNEL, []uint64{4}},
+		{"pinned channel failed", &config.CbscHiddenFeeAggregationConfig{Strategy: config.HiddenFeeAggregationPinnedChannel, PinnedChannelId: 3}, nil,
+			3, pb.Constant_HIDDEN_FEE_AGGREGATION_MAX, []uint64{1}},
+	}
+	for _, c := range cases {
+		fees := newFees()
+		hiddenFee, strategy := aggregateChannelHiddenFees(fees, c.setting, c.defaultChannelIds)
+		assert.Equal(t, c.hiddenFee, hiddenFee, c.name)
+		assert.Equal(t, c.strategy, strategy, c.name)
+		assert.Equal(t, c.chosen, chosenIds(fees), c.name)
+	}
+
+	hiddenFee, strategy := aggregateChannelHiddenFees(nil, nil, nil)
+	assert.Equal(t, float64(0), hiddenFee)
+	assert.Equal(t, pb.Constant_HIDDEN_FEE_AGGREGATION_MAX, strategy)
+}
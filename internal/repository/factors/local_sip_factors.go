@@ -13,6 +13,7 @@ import (
 	"git.garena.com/shopee/core-server/core-logic/cerr"
 	"git.garena.com/shopee/core-server/core-logic/cutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/http"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	internal "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/internal_lps.pb"
 	internal_sip "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/internal_sip.pb"
@@ -46,6 +47,16 @@ func (c *CalculationFactorsRepoImpl) GetPItemDataForLocalSip(ctx context.Context
 	return res[pItemId], nil
 }
 
+// GetPItemDimensionForLocalSip package dimension of P item, nil if it is unknown
+func (c *CalculationFactorsRepoImpl) GetPItemDimensionForLocalSip(ctx context.Context, pItemId uint64, pRegion string) *model.ItemDimension {
+	pItemProductInfoMap := c.itemService.GetProductInfoMapForSameRegion(ctx, []uint64{pItemId}, pRegion)
+	dimension := service.GetItemDimension(pItemProductInfoMap[pItemId].GetLogistics())
+	if dimension == nil {
+		logging.GetLogger(ctx).Info(fmt.Sprintf("package dimension of pItemId=%v is unknown, volumetric weight is not charged", pItemId))
+	}
+	return dimension
+}
+
 // exchange rate, buffer (region margin)
 func (c *CalculationFactorsRepoImpl) GetLocalSipConfigByRegionBatch(ctx context.Context, pRegion string, aRegions []string) (map[string]*model.CommonPriceConfig, error) {
 	res := make(map[string]*model.CommonPriceConfig)
@@ -102,7 +113,7 @@ func (c *CalculationFactorsRepoImpl) GetShippingFeeForLocalSip(ctx context.Conte
 	var slsResults []model.LocalSipShippingFeeResult
 	if len(slsQueries) > 0 {
 		if calcForCreate {
-			slsResults, err = c.getShippingFeeForLocalSipCreateFromSls(ctx, slsQueries)
+			slsResults, err = c.getShippingFeeForLocalSipCreateFromSls(http.WithSlsHiddenFeeCacheBypass(ctx), slsQueries)
 		} else {
 			slsResults, err = c.getShippingFeeForLocalSipFromSls(ctx, slsQueries)
 		}
@@ -123,20 +134,9 @@ func (c *CalculationFactorsRepoImpl) GetShippingFeeForLocalSip(ctx context.Conte
 
 func (c *CalculationFactorsRepoImpl) getShippingFeeForLocalSipFromDb(ctx context.Context, pRegion string, dbQueries []model.LocalSipShippingFeeQuery) ([]model.LocalSipShippingFeeResult, error) {
 	results := make([]model.LocalSipShippingFeeResult, len(dbQueries))
-	session := c.sipRepo.DbSession()
 	for i, query := range dbQueries {
-		record, err := c.sipRepo.GetLocalShippingFeeConfigRecordByWeight(ctx, session, pRegion, query.ARegion, query.Weight)
-
-		if err != nil {
-			dbErr := cerr.New(fmt.Sprintf("failed to get shipping fee, err=%v", err), uint32(pb.Constant_ERROR_DATABASE))
-			results[i] = model.LocalSipShippingFeeResult{
-				QueryId:     query.QueryId,
-				Err:         dbErr,
-				ShippingFee: 0,
-			}
-			continue
-		}
-		if record == nil {
+		shippingFee, ok := c.localSipFeeConfigRepo.GetShippingFee(ctx, pRegion, query.ARegion, query.Weight)
+		if !ok {
 			dbErr := cerr.New(fmt.Sprintf("cannot find shipping fee record for query=%+v", query), uint32(pb.Constant_ERROR_NOT_FOUND))
 			results[i] = model.LocalSipShippingFeeResult{
 				QueryId:     query.QueryId,
@@ -146,9 +146,13 @@ func (c *CalculationFactorsRepoImpl) getShippingFeeForLocalSipFromDb(ctx context
 			continue
 		}
 
+		// fee table is not capped if limit of region pair is not configured
+		if limitCfg, err := config.GetLocalSipSettingLimitConfigByRegions(pRegion, query.ARegion); err == nil {
+			shippingFee = capLocalSipFee(shippingFee, limitCfg.ShippingFeeMin, limitCfg.ShippingFeeMax)
+		}
 		results[i] = model.LocalSipShippingFeeResult{
 			QueryId:     query.QueryId,
-			ShippingFee: calcutil.ToRealPrice(record.ShippingFeePrice),
+			ShippingFee: shippingFee,
 		}
 	}
 	return results, nil
@@ -311,7 +315,8 @@ func (c *CalculationFactorsRepoImpl) getAItemLeafCatIdMap(ctx context.Context, a
 // - hidden price calculation by SLS https://confluence.shopee.io/display/SCPM/%5BSPML-15978%5DLocal+SIP+Pricing+formula+update
 // - multiple warehouse https://confluence.shopee.io/display/SCPM/%5BSPML-17283%5D%5Blocal+SC%5D%5BMW%5DMulti-Warehouse+Phase+2
 // - multiple warehouse & use location id for SLS api https://confluence.shopee.io/pages/viewpage.action?pageId=1795010579
-func (c *CalculationFactorsRepoImpl) GetInitialHiddenPriceForLocalSip(ctx context.Context, pItemId uint64, pShopId uint64, pRegion string, queries []model.LocalSipHiddenPriceQuery) ([]model.LocalSipHiddenPriceResult, error) {
+// The SLS hidden fee cache is bypassed if calcForCreate, the same as GetShippingFeeForLocalSip.
+func (c *CalculationFactorsRepoImpl) GetInitialHiddenPriceForLocalSip(ctx context.Context, pItemId uint64, pShopId uint64, pRegion string, queries []model.LocalSipHiddenPriceQuery, calcForCreate bool) ([]model.LocalSipHiddenPriceResult, error) {
 	slsQueries, dbQueries := model.GroupLocalSipHiddenPriceQueryBySlsToggleStatus(queries)
 
 	var err error
@@ -325,7 +330,11 @@ func (c *CalculationFactorsRepoImpl) GetInitialHiddenPriceForLocalSip(ctx contex
 
 	var slsHiddenPrices []model.LocalSipHiddenPriceResult
 	if len(slsQueries) > 0 {
-		slsHiddenPrices, err = c.getInitialHiddenPriceForLocalSipFromSls(ctx, pItemId, pShopId, pRegion, slsQueries)
+		slsCtx := ctx
+		if calcForCreate {
+			slsCtx = http.WithSlsHiddenFeeCacheBypass(ctx)
+		}
+		slsHiddenPrices, err = c.getInitialHiddenPriceForLocalSipFromSls(slsCtx, pItemId, pShopId, pRegion, slsQueries)
 		if err != nil {
 			return nil, err
 		}
@@ -748,27 +757,34 @@ func (c *CalculationFactorsRepoImpl) getPItemChannelList(ctx context.Context, pI
 
 func (c *CalculationFactorsRepoImpl) getInitialHiddenPriceForLocalSipFromDb(ctx context.Context, pRegion string, dbQueries []model.LocalSipHiddenPriceQuery) ([]model.LocalSipHiddenPriceResult, error) {
 	res := make([]model.LocalSipHiddenPriceResult, len(dbQueries))
-	dbSession := c.sipRepo.DbSession()
 	for i, query := range dbQueries {
-		record, err := c.sipRepo.GetHiddenPriceConfigRecordByWeight(ctx, dbSession, pRegion, query.ARegion, query.Weight)
-		if err != nil {
+		hiddenPrice, ok := c.localSipFeeConfigRepo.GetHiddenPrice(ctx, pRegion, query.ARegion, query.Weight)
+		if !ok {
 			res[i] = model.LocalSipHiddenPriceResult{
 				QueryId: query.QueryId,
-				Err:     err,
+				Err:     cerr.New(fmt.Sprintf("hidden fee record for weight not found|P-region=%s|A-region=%s|weight=%d", pRegion, query.ARegion, query.Weight), uint32(pb.Constant_ERROR_NOT_FOUND)),
 			}
 			continue
 		}
-		if record == nil {
-			res[i] = model.LocalSipHiddenPriceResult{
-				Err: cerr.New(fmt.Sprintf("hidden fee record for weight not found|P-region=%s|A-region=%s|weight=%d", pRegion, query.ARegion, query.Weight), uint32(pb.Constant_ERROR_NOT_FOUND)),
-			}
-			continue
+		if limitCfg, err := config.GetLocalSipSettingLimitConfigByRegions(pRegion, query.ARegion); err == nil {
+			hiddenPrice = capLocalSipFee(hiddenPrice, limitCfg.InitHiddenPriceMin, limitCfg.InitHiddenPriceMax)
 		}
 		res[i] = model.LocalSipHiddenPriceResult{
 			QueryId:     query.QueryId,
-			HiddenPrice: calcutil.ToRealPrice(record.HiddenPrice),
+			HiddenPrice: hiddenPrice,
 		}
 	}
 
 	return res, nil
 }
+
+// capLocalSipFee caps fee matched from local SIP fee table into [min, max], a bound of 0 is not checked
+func capLocalSipFee(fee float64, min float64, max float64) float64 {
+	if max > 0 && fee > max {
+		return max
+	}
+	if min > 0 && fee < min {
+		return min
+	}
+	return fee
+}
@@ -8,8 +8,12 @@ import (
 	"sync"
 	"time"
 
+	"git.garena.com/shopee/platform/service-governance/observability/metric"
+
 	"git.garena.com/shopee/common/ulog"
 	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config/config_parser"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
@@ -19,20 +23,37 @@ type HpfnConfigRepo interface {
 	GetOne(ctx context.Context, hpfnKey string, weight int) (*sip_db.HpfnConfig, error)
 	GetAllHpfnConfig(ctx context.Context) (map[string][]*sip_db.HpfnConfig, error)
 	GetAllHpfnConfigFromDb(ctx context.Context) ([]*sip_db.HpfnConfig, error)
+	ValidateRateTables(ctx context.Context) []*model.RateTableViolation
+
+	GetRateTableCfgOverride(ctx context.Context, target pb.Constant_RateTableVersionTarget, targetKey string) (*model.RateTableCfg, bool)
+	GetAllRateTableVersions(ctx context.Context) []*model.HpfnRateTableVersion
+	GetRateTableVersion(ctx context.Context, versionId uint64) (*model.HpfnRateTableVersion, error)
+	ListRateTableVersions(ctx context.Context, target pb.Constant_RateTableVersionTarget, targetKey string) ([]*model.HpfnRateTableVersion, error)
+	CreateRateTableVersion(ctx context.Context, version *model.HpfnRateTableVersion) error
 }
 
+const rateTableViolationMetricName = "hpfn_rate_table_violation"
+
 type HpfnConfigRepoImpl struct {
 	mu         *sync.RWMutex
 	localCache map[string][]*sip_db.HpfnConfig
 
+	// all versions, and the active rate_table_cfg versions by target and key
+	versions          []*model.HpfnRateTableVersion
+	rateTableCfgCache map[pb.Constant_RateTableVersionTarget]map[string]*model.RateTableCfg
+
+	lastViolations       string         // violations found by last refresh, logged again only when changed
+	lastViolationRegions map[string]int // violation count of each region reported by last refresh
+
 	sipRepo sip_db.SipRepo
 }
 
 func NewHpfnConfigRepoImpl(sipRepo sip_db.SipRepo) *HpfnConfigRepoImpl {
 	v := &HpfnConfigRepoImpl{
-		mu:         &sync.RWMutex{},
-		localCache: map[string][]*sip_db.HpfnConfig{},
-		sipRepo:    sipRepo,
+		mu:                &sync.RWMutex{},
+		localCache:        map[string][]*sip_db.HpfnConfig{},
+		rateTableCfgCache: map[pb.Constant_RateTableVersionTarget]map[string]*model.RateTableCfg{},
+		sipRepo:           sipRepo,
 	}
 	v.init()
 	return v
@@ -52,7 +73,14 @@ func (h *HpfnConfigRepoImpl) init() {
 				time.Sleep(1 * time.Second)
 				continue
 			}
-			h.UpdateHpfnConfigInLocalCache(ctx, data)
+			versions, err := h.GetAllRateTableVersionsFromDb(ctx)
+			if err != nil {
+				// hpfn_config_tab is still refreshed with the versions loaded before, none on first load
+				logging.GetLogger(ctx).Error("list all hpfn_rate_table_version_tab", ulog.Error(err))
+				versions = h.GetAllRateTableVersions(ctx)
+			}
+			h.UpdateHpfnConfigInLocalCache(ctx, data, versions)
+			h.reportRateTableViolations(ctx, h.ValidateRateTables(ctx))
 			if firstLoad {
 				firstLoad = false
 				s, _ := json.Marshal(&h.localCache)
@@ -78,7 +106,10 @@ func (h *HpfnConfigRepoImpl) GetAllHpfnConfig(ctx context.Context) (map[string][
 	return m, nil
 }
 
-func (h *HpfnConfigRepoImpl) UpdateHpfnConfigInLocalCache(ctx context.Context, data []*sip_db.HpfnConfig) {
+// UpdateHpfnConfigInLocalCache caches hpfn_config_tab rows, the rows of a key are replaced by its active rate table
+// version if any. the active versions are resolved on every refresh, so a version takes effect at the first refresh
+// after its effective_from
+func (h *HpfnConfigRepoImpl) UpdateHpfnConfigInLocalCache(ctx context.Context, data []*sip_db.HpfnConfig, versions []*model.HpfnRateTableVersion) {
 	newLocalCache := map[string][]*sip_db.HpfnConfig{}
 	for _, datum := range data {
 		old, ok := newLocalCache[datum.HpfnKey]
@@ -87,6 +118,17 @@ func (h *HpfnConfigRepoImpl) UpdateHpfnConfigInLocalCache(ctx context.Context, d
 		}
 		newLocalCache[datum.HpfnKey] = append(old, datum)
 	}
+	newRateTableCfgCache := map[pb.Constant_RateTableVersionTarget]map[string]*model.RateTableCfg{}
+	for _, version := range resolveActiveRateTableVersions(versions, time.Now().Unix()) {
+		if version.Target == pb.Constant_RATE_TABLE_VERSION_TARGET_HPFN_RATE_TABLE {
+			newLocalCache[version.TargetKey] = rateTableVersionToHpfnConfigs(version)
+			continue
+		}
+		if _, ok := newRateTableCfgCache[version.Target]; !ok {
+			newRateTableCfgCache[version.Target] = map[string]*model.RateTableCfg{}
+		}
+		newRateTableCfgCache[version.Target][version.TargetKey] = version.Content.RateTableCfg
+	}
 	for _, v := range newLocalCache {
 		sort.Slice(v, func(i, j int) bool {
 			return v[i].WeightRange < v[j].WeightRange
@@ -96,6 +138,57 @@ func (h *HpfnConfigRepoImpl) UpdateHpfnConfigInLocalCache(ctx context.Context, d
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.localCache = newLocalCache
+	h.versions = versions
+	h.rateTableCfgCache = newRateTableCfgCache
+}
+
+// ValidateRateTables lints the cached hpfn_config_tab rows and the default rate table in config
+func (h *HpfnConfigRepoImpl) ValidateRateTables(ctx context.Context) []*model.RateTableViolation {
+	all, _ := h.GetAllHpfnConfig(ctx)
+	violations := config_parser.ValidateRateTables(pb.Constant_RATE_TABLE_SOURCE_HPFN_CONFIG_TAB, all)
+
+	defaultViolations, err := config_parser.ValidateDefaultRateTable()
+	if err != nil {
+		logging.GetLogger(ctx).Warn("default rate table for cb hidden price not configured", ulog.Error(err))
+	}
+	return append(violations, defaultViolations...)
+}
+
+// reportRateTableViolations runs on every refresh, the current violation count of each region is metered, and
+// violations are logged when they change
+func (h *HpfnConfigRepoImpl) reportRateTableViolations(ctx context.Context, violations []*model.RateTableViolation) {
+	h.lastViolationRegions = countViolationsByRegion(violations, h.lastViolationRegions)
+	for region, count := range h.lastViolationRegions {
+		_ = metric.RPCCustomReporter.ReportGauge(rateTableViolationMetricName, map[string]string{
+			"region": region,
+		}, float64(count))
+	}
+
+	s, _ := json.Marshal(violations)
+	if string(s) == h.lastViolations {
+		return
+	}
+	h.lastViolations = string(s)
+	if len(violations) == 0 {
+		logging.GetLogger(ctx).Info("[HPFN Rate Table] all rate tables are valid")
+		return
+	}
+	logging.GetLogger(ctx).Error(fmt.Sprintf("[HPFN Rate Table] found %d violations in rate tables, violations=%s", len(violations), s))
+}
+
+// countViolationsByRegion violation count of each region, the regions of last count without violations now are
+// counted as 0 so their gauges are cleared. a region is dropped once it is reported as 0
+func countViolationsByRegion(violations []*model.RateTableViolation, last map[string]int) map[string]int {
+	counts := map[string]int{}
+	for region, count := range last {
+		if count > 0 {
+			counts[region] = 0
+		}
+	}
+	for _, v := range violations {
+		counts[v.Region]++
+	}
+	return counts
 }
 
 func (c *HpfnConfigRepoImpl) GetOne(ctx context.Context, hpfnKey string, weight int) (*sip_db.HpfnConfig, error) {
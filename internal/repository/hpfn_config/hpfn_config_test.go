@@ -0,0 +1,25 @@
+package hpfn_config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+)
+
+func TestCountViolationsByRegion(t *testing.T) {
+	violations := []*model.RateTableViolation{{Region: "SG"}, {Region: "SG"}, {Region: "MY"}}
+
+	// the same violations on every refresh are counted the same
+	counts := countViolationsByRegion(violations, nil)
+	assert.Equal(t, map[string]int{"SG": 2, "MY": 1}, counts)
+	counts = countViolationsByRegion(violations, counts)
+	assert.Equal(t, map[string]int{"SG": 2, "MY": 1}, counts)
+
+	// a fixed region is reported as 0 once
+	counts = countViolationsByRegion(violations[:2], counts)
+	assert.Equal(t, map[string]int{"SG": 2, "MY": 0}, counts)
+	counts = countViolationsByRegion(violations[:2], counts)
+	assert.Equal(t, map[string]int{"SG": 2}, counts)
+}
@@ -0,0 +1,202 @@
+package hpfn_config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/infra/snowflake"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// GetRateTableCfgOverride the rate_table_cfg of the active version of target, ok is false if target has no active
+// version. cfg is nil if the active version unassigns the rate table
+func (h *HpfnConfigRepoImpl) GetRateTableCfgOverride(ctx context.Context, target pb.Constant_RateTableVersionTarget, targetKey string) (*model.RateTableCfg, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	cfg, ok := h.rateTableCfgCache[target][targetKey]
+	return cfg, ok
+}
+
+// GetAllRateTableVersions all versions in local cache, status is not set
+func (h *HpfnConfigRepoImpl) GetAllRateTableVersions(ctx context.Context) []*model.HpfnRateTableVersion {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.versions
+}
+
+func (h *HpfnConfigRepoImpl) GetAllRateTableVersionsFromDb(ctx context.Context) ([]*model.HpfnRateTableVersion, error) {
+	session := h.sipRepo.DbSession()
+	records, err := h.sipRepo.GetAllHpfnRateTableVersion(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]*model.HpfnRateTableVersion, 0, len(records))
+	for _, record := range records {
+		version, err := recordToRateTableVersion(record)
+		if err != nil {
+			// a broken version is never activated, the previous version keeps effective
+			logging.GetLogger(ctx).Error(err.Error())
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (h *HpfnConfigRepoImpl) GetRateTableVersion(ctx context.Context, versionId uint64) (*model.HpfnRateTableVersion, error) {
+	session := h.sipRepo.DbSession()
+	record, err := h.sipRepo.GetHpfnRateTableVersionById(ctx, session, int64(versionId))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, cerr.New(fmt.Sprintf("hpfn rate table version not found, versionId=%v", versionId), uint32(pb.Constant_ERROR_NOT_FOUND))
+	}
+	return recordToRateTableVersion(record)
+}
+
+// ListRateTableVersions versions of target with status, descending by effective_from
+func (h *HpfnConfigRepoImpl) ListRateTableVersions(ctx context.Context, target pb.Constant_RateTableVersionTarget, targetKey string) ([]*model.HpfnRateTableVersion, error) {
+	session := h.sipRepo.DbSession()
+	records, err := h.sipRepo.GetHpfnRateTableVersionsByTarget(ctx, session, uint32(target), targetKey)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]*model.HpfnRateTableVersion, 0, len(records))
+	for _, record := range records {
+		version, err := recordToRateTableVersion(record)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	SetRateTableVersionStatus(versions, time.Now().Unix())
+	return versions, nil
+}
+
+// SetRateTableVersionStatus sets status of versions of one target and sorts them descending by effective_from. the
+// active one is resolved the same as the local cache refresh, instances switch to it at their next refresh
+func SetRateTableVersionStatus(versions []*model.HpfnRateTableVersion, now int64) {
+	sortRateTableVersions(versions)
+	active := resolveActiveRateTableVersions(versions, now)
+	for _, version := range versions {
+		switch {
+		case version.EffectiveFrom > now:
+			version.Status = pb.Constant_RATE_TABLE_VERSION_STATUS_PENDING
+		case len(active) > 0 && active[0] == version:
+			version.Status = pb.Constant_RATE_TABLE_VERSION_STATUS_ACTIVE
+		default:
+			version.Status = pb.Constant_RATE_TABLE_VERSION_STATUS_SUPERSEDED
+		}
+	}
+}
+
+// CreateRateTableVersion stores version with a new id, it takes effect at the first refresh after effective_from
+func (h *HpfnConfigRepoImpl) CreateRateTableVersion(ctx context.Context, version *model.HpfnRateTableVersion) error {
+	content, err := json.Marshal(version.Content)
+	if err != nil {
+		return cerr.New(fmt.Sprintf("marshal hpfn rate table version content failed, err=%v", err), uint32(pb.Constant_ERROR_PARAMS))
+	}
+	id, err := snowflake.GetGenIDWorker().NextId(ctx)
+	if err != nil {
+		return cerr.Wrap(err, "error generating next hpfn rate table version id", uint32(pb.Constant_ERROR_INTERNAL))
+	}
+
+	record := &sip_db.HpfnRateTableVersion{
+		Id:             id,
+		TargetType:     uint32(version.Target),
+		TargetKey:      version.TargetKey,
+		Content:        string(content),
+		EffectiveFrom:  version.EffectiveFrom,
+		RollbackFromId: int64(version.RollbackFromId),
+		Operator:       version.Operator,
+		Remark:         version.Remark,
+	}
+	session := h.sipRepo.DbSession()
+	if err := h.sipRepo.CreateHpfnRateTableVersion(ctx, session, record); err != nil {
+		return err
+	}
+	version.Id = uint64(record.Id)
+	version.Ctime = record.Ctime
+	logging.GetLogger(ctx).Info(fmt.Sprintf("[HPFN Rate Table] created version, id=%v, target=%v, targetKey=%v, effectiveFrom=%v, operator=%v",
+		version.Id, version.Target, version.TargetKey, version.EffectiveFrom, version.Operator))
+	return nil
+}
+
+func recordToRateTableVersion(record *sip_db.HpfnRateTableVersion) (*model.HpfnRateTableVersion, error) {
+	version := &model.HpfnRateTableVersion{
+		Id:             uint64(record.Id),
+		Target:         pb.Constant_RateTableVersionTarget(record.TargetType),
+		TargetKey:      record.TargetKey,
+		EffectiveFrom:  record.EffectiveFrom,
+		RollbackFromId: uint64(record.RollbackFromId),
+		Operator:       record.Operator,
+		Remark:         record.Remark,
+		Ctime:          record.Ctime,
+	}
+	if err := json.Unmarshal([]byte(record.Content), &version.Content); err != nil {
+		return nil, cerr.New(fmt.Sprintf("unmarshal hpfn rate table version content failed, id=%v, err=%v", record.Id, err), uint32(pb.Constant_ERROR_INTERNAL))
+	}
+	return version, nil
+}
+
+// sortRateTableVersions descending by effective_from, the later created one first if same
+func sortRateTableVersions(versions []*model.HpfnRateTableVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].EffectiveFrom != versions[j].EffectiveFrom {
+			return versions[i].EffectiveFrom > versions[j].EffectiveFrom
+		}
+		return versions[i].Id > versions[j].Id
+	})
+}
+
+// resolveActiveRateTableVersions the version with the latest reached effective_from of each target
+func resolveActiveRateTableVersions(versions []*model.HpfnRateTableVersion, now int64) []*model.HpfnRateTableVersion {
+	sorted := make([]*model.HpfnRateTableVersion, len(versions))
+	copy(sorted, versions)
+	sortRateTableVersions(sorted)
+
+	type targetKey struct {
+		target pb.Constant_RateTableVersionTarget
+		key    string
+	}
+	seen := map[targetKey]bool{}
+	active := make([]*model.HpfnRateTableVersion, 0)
+	for _, version := range sorted {
+		k := targetKey{target: version.Target, key: version.TargetKey}
+		if version.EffectiveFrom > now || seen[k] {
+			continue
+		}
+		seen[k] = true
+		active = append(active, version)
+	}
+	return active
+}
+
+func rateTableVersionToHpfnConfigs(version *model.HpfnRateTableVersion) []*sip_db.HpfnConfig {
+	rows := make([]*sip_db.HpfnConfig, 0, len(version.Content.Rows))
+	for _, row := range version.Content.Rows {
+		rows = append(rows, &sip_db.HpfnConfig{
+			HpfnKey:     version.TargetKey,
+			WeightRange: row.WeightRange,
+			StartPrice:  row.StartPrice,
+			StartWeight: row.StartWeight,
+			RoundSize:   row.RoundSize,
+			Price:       row.Price,
+			WeightStep:  row.WeightStep,
+			Adjustment:  row.Adjustment,
+			DescInfo:    row.DescInfo,
+			Ctime:       version.Ctime,
+			Mtime:       version.Ctime,
+		})
+	}
+	return rows
+}
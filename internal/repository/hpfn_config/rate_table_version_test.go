@@ -0,0 +1,63 @@
+package hpfn_config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+func TestResolveActiveRateTableVersions(t *testing.T) {
+	table := pb.Constant_RATE_TABLE_VERSION_TARGET_HPFN_RATE_TABLE
+	shop := pb.Constant_RATE_TABLE_VERSION_TARGET_SHOP_RATE_TABLE_CFG
+	versions := []*model.HpfnRateTableVersion{
+		{Id: 1, Target: table, TargetKey: "k1", EffectiveFrom: 100},
+		{Id: 2, Target: table, TargetKey: "k1", EffectiveFrom: 200},
+		{Id: 3, Target: table, TargetKey: "k1", EffectiveFrom: 300}, // pending
+		{Id: 4, Target: table, TargetKey: "k1", EffectiveFrom: 200}, // rollback created later with same effective_from
+		{Id: 5, Target: shop, TargetKey: "k1", EffectiveFrom: 100},
+		{Id: 6, Target: shop, TargetKey: "k2", EffectiveFrom: 300}, // pending
+	}
+
+	active := resolveActiveRateTableVersions(versions, 250)
+	ids := make([]uint64, 0, len(active))
+	for _, version := range active {
+		ids = append(ids, version.Id)
+	}
+	assert.ElementsMatch(t, []uint64{4, 5}, ids)
+
+	active = resolveActiveRateTableVersions(versions, 300)
+	ids = ids[:0]
+	for _, version := range active {
+		ids = append(ids, version.Id)
+	}
+	assert.ElementsMatch(t, []uint64{3, 5, 6}, ids)
+}
+
+func TestSetRateTableVersionStatus(t *testing.T) {
+	table := pb.Constant_RATE_TABLE_VERSION_TARGET_HPFN_RATE_TABLE
+	versions := []*model.HpfnRateTableVersion{
+		{Id: 1, Target: table, TargetKey: "k1", EffectiveFrom: 100},
+		{Id: 2, Target: table, TargetKey: "k1", EffectiveFrom: 200},
+		{Id: 3, Target: table, TargetKey: "k1", EffectiveFrom: 300},
+		// created last with an effective_from in the tolerated past, before the active version
+		{Id: 4, Target: table, TargetKey: "k1", EffectiveFrom: 150},
+	}
+
+	SetRateTableVersionStatus(versions, 250)
+	statuses := map[uint64]pb.Constant_RateTableVersionStatus{}
+	ids := make([]uint64, 0, len(versions))
+	for _, version := range versions {
+		statuses[version.Id] = version.Status
+		ids = append(ids, version.Id)
+	}
+	assert.Equal(t, []uint64{3, 2, 4, 1}, ids)
+	assert.Equal(t, map[uint64]pb.Constant_RateTableVersionStatus{
+		1: pb.Constant_RATE_TABLE_VERSION_STATUS_SUPERSEDED,
+		2: pb.Constant_RATE_TABLE_VERSION_STATUS_ACTIVE,
+		3: pb.Constant_RATE_TABLE_VERSION_STATUS_PENDING,
+		4: pb.Constant_RATE_TABLE_VERSION_STATUS_SUPERSEDED,
+	}, statuses)
+}
@@ -0,0 +1,217 @@
+package local_sip_fee_config
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/calcutil"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+type LocalSipFeeConfigRepo interface {
+	// GetShippingFee real shipping fee of weight matched by the fee table mode of region pair, ok is false if not found
+	GetShippingFee(ctx context.Context, pRegion string, aRegion string, weight int64) (fee float64, ok bool)
+	// GetHiddenPrice real initial hidden price of weight matched by the fee table mode of region pair, ok is false if not found
+	GetHiddenPrice(ctx context.Context, pRegion string, aRegion string, weight int64) (fee float64, ok bool)
+}
+
+type regionPair struct {
+	pRegion string
+	aRegion string
+}
+
+// feeRow weight in db unit and fee in db price
+type feeRow struct {
+	weight int64
+	fee    int64
+}
+
+// LocalSipFeeConfigRepoImpl caches local_shipping_fee_config_tab and local_hidden_price_config_tab, the rows of each
+// P region and A region pair are sorted by weight
+type LocalSipFeeConfigRepoImpl struct {
+	mu               *sync.RWMutex
+	shippingFeeCache map[regionPair][]feeRow
+	hiddenPriceCache map[regionPair][]feeRow
+
+	// checksums of the tables loaded by last refresh, the cache is rebuilt only when they change
+	shippingFeeChecksum string
+	hiddenPriceChecksum string
+
+	sipRepo sip_db.SipRepo
+}
+
+func NewLocalSipFeeConfigRepoImpl(sipRepo sip_db.SipRepo) *LocalSipFeeConfigRepoImpl {
+	v := &LocalSipFeeConfigRepoImpl{
+		mu:               &sync.RWMutex{},
+		shippingFeeCache: map[regionPair][]feeRow{},
+		hiddenPriceCache: map[regionPair][]feeRow{},
+		sipRepo:          sipRepo,
+	}
+	v.init()
+	return v
+}
+
+func (l *LocalSipFeeConfigRepoImpl) init() {
+	tick := time.NewTicker(10 * time.Second)
+	ch := make(chan struct{})
+
+	go func() {
+		ctx := context.TODO()
+		firstLoad := true
+		for {
+			session := l.sipRepo.DbSession()
+			shippingFees, err := l.sipRepo.GetAllShippingFeeConfig(ctx, session)
+			if err != nil {
+				logging.GetLogger(ctx).Error("list all local_shipping_fee_config_tab", ulog.Error(err))
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			hiddenPrices, err := l.sipRepo.GetAllHiddenPriceConfig(ctx, session)
+			if err != nil {
+				logging.GetLogger(ctx).Error("list all local_hidden_price_config_tab", ulog.Error(err))
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			l.UpdateLocalCache(ctx, shippingFees, hiddenPrices)
+			if firstLoad {
+				firstLoad = false
+				ch <- struct{}{}
+			}
+
+			<-tick.C
+		}
+	}()
+	<-ch
+}
+
+// UpdateLocalCache replaces the cache of a table if its records changed since last refresh
+func (l *LocalSipFeeConfigRepoImpl) UpdateLocalCache(ctx context.Context, shippingFees []*sip_db.LocalShippingFeeConfigRecord, hiddenPrices []*sip_db.LocalHiddenPriceConfigRecord) {
+	shippingFeeChecksum := checksum(shippingFees)
+	hiddenPriceChecksum := checksum(hiddenPrices)
+
+	l.mu.RLock()
+	shippingFeeChanged := shippingFeeChecksum != l.shippingFeeChecksum
+	hiddenPriceChanged := hiddenPriceChecksum != l.hiddenPriceChecksum
+	l.mu.RUnlock()
+	if !shippingFeeChanged && !hiddenPriceChanged {
+		return
+	}
+
+	var newShippingFeeCache map[regionPair][]feeRow
+	if shippingFeeChanged {
+		newShippingFeeCache = map[regionPair][]feeRow{}
+		for _, record := range shippingFees {
+			key := newRegionPair(record.MstRegion, record.AffiRegion)
+			newShippingFeeCache[key] = append(newShippingFeeCache[key], feeRow{weight: record.Weight, fee: record.ShippingFeePrice})
+		}
+		for _, rows := range newShippingFeeCache {
+			sort.SliceStable(rows, func(i, j int) bool {
+				return rows[i].weight < rows[j].weight
+			})
+		}
+		logging.GetLogger(ctx).Info(fmt.Sprintf("[Local SIP Fee Config] local_shipping_fee_config_tab changed, records=%d, regionPairs=%d",
+			len(shippingFees), len(newShippingFeeCache)))
+	}
+	var newHiddenPriceCache map[regionPair][]feeRow
+	if hiddenPriceChanged {
+		newHiddenPriceCache = map[regionPair][]feeRow{}
+		for _, record := range hiddenPrices {
+			key := newRegionPair(record.MstRegion, record.AffiRegion)
+			newHiddenPriceCache[key] = append(newHiddenPriceCache[key], feeRow{weight: record.Weight, fee: record.HiddenPrice})
+		}
+		for _, rows := range newHiddenPriceCache {
+			sort.SliceStable(rows, func(i, j int) bool {
+				return rows[i].weight < rows[j].weight
+			})
+		}
+		logging.GetLogger(ctx).Info(fmt.Sprintf("[Local SIP Fee Config] local_hidden_price_config_tab changed, records=%d, regionPairs=%d",
+			len(hiddenPrices), len(newHiddenPriceCache)))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if shippingFeeChanged {
+		l.shippingFeeCache = newShippingFeeCache
+		l.shippingFeeChecksum = shippingFeeChecksum
+	}
+	if hiddenPriceChanged {
+		l.hiddenPriceCache = newHiddenPriceCache
+		l.hiddenPriceChecksum = hiddenPriceChecksum
+	}
+}
+
+func (l *LocalSipFeeConfigRepoImpl) GetShippingFee(ctx context.Context, pRegion string, aRegion string, weight int64) (float64, bool) {
+	l.mu.RLock()
+	rows := l.shippingFeeCache[newRegionPair(pRegion, aRegion)]
+	l.mu.RUnlock()
+
+	return lookupFeeTable(rows, weight, config.GetLocalSipFeeTableConfig(pRegion, aRegion).ShippingFee)
+}
+
+func (l *LocalSipFeeConfigRepoImpl) GetHiddenPrice(ctx context.Context, pRegion string, aRegion string, weight int64) (float64, bool) {
+	l.mu.RLock()
+	rows := l.hiddenPriceCache[newRegionPair(pRegion, aRegion)]
+	l.mu.RUnlock()
+
+	return lookupFeeTable(rows, weight, config.GetLocalSipFeeTableConfig(pRegion, aRegion).HiddenPrice)
+}
+
+// FeeTableMode the mode of setting, unknown mode is matched as step
+func FeeTableMode(setting config.LocalSipFeeTableSetting) pb.Constant_LocalSipFeeTableMode {
+	switch setting.Mode {
+	case config.LocalSipFeeTableModeLinear:
+		return pb.Constant_LOCAL_SIP_FEE_TABLE_MODE_LINEAR
+	case config.LocalSipFeeTableModeBasePlusExtra:
+		return pb.Constant_LOCAL_SIP_FEE_TABLE_MODE_BASE_PLUS_EXTRA
+	default:
+		return pb.Constant_LOCAL_SIP_FEE_TABLE_MODE_STEP
+	}
+}
+
+// lookupFeeTable matches weight to rows sorted by weight, a weight above the last row is only matched in base plus extra mode
+func lookupFeeTable(rows []feeRow, weight int64, setting config.LocalSipFeeTableSetting) (float64, bool) {
+	if len(rows) == 0 {
+		return 0, false
+	}
+	mode := FeeTableMode(setting)
+
+	i := sort.Search(len(rows), func(i int) bool {
+		return rows[i].weight >= weight
+	})
+	if i == len(rows) {
+		if mode != pb.Constant_LOCAL_SIP_FEE_TABLE_MODE_BASE_PLUS_EXTRA {
+			return 0, false
+		}
+		last := rows[len(rows)-1]
+		extraGram := calcutil.DbWeightToGram(weight) - calcutil.DbWeightToGram(last.weight)
+		return calcutil.ToRealPrice(last.fee) + extraGram*setting.PerExtraGram, true
+	}
+
+	// weights below the first row take its fee in all modes
+	if mode == pb.Constant_LOCAL_SIP_FEE_TABLE_MODE_LINEAR && i > 0 && rows[i].weight != weight {
+		prev, next := rows[i-1], rows[i]
+		ratio := float64(weight-prev.weight) / float64(next.weight-prev.weight)
+		prevFee, nextFee := calcutil.ToRealPrice(prev.fee), calcutil.ToRealPrice(next.fee)
+		return prevFee + (nextFee-prevFee)*ratio, true
+	}
+	return calcutil.ToRealPrice(rows[i].fee), true
+}
+
+func newRegionPair(pRegion string, aRegion string) regionPair {
+	return regionPair{pRegion: strings.ToUpper(pRegion), aRegion: strings.ToUpper(aRegion)}
+}
+
+func checksum(records interface{}) string {
+	s, _ := json.Marshal(records)
+	return fmt.Sprintf("%x", md5.Sum(s))
+}
@@ -0,0 +1,91 @@
+package local_sip_fee_config
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/sip_db"
+)
+
+func TestLocalSipFeeConfigRepoImpl(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{SIPMigrationCfg: &config.SIPMigrationConfig{}})
+	repo := &LocalSipFeeConfigRepoImpl{mu: &sync.RWMutex{}}
+	shippingFees := []*sip_db.LocalShippingFeeConfigRecord{
+		{Id: 1, MstRegion: "SG", AffiRegion: "MY", Weight: 1000, ShippingFeePrice: 300},
+		{Id: 2, MstRegion: "SG", AffiRegion: "MY", Weight: 500, ShippingFeePrice: 200},
+		{Id: 3, MstRegion: "SG", AffiRegion: "TH", Weight: 500, ShippingFeePrice: 100},
+	}
+	hiddenPrices := []*sip_db.LocalHiddenPriceConfigRecord{
+		{Id: 1, MstRegion: "SG", AffiRegion: "MY", Weight: 500, HiddenPrice: 50},
+	}
+	repo.UpdateLocalCache(ctx, shippingFees, hiddenPrices)
+
+	assertFee := func(expected float64, fee float64, ok bool) {
+		assert.True(t, ok)
+		assert.Equal(t, expected, fee)
+	}
+	assertNotFound := func(_ float64, ok bool) {
+		assert.False(t, ok)
+	}
+	fee, ok := repo.GetShippingFee(ctx, "SG", "MY", 1)
+	assertFee(0.002, fee, ok)
+	fee, ok = repo.GetShippingFee(ctx, "sg", "my", 500)
+	assertFee(0.002, fee, ok)
+	fee, ok = repo.GetShippingFee(ctx, "SG", "MY", 501)
+	assertFee(0.003, fee, ok)
+	assertNotFound(repo.GetShippingFee(ctx, "SG", "MY", 1001))
+	fee, ok = repo.GetShippingFee(ctx, "SG", "TH", 100)
+	assertFee(0.001, fee, ok)
+	assertNotFound(repo.GetShippingFee(ctx, "SG", "VN", 100))
+	fee, ok = repo.GetHiddenPrice(ctx, "SG", "MY", 100)
+	assertFee(0.0005, fee, ok)
+	assertNotFound(repo.GetHiddenPrice(ctx, "SG", "TH", 100))
+
+	// unchanged tables are not rebuilt
+	cache := repo.shippingFeeCache
+	repo.UpdateLocalCache(ctx, shippingFees, []*sip_db.LocalHiddenPriceConfigRecord{
+		{Id: 1, MstRegion: "SG", AffiRegion: "MY", Weight: 500, HiddenPrice: 60},
+	})
+	fee, ok = repo.GetHiddenPrice(ctx, "SG", "MY", 100)
+	assertFee(0.0006, fee, ok)
+	assert.Equal(t, cache, repo.shippingFeeCache)
+}
+
+func TestLookupFeeTable(t *testing.T) {
+	// 1kg for 2.0, 2kg for 4.0
+	rows := []feeRow{{weight: 100000, fee: 200000}, {weight: 200000, fee: 400000}}
+	step := config.LocalSipFeeTableSetting{}
+	linear := config.LocalSipFeeTableSetting{Mode: config.LocalSipFeeTableModeLinear}
+	basePlusExtra := config.LocalSipFeeTableSetting{Mode: config.LocalSipFeeTableModeBasePlusExtra, PerExtraGram: 0.01}
+
+	cases := []struct {
+		name    string
+		setting config.LocalSipFeeTableSetting
+		weight  int64
+		fee     float64
+		ok      bool
+	}{
+		{"step below first row", step, 50000, 2, true},
+		{"step between rows", step, 150000, 4, true},
+		{"step above last row", step, 200001, 0, false},
+		{"linear below first row", linear, 50000, 2, true},
+		{"linear between rows", linear, 150000, 3, true},
+		{"linear on row", linear, 200000, 4, true},
+		{"linear above last row", linear, 200001, 0, false},
+		{"base plus extra between rows", basePlusExtra, 150000, 4, true},
+		{"base plus extra above last row", basePlusExtra, 300000, 14, true},
+	}
+	for _, c := range cases {
+		fee, ok := lookupFeeTable(rows, c.weight, c.setting)
+		assert.Equal(t, c.ok, ok, c.name)
+		assert.InDelta(t, c.fee, fee, 1e-9, c.name)
+	}
+
+	_, ok := lookupFeeTable(nil, 100000, basePlusExtra)
+	assert.False(t, ok)
+}
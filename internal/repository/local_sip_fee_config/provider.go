@@ -0,0 +1,10 @@
+package local_sip_fee_config
+
+import (
+	"github.com/google/wire"
+)
+
+var ProviderSet = wire.NewSet(
+	NewLocalSipFeeConfigRepoImpl,
+	wire.Bind(new(LocalSipFeeConfigRepo), new(*LocalSipFeeConfigRepoImpl)),
+)
@@ -3,8 +3,10 @@ package repository
 import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/account_service"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/edit_item_price_allow_list"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/factors"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/hpfn_config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/local_sip_fee_config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/price_sync_db"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/region_rate_table_config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/shop_ops_audit_log"
@@ -19,6 +21,8 @@ var ProviderSet = wire.NewSet(
 	sip_db.ProviderSet,
 	sip_v2_db.ProviderSet,
 	hpfn_config.ProviderSet,
+	local_sip_fee_config.ProviderSet,
+	exchange_rate_history.ProviderSet,
 	account_service.ProviderSet,
 	factors.ProviderSet,
 	region_rate_table_config.ProviderSet,
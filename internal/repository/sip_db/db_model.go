@@ -40,6 +40,18 @@ func init() {
 		&HpfnConfig{},
 		tablereflect.Table("hpfn_config_tab"),
 	)
+	tablereflect.TypeInit(
+		&HpfnRateTableVersion{},
+		tablereflect.Table("hpfn_rate_table_version_tab"),
+	)
+	tablereflect.TypeInit(
+		&ExchangeRateHistory{},
+		tablereflect.Table("exchange_rate_history_tab"),
+	)
+	tablereflect.TypeInit(
+		&ExchangeRateQuarantine{},
+		tablereflect.Table("exchange_rate_quarantine_tab"),
+	)
 
 	tablereflect.TypeInit(
 		&internal.AShopData{},
@@ -124,6 +136,31 @@ type ExchangeRate struct {
 	Mtime          int64  `gdbc:"column=mtime"`
 }
 
+// ExchangeRateHistory an exchange rate seen by the service, a row is added only when the rate of a key changes
+type ExchangeRateHistory struct {
+	Id           int64  `gdbc:"primary_key=true, column=id"`
+	Source       uint32 `gdbc:"column=source"`   // refer to pb.Constant_ExchangeRateSource
+	RateKey      string `gdbc:"column=rate_key"` // currency pair, merchant and region, or currency according to source
+	ExchangeRate string `gdbc:"column=exchange_rate"`
+	SeenFrom     int64  `gdbc:"column=seen_from"`
+	Ctime        int64  `gdbc:"column=ctime"`
+	Mtime        int64  `gdbc:"column=mtime"`
+}
+
+// ExchangeRateQuarantine a refreshed rate which jumped too far from the previous rate of its key, the previous rate is
+// served until the row is approved
+type ExchangeRateQuarantine struct {
+	Id           int64  `gdbc:"primary_key=true, column=id"`
+	Source       uint32 `gdbc:"column=source"` // refer to pb.Constant_ExchangeRateSource
+	RateKey      string `gdbc:"column=rate_key"`
+	PreviousRate string `gdbc:"column=previous_rate"`
+	PendingRate  string `gdbc:"column=pending_rate"`
+	Status       uint32 `gdbc:"column=status"` // refer to pb.Constant_ExchangeRateQuarantineStatus
+	Operator     string `gdbc:"column=operator"`
+	Ctime        int64  `gdbc:"column=ctime"`
+	Mtime        int64  `gdbc:"column=mtime"`
+}
+
 type HpfnConfig struct {
 	Id          int64  `gdbc:"column=id"`
 	HpfnKey     string `gdbc:"column=hpfn_key"`
@@ -138,3 +175,17 @@ type HpfnConfig struct {
 	Ctime       int64  `gdbc:"column=ctime"`
 	Mtime       int64  `gdbc:"column=mtime"`
 }
+
+// HpfnRateTableVersion immutable version of an HPFN rate table or a rate_table_cfg assignment, rows are never updated
+type HpfnRateTableVersion struct {
+	Id             int64  `gdbc:"primary_key=true, column=id"`
+	TargetType     uint32 `gdbc:"column=target_type"` // refer to pb.Constant_RateTableVersionTarget
+	TargetKey      string `gdbc:"column=target_key"`
+	Content        string `gdbc:"column=content"` // json of model.HpfnRateTableVersionContent
+	EffectiveFrom  int64  `gdbc:"column=effective_from"`
+	RollbackFromId int64  `gdbc:"column=rollback_from_id"`
+	Operator       string `gdbc:"column=operator"`
+	Remark         string `gdbc:"column=remark"`
+	Ctime          int64  `gdbc:"column=ctime"`
+	Mtime          int64  `gdbc:"column=mtime"`
+}
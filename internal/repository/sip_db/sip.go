@@ -14,10 +14,23 @@ type SipRepo interface {
 	GetSystemConfigRecordByType(ctx context.Context, session orm.DbSession, configType int) (*SystemConfigRecord, error)
 	GetHiddenPriceConfigList(ctx context.Context, session orm.DbSession, pRegion, aRegion string) ([]*LocalHiddenPriceConfigRecord, error)
 	GetShippingFeeConfigList(ctx context.Context, session orm.DbSession, pRegion, aRegion string) ([]*LocalShippingFeeConfigRecord, error)
+	GetAllHiddenPriceConfig(ctx context.Context, session orm.DbSession) ([]*LocalHiddenPriceConfigRecord, error)
+	GetAllShippingFeeConfig(ctx context.Context, session orm.DbSession) ([]*LocalShippingFeeConfigRecord, error)
 	GetExchangeRateByCurrency(ctx context.Context, session orm.DbSession, currencyPair string) (*ExchangeRate, error)
 	GetHpfnConfigByHpfnKey(ctx context.Context, session orm.DbSession, hpfnKey string) (*HpfnConfig, error)
 	GetAllHpfnConfig(ctx context.Context, session orm.DbSession) ([]*HpfnConfig, error)
+	GetAllHpfnRateTableVersion(ctx context.Context, session orm.DbSession) ([]*HpfnRateTableVersion, error)
+	GetHpfnRateTableVersionById(ctx context.Context, session orm.DbSession, id int64) (*HpfnRateTableVersion, error)
+	GetHpfnRateTableVersionsByTarget(ctx context.Context, session orm.DbSession, targetType uint32, targetKey string) ([]*HpfnRateTableVersion, error)
+	CreateHpfnRateTableVersion(ctx context.Context, session orm.DbSession, version *HpfnRateTableVersion) error
 	GetAllExchangeRate(ctx context.Context, session orm.DbSession) ([]*ExchangeRate, error)
+	GetExchangeRateHistoryAsOf(ctx context.Context, session orm.DbSession, source uint32, rateKey string, asOf int64) (*ExchangeRateHistory, error)
+	CreateExchangeRateHistory(ctx context.Context, session orm.DbSession, history *ExchangeRateHistory) error
+	GetExchangeRateQuarantinesByPendingRate(ctx context.Context, session orm.DbSession, source uint32, rateKey string, pendingRate string) ([]*ExchangeRateQuarantine, error)
+	GetExchangeRateQuarantineById(ctx context.Context, session orm.DbSession, id int64) (*ExchangeRateQuarantine, error)
+	GetExchangeRateQuarantines(ctx context.Context, session orm.DbSession, status uint32, limit int) ([]*ExchangeRateQuarantine, error)
+	CreateExchangeRateQuarantine(ctx context.Context, session orm.DbSession, quarantine *ExchangeRateQuarantine) error
+	ApproveExchangeRateQuarantine(ctx context.Context, session orm.DbSession, id int64, operator string) error
 
 	GetAShopDataByAffiShopId(ctx context.Context, session orm.DbSession, affiShopId uint64) (*internal.AShopData, error)
 	GetAShopDataByAffiShopIdBatch(ctx context.Context, session orm.DbSession, affiShopIds []uint64) ([]*internal.AShopData, error)
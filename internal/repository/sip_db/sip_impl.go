@@ -130,6 +130,9 @@ func (s *SipRepoImpl) getShopMapByAShopIdBatch(ctx context.Context, session orm.
 func (s *SipRepoImpl) GetExchangeRateByCurrency(ctx context.Context, session orm.DbSession, currencyPair string) (*ExchangeRate, error) {
 	res := &ExchangeRate{}
 	err := session.Select(res).Where(gdbc.P("currency_pair").EQ(currencyPair)).Fetch(ctx)
+	if err == gdbc.ErrNoRows {
+		return nil, cerr.New(fmt.Sprintf("exchange rate not found for currencyPair=%s", currencyPair), uint32(pb.Constant_ERROR_NOT_FOUND))
+	}
 	if err != nil {
 		return nil, cerr.New(fmt.Sprintf("failed to GetExchangeRateByCurrency for currencyPair=%s", currencyPair), uint32(pb.Constant_ERROR_DATABASE))
 	}
@@ -225,6 +228,62 @@ func (s *SipRepoImpl) GetLocalShippingFeeConfigRecordByWeight(ctx context.Contex
 	return &record, nil
 }
 
+func (s *SipRepoImpl) GetAllHiddenPriceConfig(ctx context.Context, session orm.DbSession) ([]*LocalHiddenPriceConfigRecord, error) {
+	var allRecords []gdbc.Entity
+	done := false
+	batchSize := 200
+	lastId := int64(0)
+	for !done {
+		records, err := session.Select(&LocalHiddenPriceConfigRecord{}).Where(gdbc.P("id").GT(lastId)).OrderBy(gdbc.Asc("id")).Limit(batchSize).FetchAll(ctx)
+		if err != nil {
+			return nil, cerr.New(fmt.Sprintf("failed to get all local hidden price config, err=%v", err), uint32(pb.Constant_ERROR_DATABASE))
+		}
+
+		for _, record := range records {
+			allRecords = append(allRecords, record)
+			lastId = record.(*LocalHiddenPriceConfigRecord).Id
+		}
+
+		if len(records) < batchSize {
+			done = true
+		}
+	}
+
+	results := make([]*LocalHiddenPriceConfigRecord, len(allRecords))
+	for i, record := range allRecords {
+		results[i] = record.(*LocalHiddenPriceConfigRecord)
+	}
+	return results, nil
+}
+
+func (s *SipRepoImpl) GetAllShippingFeeConfig(ctx context.Context, session orm.DbSession) ([]*LocalShippingFeeConfigRecord, error) {
+	var allRecords []gdbc.Entity
+	done := false
+	batchSize := 200
+	lastId := int64(0)
+	for !done {
+		records, err := session.Select(&LocalShippingFeeConfigRecord{}).Where(gdbc.P("id").GT(lastId)).OrderBy(gdbc.Asc("id")).Limit(batchSize).FetchAll(ctx)
+		if err != nil {
+			return nil, cerr.New(fmt.Sprintf("failed to get all local shipping fee config, err=%v", err), uint32(pb.Constant_ERROR_DATABASE))
+		}
+
+		for _, record := range records {
+			allRecords = append(allRecords, record)
+			lastId = record.(*LocalShippingFeeConfigRecord).Id
+		}
+
+		if len(records) < batchSize {
+			done = true
+		}
+	}
+
+	results := make([]*LocalShippingFeeConfigRecord, len(allRecords))
+	for i, record := range allRecords {
+		results[i] = record.(*LocalShippingFeeConfigRecord)
+	}
+	return results, nil
+}
+
 func (s *SipRepoImpl) GetSystemConfigRecordByType(ctx context.Context, session orm.DbSession, configType int) (*SystemConfigRecord, error) {
 	var record SystemConfigRecord
 	err := session.Select(&record).
@@ -265,6 +324,184 @@ func (s *SipRepoImpl) GetAllHpfnConfig(ctx context.Context, session orm.DbSessio
 	return results, nil
 }
 
+func (s *SipRepoImpl) GetAllHpfnRateTableVersion(ctx context.Context, session orm.DbSession) ([]*HpfnRateTableVersion, error) {
+	var allRecords []gdbc.Entity
+	done := false
+	batchSize := 50
+	lastId := int64(0)
+	for !done {
+		records, err := session.Select(&HpfnRateTableVersion{}).Where(gdbc.P("id").GT(lastId)).OrderBy(gdbc.Asc("id")).Limit(batchSize).FetchAll(ctx)
+		if err != nil {
+			return nil, cerr.New(fmt.Sprintf("failed to get all hpfn rate table version, err=%v", err), uint32(pb.Constant_ERROR_DATABASE))
+		}
+
+		for _, record := range records {
+			allRecords = append(allRecords, record)
+			lastId = record.(*HpfnRateTableVersion).Id
+		}
+
+		if len(records) < batchSize {
+			done = true
+		}
+	}
+
+	results := make([]*HpfnRateTableVersion, len(allRecords))
+	for i, record := range allRecords {
+		results[i] = record.(*HpfnRateTableVersion)
+	}
+	return results, nil
+}
+
+func (s *SipRepoImpl) GetHpfnRateTableVersionById(ctx context.Context, session orm.DbSession, id int64) (*HpfnRateTableVersion, error) {
+	records, err := session.Select(&HpfnRateTableVersion{}).Where(gdbc.P("id").EQ(id)).FetchAll(ctx)
+	if err != nil {
+		return nil, cerr.New(fmt.Sprintf("failed to get hpfn rate table version, id=%v, err=%v", id, err), uint32(pb.Constant_ERROR_DATABASE))
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0].(*HpfnRateTableVersion), nil
+}
+
+func (s *SipRepoImpl) GetHpfnRateTableVersionsByTarget(ctx context.Context, session orm.DbSession, targetType uint32, targetKey string) ([]*HpfnRateTableVersion, error) {
+	records, err := session.Select(&HpfnRateTableVersion{}).
+		Where(gdbc.P("target_type").EQ(targetType), gdbc.P("target_key").EQ(targetKey)).
+		OrderBy(gdbc.Asc("id")).
+		FetchAll(ctx)
+	if err != nil {
+		return nil, cerr.New(fmt.Sprintf("failed to get hpfn rate table versions, targetType=%v, targetKey=%v, err=%v", targetType, targetKey, err),
+			uint32(pb.Constant_ERROR_DATABASE))
+	}
+	results := make([]*HpfnRateTableVersion, len(records))
+	for i, record := range records {
+		results[i] = record.(*HpfnRateTableVersion)
+	}
+	return results, nil
+}
+
+func (s *SipRepoImpl) CreateHpfnRateTableVersion(ctx context.Context, session orm.DbSession, version *HpfnRateTableVersion) error {
+	now := time.Now().Unix()
+	version.Ctime = now
+	version.Mtime = now
+	_, err := session.Create(version).Do(ctx)
+	if err != nil {
+		return cerr.New(fmt.Sprintf("failed to create hpfn rate table version, version=%+v, err=%v", version, err), uint32(pb.Constant_ERROR_DATABASE))
+	}
+	return nil
+}
+
+// GetExchangeRateHistoryAsOf the latest history of key seen at or before asOf, nil if not found
+func (s *SipRepoImpl) GetExchangeRateHistoryAsOf(ctx context.Context, session orm.DbSession, source uint32, rateKey string, asOf int64) (*ExchangeRateHistory, error) {
+	records, err := session.Select(&ExchangeRateHistory{}).
+		Where(gdbc.P("source").EQ(source), gdbc.P("rate_key").EQ(rateKey), gdbc.P("seen_from").LTEQ(asOf)).
+		OrderBy(gdbc.Desc("seen_from"), gdbc.Desc("id")).
+		Limit(1).
+		FetchAll(ctx)
+	if err != nil {
+		return nil, cerr.New(fmt.Sprintf("failed to get exchange rate history, source=%v, rateKey=%v, asOf=%v, err=%v", source, rateKey, asOf, err),
+			uint32(pb.Constant_ERROR_DATABASE))
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0].(*ExchangeRateHistory), nil
+}
+
+func (s *SipRepoImpl) CreateExchangeRateHistory(ctx context.Context, session orm.DbSession, history *ExchangeRateHistory) error {
+	now := time.Now().Unix()
+	history.Ctime = now
+	history.Mtime = now
+	_, err := session.Create(history).Do(ctx)
+	if err != nil {
+		return cerr.New(fmt.Sprintf("failed to create exchange rate history, history=%+v, err=%v", history, err), uint32(pb.Constant_ERROR_DATABASE))
+	}
+	return nil
+}
+
+// GetExchangeRateQuarantinesByPendingRate quarantines of the same jump, there can be more than one if several instances
+// saw it at the same time
+func (s *SipRepoImpl) GetExchangeRateQuarantinesByPendingRate(ctx context.Context, session orm.DbSession, source uint32, rateKey string, pendingRate string) ([]*ExchangeRateQuarantine, error) {
+	records, err := session.Select(&ExchangeRateQuarantine{}).
+		Where(gdbc.P("source").EQ(source), gdbc.P("rate_key").EQ(rateKey), gdbc.P("pending_rate").EQ(pendingRate)).
+		FetchAll(ctx)
+	if err != nil {
+		return nil, cerr.New(fmt.Sprintf("failed to get exchange rate quarantines, source=%v, rateKey=%v, pendingRate=%v, err=%v",
+			source, rateKey, pendingRate, err), uint32(pb.Constant_ERROR_DATABASE))
+	}
+	res := make([]*ExchangeRateQuarantine, 0, len(records))
+	for _, record := range records {
+		res = append(res, record.(*ExchangeRateQuarantine))
+	}
+	return res, nil
+}
+
+func (s *SipRepoImpl) GetExchangeRateQuarantineById(ctx context.Context, session orm.DbSession, id int64) (*ExchangeRateQuarantine, error) {
+	records, err := session.Select(&ExchangeRateQuarantine{}).Where(gdbc.P("id").EQ(id)).FetchAll(ctx)
+	if err != nil {
+		return nil, cerr.New(fmt.Sprintf("failed to get exchange rate quarantine, id=%v, err=%v", id, err), uint32(pb.Constant_ERROR_DATABASE))
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0].(*ExchangeRateQuarantine), nil
+}
+
+// GetExchangeRateQuarantines the latest limit quarantines of status, of all status if status is 0
+func (s *SipRepoImpl) GetExchangeRateQuarantines(ctx context.Context, session orm.DbSession, status uint32, limit int) ([]*ExchangeRateQuarantine, error) {
+	statuses := []uint32{status}
+	if status == 0 {
+		statuses = []uint32{
+			uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_PENDING),
+			uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_APPROVED),
+		}
+	}
+	records, err := session.Select(&ExchangeRateQuarantine{}).
+		Where(gdbc.P("status").IN(statuses)).
+		OrderBy(gdbc.Desc("id")).
+		Limit(limit).
+		FetchAll(ctx)
+	if err != nil {
+		return nil, cerr.New(fmt.Sprintf("failed to get exchange rate quarantines, status=%v, err=%v", status, err), uint32(pb.Constant_ERROR_DATABASE))
+	}
+	res := make([]*ExchangeRateQuarantine, 0, len(records))
+	for _, record := range records {
+		res = append(res, record.(*ExchangeRateQuarantine))
+	}
+	return res, nil
+}
+
+func (s *SipRepoImpl) CreateExchangeRateQuarantine(ctx context.Context, session orm.DbSession, quarantine *ExchangeRateQuarantine) error {
+	now := time.Now().Unix()
+	quarantine.Ctime = now
+	quarantine.Mtime = now
+	_, err := session.Create(quarantine).Do(ctx)
+	if err != nil {
+		return cerr.New(fmt.Sprintf("failed to create exchange rate quarantine, quarantine=%+v, err=%v", quarantine, err), uint32(pb.Constant_ERROR_DATABASE))
+	}
+	return nil
+}
+
+// ApproveExchangeRateQuarantine approves the quarantine of id if it is still pending
+func (s *SipRepoImpl) ApproveExchangeRateQuarantine(ctx context.Context, session orm.DbSession, id int64, operator string) error {
+	_, err := session.Update(&ExchangeRateQuarantine{
+		Id:     id,
+		Status: uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_PENDING),
+	}).
+		Set(
+			gdbc.Field("status", uint32(pb.Constant_EXCHANGE_RATE_QUARANTINE_STATUS_APPROVED)),
+			gdbc.Field("operator", operator),
+			gdbc.Field("mtime", time.Now().Unix()),
+		).Where(
+		gdbc.P("id"),
+		gdbc.P("status"),
+	).Do(ctx)
+	if err != nil {
+		return cerr.New(fmt.Sprintf("failed to approve exchange rate quarantine, id=%v, operator=%v, err=%v", id, operator, err),
+			uint32(pb.Constant_ERROR_DATABASE))
+	}
+	return nil
+}
+
 func (s *SipRepoImpl) GetHpfnConfigByHpfnKey(ctx context.Context, session orm.DbSession, hpfnKey string) (*HpfnConfig, error) {
 	res := &HpfnConfig{}
 	err := session.Select(res).Where(gdbc.P("hpfn_key").EQ(hpfnKey)).Fetch(ctx)
@@ -13,6 +13,7 @@ import (
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/http"
 	internalExchangeRatePb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/internal_exchange_rate.pb"
 	priceSyncPriceCalculationPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/cidutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/httpcliutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
@@ -21,12 +22,15 @@ import (
 type ExchangeRateServiceDm struct {
 	exchangeRateCacheManager cache.ExchangeRateCacheManager
 	httpCli                  httpcliutil.HTTPCli
+	exchangeRateHistoryRepo  exchange_rate_history.ExchangeRateHistoryRepo
 }
 
-func NewExchangeRateService(exchangeRateCacheManager cache.ExchangeRateCacheManager, httpCli httpcliutil.HTTPCli) ExchangeRateService {
+func NewExchangeRateService(exchangeRateCacheManager cache.ExchangeRateCacheManager, httpCli httpcliutil.HTTPCli,
+	exchangeRateHistoryRepo exchange_rate_history.ExchangeRateHistoryRepo) ExchangeRateService {
 	return &ExchangeRateServiceDm{
 		exchangeRateCacheManager: exchangeRateCacheManager,
 		httpCli:                  httpCli,
+		exchangeRateHistoryRepo:  exchangeRateHistoryRepo,
 	}
 }
 
@@ -106,6 +110,12 @@ func (dm *ExchangeRateServiceDm) GetMerchantExchangeRateInfo(ctx context.Context
 		return nil, cerr.New(fmt.Sprintf("exchange rate info is nil for merchantId=%v", merchantId), uint32(priceSyncPriceCalculationPb.Constant_ERROR_NOT_FOUND))
 	}
 	exchangeRateInfo = res.Data
+	seenRates := make(map[string]string, len(exchangeRateInfo.GetExchangeRateList()))
+	for _, exchangeRateData := range exchangeRateInfo.GetExchangeRateList() {
+		seenRates[exchange_rate_history.SellerPlatformRateKey(merchantId, exchangeRateData.GetRegion())] =
+			exchange_rate_history.FormatRate(exchangeRateData.GetExchangeRate())
+	}
+	dm.exchangeRateHistoryRepo.Observe(ctx, priceSyncPriceCalculationPb.Constant_SELLER_PLATFORM, seenRates)
 	err = dm.exchangeRateCacheManager.Set(ctx, key, exchangeRateInfo)
 	if err != nil {
 		logging.GetLogger(ctx).Error(fmt.Sprintf(
@@ -13,6 +13,7 @@ import (
 	"github.com/golang/protobuf/proto"
 
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
 
 	ibsPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/item_business.pb"
@@ -211,3 +212,16 @@ func GetItemEnabledChannelList(logistics *ibsPb.Logistics, ctx context.Context)
 	}
 	return enabledChannelIDList, nil
 }
+
+// GetItemDimension package dimension of item, nil if any side is not set
+func GetItemDimension(logistics *ibsPb.Logistics) *model.ItemDimension {
+	dimension := logistics.GetDimension()
+	if dimension.GetLength() <= 0 || dimension.GetWidth() <= 0 || dimension.GetHeight() <= 0 {
+		return nil
+	}
+	return &model.ItemDimension{
+		Length: float64(dimension.GetLength()),
+		Width:  float64(dimension.GetWidth()),
+		Height: float64(dimension.GetHeight()),
+	}
+}
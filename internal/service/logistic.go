@@ -16,8 +16,9 @@ type LogisticService interface {
 	// GetShopDefaultChannel get shop default channel info.
 	GetShopDefaultChannel(ctx context.Context, shopId uint64, region string) (map[string]*internalFulfillmentChannelPb.ItemLogisticsInfo, error)
 
-	// CalcHiddenFeeForCbSip calculate hidden fee by SLS api for cb sip.
-	CalcHiddenFeeForCbSip(ctx context.Context, shopID uint64, region string, itemId uint64, leafCategoryID uint64, weight uint64, enabledChannelIDList []uint32) (float64, error)
+	// CalcHiddenFeeForCbSip calculate hidden fee by SLS api for cb sip, the max hidden fee among channels is used,
+	// hidden fee of each channel is returned with the used one chosen.
+	CalcHiddenFeeForCbSip(ctx context.Context, shopID uint64, region string, itemId uint64, leafCategoryID uint64, weight uint64, enabledChannelIDList []uint32) (float64, []*model.ChannelHiddenFee, error)
 
 	// CalcHiddenFeeForLocalSip calculate hidden fee by SLS api for local sip.
 	CalcHiddenFeeForLocalSip(
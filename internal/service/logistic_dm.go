@@ -292,11 +292,11 @@ func (dm *LogisticServiceDm) GetShopDefaultChannel(ctx context.Context, shopId u
 	return rsp.DefaultItemLogisticInfo, nil
 }
 
-func (dm *LogisticServiceDm) CalcHiddenFeeForCbSip(ctx context.Context, shopID uint64, region string, itemId uint64, leafCategoryID uint64, weight uint64, enabledChannelIDList []uint32) (float64, error) {
+func (dm *LogisticServiceDm) CalcHiddenFeeForCbSip(ctx context.Context, shopID uint64, region string, itemId uint64, leafCategoryID uint64, weight uint64, enabledChannelIDList []uint32) (float64, []*model.ChannelHiddenFee, error) {
 	// checked with business side,
 	// for cb sip, we use 0 as hidden fee if no enabled channel
 	if len(enabledChannelIDList) == 0 {
-		return 0, nil
+		return 0, nil, nil
 	}
 
 	weightKg := calcutil.RoundUintToFloat(weight, constant.WeightPrecision, 3)
@@ -326,40 +326,80 @@ func (dm *LogisticServiceDm) CalcHiddenFeeForCbSip(ctx context.Context, shopID u
 
 	ctxWithCID, err := cidutil.FillCtxWithNewCID(ctx, region)
 	if err != nil {
-		return 0, cerr.New(fmt.Sprintf("failed to fill CID, err=%s", err.Error()),
+		return 0, nil, cerr.New(fmt.Sprintf("failed to fill CID, err=%s", err.Error()),
 			uint32(priceSyncPriceCalculationPb.Constant_ERROR_INTERNAL))
 	}
 
-	resp, err := http.BatchCalcHiddenFee(dm.httpCli, ctxWithCID, regionDomainUrl, &req)
+	resp, err := http.BatchCalcHiddenFeeWithCache(dm.httpCli, ctxWithCID, dm.commonCacheManager, region, regionDomainUrl, &req)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to calc hidden fee, shopId=%d, itemId=%d, err=%s",
 			shopID, itemId, err)
 		logging.GetLogger(ctx).Error(errMsg)
-		return 0, err
+		return 0, nil, err
 	}
 
 	if resp.RetCode != 0 {
 		logging.GetLogger(ctx).Error(fmt.Sprintf("calc hidden fee fail, code=%d, msg=%v", resp.RetCode, resp.Message))
-		return 0, cerr.New(fmt.Sprintf("cal hidden fee fail, code=%d, msg=%s", resp.RetCode, resp.Message), uint32(priceSyncPriceCalculationPb.Constant_ERROR_EXTERNAL))
+		return 0, nil, cerr.New(fmt.Sprintf("cal hidden fee fail, code=%d, msg=%s", resp.RetCode, resp.Message), uint32(priceSyncPriceCalculationPb.Constant_ERROR_EXTERNAL))
 	}
 
+	// a failed channel fails the calculation, the failed channels are only logged
 	var maxHiddenFee float64
+	var chosen *model.ChannelHiddenFee
+	var channelErr error
+	channelHiddenFees := make([]*model.ChannelHiddenFee, 0)
 	for _, channelList := range resp.Data {
 		for _, result := range channelList {
+			if result == nil {
+				continue
+			}
+			channelHiddenFee := &model.ChannelHiddenFee{
+				ChannelId: uint64(result.ProductID),
+				ErrCode:   result.RetCode,
+			}
+			channelHiddenFees = append(channelHiddenFees, channelHiddenFee)
 			if result.RetCode != 0 {
 				errMsg := fmt.Sprintf("failed to calculate hidden fee, channelId=%d, code=%d, msg=%s",
 					result.ProductID, result.RetCode, result.Message)
 				logging.GetLogger(ctx).Error(errMsg)
-				return 0, cerr.New(errMsg, uint32(priceSyncPriceCalculationPb.Constant_ERROR_HTTP_API))
+				if channelErr == nil {
+					channelErr = cerr.New(errMsg, uint32(priceSyncPriceCalculationPb.Constant_ERROR_HTTP_API))
+				}
+				continue
 			}
 
+			channelHiddenFee.HiddenFee = result.HiddenFee
 			if result.HiddenFee > maxHiddenFee {
 				maxHiddenFee = result.HiddenFee
 			}
+			if chosen == nil || result.HiddenFee > chosen.HiddenFee {
+				chosen = channelHiddenFee
+			}
 		}
 	}
+	if channelErr != nil {
+		return 0, nil, channelErr
+	}
+	if chosen != nil {
+		chosen.Chosen = true
+	}
+	dm.fillChannelNames(ctx, region, channelHiddenFees)
 
-	return maxHiddenFee, nil
+	return maxHiddenFee, channelHiddenFees, nil
+}
+
+// fillChannelNames names are left empty if the channels of region can not be fetched, the hidden fee is not affected
+func (dm *LogisticServiceDm) fillChannelNames(ctx context.Context, region string, channelHiddenFees []*model.ChannelHiddenFee) {
+	regionChannelMap, err := dm.GetChannelInfoMapForRegions(ctx, []string{region})
+	if err != nil {
+		logging.GetLogger(ctx).Warn(fmt.Sprintf("failed to get channel names, region=%s, err=%v", region, err))
+		return
+	}
+	for _, fee := range channelHiddenFees {
+		if channelInfo, ok := regionChannelMap[region][fee.ChannelId]; ok {
+			fee.ChannelName = channelInfo.Name
+		}
+	}
 }
 
 func (dm *LogisticServiceDm) CalcHiddenFeeForLocalSip(
@@ -435,7 +475,7 @@ func (dm *LogisticServiceDm) batchCalcHiddenFee(ctx context.Context, pRegion str
 			uint32(priceSyncPriceCalculationPb.Constant_ERROR_INTERNAL))
 	}
 
-	resp, err := http.BatchCalcHiddenFee(dm.httpCli, ctxWithCID, regionDomainUrl, &req)
+	resp, err := http.BatchCalcHiddenFeeWithCache(dm.httpCli, ctxWithCID, dm.commonCacheManager, pRegion, regionDomainUrl, &req)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to calc hidden fee, itemId=%d, err=%s", pItemId, err)
 		logging.GetLogger(ctx).Error(errMsg)
@@ -481,7 +521,7 @@ func (dm *LogisticServiceDm) CalcShippingFeeForLocalSIP(ctx context.Context,
 			uint32(priceSyncPriceCalculationPb.Constant_ERROR_INTERNAL))
 	}
 
-	resp, err := http.BatchCalcHiddenFee(dm.httpCli, ctxWithCID, regionDomainUrl, &req)
+	resp, err := http.BatchCalcHiddenFeeWithCache(dm.httpCli, ctxWithCID, dm.commonCacheManager, region, regionDomainUrl, &req)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to calc hidden fee, shopId=%d, itemId=%d, err=%s",
 			shopID, itemId, err)
@@ -582,6 +622,7 @@ func (dm *LogisticServiceDm) GetChannelInfoMapForRegions(ctx context.Context, re
 			regionChannelMap[region][channel.GetChannelId()] = &model.ChannelInfo{
 				ChannelId: channel.GetChannelId(),
 				Tag:       channel.GetTag(),
+				Name:      channel.GetName(),
 			}
 		}
 
@@ -6,28 +6,40 @@ import (
 	"time"
 
 	"git.garena.com/shopee/core-server/core-logic/cutil"
+	"git.garena.com/shopee/platform/service-governance/observability/metric"
 
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/cache"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/data_infra"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/repository/exchange_rate_history"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
 )
 
+const orderMartExchangeRateAgeMetricName = "order_mart_exchange_rate_age_seconds"
+
 type AsyncData interface {
 	AsyncSetOrderMartExchangeRate()
 }
 
 type AsyncDataImpl struct {
-	Cache cache.CommonCache
+	Cache                   cache.CommonCache
+	ExchangeRateHistoryRepo exchange_rate_history.ExchangeRateHistoryRepo
+
+	// rows of the last successful refresh, their ages are reported at every refresh even if it fails
+	orderMartExchangeRates []*model.OrderMartExchangeRate
 }
 
 type AsyncDataOpt struct {
-	Cache cache.CommonCache
+	Cache                   cache.CommonCache
+	ExchangeRateHistoryRepo exchange_rate_history.ExchangeRateHistoryRepo
 }
 
 func NewAsyncData(opts *AsyncDataOpt) *AsyncDataImpl {
 	return &AsyncDataImpl{
-		Cache: opts.Cache,
+		Cache:                   opts.Cache,
+		ExchangeRateHistoryRepo: opts.ExchangeRateHistoryRepo,
 	}
 }
 
@@ -53,6 +65,8 @@ func (s *AsyncDataImpl) aSyncQuery(ds *data_infra.DataService) time.Duration {
 
 	var interval time.Duration
 
+	defer s.reportOrderMartExchangeRateAge()
+
 	data, success := ds.GetOrderMartExchangeRateList(ctx)
 	if !success {
 		interval = time.Duration(config.GetCommonConfig().OrderMartExchangeRateRetrySeconds) * time.Second
@@ -66,8 +80,16 @@ func (s *AsyncDataImpl) aSyncQuery(ds *data_infra.DataService) time.Duration {
 	logging.GetLogger(ctx).Info(fmt.Sprintf(
 		"success to get order mart exchange rate from data infra, data=%v", cutil.JSONEncode(data)))
 
-	success = s.Cache.SetOrderMartExchangeRateBatch(ctx, data)
+	fetchedAt := time.Now().Unix()
+	for _, row := range data {
+		row.FetchedAt = fetchedAt
+	}
+	// quarantined rows are not cached, the cached previous rows are served until approved
+	accepted, quarantined := s.guardOrderMartExchangeRateJump(ctx, data)
+	success = len(accepted) == 0 || s.Cache.SetOrderMartExchangeRateBatch(ctx, accepted)
 	if success {
+		s.orderMartExchangeRates = append(accepted, quarantined...)
+
 		interval = time.Duration(config.GetCommonConfig().OrderMartExchangeRateRefreshSeconds) * time.Second
 
 		logging.GetLogger(ctx).Info(fmt.Sprintf(
@@ -80,3 +102,41 @@ func (s *AsyncDataImpl) aSyncQuery(ds *data_infra.DataService) time.Duration {
 	}
 	return interval
 }
+
+// guardOrderMartExchangeRateJump splits data into the rows to cache and the previous rows of quarantined currencies
+func (s *AsyncDataImpl) guardOrderMartExchangeRateJump(ctx context.Context, data []*model.OrderMartExchangeRate) (accepted, quarantined []*model.OrderMartExchangeRate) {
+	seenRates := make(map[string]string, len(data))
+	for _, row := range data {
+		seenRates[exchange_rate_history.OrderMartRateKey(row.Currency)] = exchange_rate_history.FormatRate(row.ExchangeRate)
+	}
+	servedRates := s.ExchangeRateHistoryRepo.GuardJump(ctx, pb.Constant_ORDER_MART_EXCHANGE_RATE, seenRates)
+
+	quarantinedCurrencies := make(map[string]bool)
+	for _, row := range data {
+		rateKey := exchange_rate_history.OrderMartRateKey(row.Currency)
+		if servedRates[rateKey] != seenRates[rateKey] {
+			quarantinedCurrencies[rateKey] = true
+			continue
+		}
+		accepted = append(accepted, row)
+	}
+	for _, row := range s.orderMartExchangeRates {
+		if quarantinedCurrencies[exchange_rate_history.OrderMartRateKey(row.Currency)] {
+			quarantined = append(quarantined, row)
+		}
+	}
+	return accepted, quarantined
+}
+
+func (s *AsyncDataImpl) reportOrderMartExchangeRateAge() {
+	now := time.Now()
+	for _, row := range s.orderMartExchangeRates {
+		rateAge := row.RateAge(now)
+		if rateAge == model.UnknownRateAge {
+			continue
+		}
+		_ = metric.RPCCustomReporter.ReportGauge(orderMartExchangeRateAgeMetricName, map[string]string{
+			"currency": row.Currency,
+		}, float64(rateAge))
+	}
+}
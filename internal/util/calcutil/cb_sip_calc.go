@@ -1,23 +1,131 @@
 package calcutil
 
+import (
+	"math"
+	"strings"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/constant"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
+)
+
+const CbSipAffiPriceFormula = pricekernel.CbSipAPriceFormula
+
 func CalcAffiDBPriceForCbSip(basePrice int64, aRegion string, exchangeRate,
 	priceRatio, ratio, countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee float64) int64 {
-	realPrimPrice := ToRealPrice(basePrice)
-	affiPrice := calcAffiPriceForCbSip(realPrimPrice, exchangeRate, priceRatio, ratio,
+	affiPrice, _ := CalcAffiDBPriceForCbSipWithTrace(basePrice, aRegion, exchangeRate, priceRatio, ratio,
 		countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee)
-	return ToDBPrice(PriceRoundUpByCountry(aRegion, affiPrice))
+	return affiPrice
 }
 
-func calcAffiPriceForCbSip(primPrice, exchangeRate,
-	priceRatio, ratio, countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee float64) float64 {
-	margin := calFinalMargin(countryMargin, shopMargin, itemMargin)
-	return (primPrice*priceRatio*exchangeRate + hiddenPrice) * ratio * margin * finalFee
+// CalcAffiDBPriceForCbSipWithTrace same as CalcAffiDBPriceForCbSip, and also returns every term used in calculation
+func CalcAffiDBPriceForCbSipWithTrace(basePrice int64, aRegion string, exchangeRate,
+	priceRatio, ratio, countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee float64) (int64, *model.PriceTrace) {
+	return pricekernel.CalcCbSipAffiDBPrice(basePrice, ratio, pricekernel.CbSipFactors{
+		ExchangeRate:  exchangeRate,
+		PriceRatio:    priceRatio,
+		CountryMargin: countryMargin,
+		ShopMargin:    shopMargin,
+		ItemMargin:    itemMargin,
+		HiddenPrice:   hiddenPrice,
+		FinalFee:      finalFee,
+		Decimal:       config.UseDecimalPriceCalc(aRegion),
+		Rounding:      RegionRoundingSetting(aRegion),
+	})
+}
+
+// NewCbSipKernelFactors resolves the config dependent factors of CB SIP A price formula for aRegion
+func NewCbSipKernelFactors(aRegion string, priceFactors *model.CbSipPriceFactors) pricekernel.CbSipFactors {
+	return pricekernel.CbSipFactors{
+		ExchangeRate:        priceFactors.ExchangeRate,
+		PriceRatio:          priceFactors.PriceRatio,
+		CountryMargin:       priceFactors.CountryMargin,
+		ShopMargin:          priceFactors.ShopMargin,
+		ItemMargin:          priceFactors.ItemMargin,
+		HiddenPrice:         priceFactors.HiddenPrice,
+		FinalFee:            priceFactors.FinalFee,
+		ServiceFee:          priceFactors.ServiceFee,
+		CommissionFee:       priceFactors.CommissionFee,
+		HandlingFee:         priceFactors.HandlingFee,
+		PromotionRatioLimit: CbSipPromotionRatioLimit(aRegion),
+		Decimal:             config.UseDecimalPriceCalc(aRegion),
+		Rounding:            RegionRoundingSetting(aRegion),
+		SettlementPrecision: config.GetSIPCurrencyCommonConf().GetByCurrency(priceFactors.SrcCurrency).Precision,
+	}
+}
+
+// CbSipPromotionRatioLimit max promotion ratio of aRegion, 0 if not limited
+func CbSipPromotionRatioLimit(aRegion string) float64 {
+	if strings.ToUpper(aRegion) == "VN" {
+		return constant.VnPromoRatioLimit
+	}
+	return 0
+}
+
+// CalcPItemDBPriceForCbSip inverts CalcAffiDBPriceForCbSip without promotion ratio.
+// it returns the largest P item price on srcCurrency precision whose A price before rounding doesn't exceed targetAPrice,
+// and the A price it produces. the A price is larger than targetAPrice when rounding up skips the target.
+// ok is false when no positive P item price can reach targetAPrice.
+func CalcPItemDBPriceForCbSip(targetAPrice int64, aRegion string, srcCurrency string, exchangeRate,
+	priceRatio, countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee float64) (pItemPrice int64, aPrice int64, ok bool) {
+	margin := pricekernel.CbSipFinalMargin(countryMargin, shopMargin, itemMargin)
+	if priceRatio*exchangeRate <= 0 || finalFee <= 0 {
+		return 0, 0, false
+	}
+
+	realPItemPrice := (ToRealPrice(targetAPrice)/(margin*finalFee) - hiddenPrice) / (priceRatio * exchangeRate)
+	if realPItemPrice <= 0 {
+		return 0, 0, false
+	}
+
+	step := MinDBPriceStepOfCurrency(srcCurrency)
+	pItemPrice = ToDBPrice(realPItemPrice) / step * step
+	if pItemPrice < step {
+		pItemPrice = step
+	}
+	aPrice = CalcAffiDBPriceForCbSip(pItemPrice, aRegion, exchangeRate, priceRatio, 1.0, countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee)
+	if aPrice < targetAPrice {
+		// float error of inverted price
+		pItemPrice += step
+		aPrice = CalcAffiDBPriceForCbSip(pItemPrice, aRegion, exchangeRate, priceRatio, 1.0, countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee)
+	}
+	return pItemPrice, aPrice, true
+}
+
+// CalcHiddenPriceByHiddenPriceConf hidden price of weight in gram by rate table row, weight is rounded up from start
+// weight on round size, then every weight step adds price. the row must have a positive HiddenPriceWeightUnits step
+func CalcHiddenPriceByHiddenPriceConf(weight float64, conf *model.HiddenPriceConf) float64 {
+	if weight <= 0 {
+		return 0
+	}
+
+	startPrice := ToRealPrice(conf.StartPrice)
+	price := ToRealPrice(conf.Price)
+	startWeight := DbWeightToGram(conf.StartWeight)
+	adjustment := ToRealPrice(conf.Adjustment)
+
+	roundSize, weightStep := HiddenPriceWeightUnits(conf.RoundSize, conf.WeightStep)
+	if conf.RoundSize > 0 {
+		pow := math.Pow10(int(conf.RoundSize))
+		startWeight *= pow
+		weight *= pow
+	}
+
+	res := startPrice + float64((RoundUp(int(startWeight), roundSize, weight)-int(startWeight))/weightStep)*price + adjustment
+	if res > 0 {
+		return res
+	} else {
+		return 0
+	}
 }
 
-func calFinalMargin(countryMargin float64, shopMargin float64, itemMargin float64) float64 {
-	margin := 1 + countryMargin + shopMargin + itemMargin
-	if margin <= 0 {
-		margin = 1
+// HiddenPriceWeightUnits round size and weight step of rate table row in the weight unit hidden price is calculated on.
+// round size <= 0 rounds weight in gram to 10^-roundSize, round size > 0 calculates weight in 10^-roundSize gram
+func HiddenPriceWeightUnits(roundSize int64, dbWeightStep int64) (int, int) {
+	weightStep := DbWeightToGram(dbWeightStep)
+	if roundSize <= 0 {
+		return int(math.Pow10(-int(roundSize))), int(weightStep)
 	}
-	return margin
+	return 1, int(weightStep * math.Pow10(int(roundSize)))
 }
@@ -0,0 +1,96 @@
+package calcutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/testconfig"
+)
+
+func TestCalcPItemDBPriceForCbSip(t *testing.T) {
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: testconfig.BuildSIPConfigFromLiveCfg(),
+	})
+
+	type factors struct {
+		exchangeRate, priceRatio, countryMargin, shopMargin, itemMargin, hiddenPrice, finalFee float64
+	}
+	tests := []struct {
+		name        string
+		aRegion     string
+		srcCurrency string
+		factors     factors
+		targetPrice int64
+		wantOk      bool
+		wantExact   bool
+	}{
+		{
+			name:        "target produced by forward calculation is matched exactly",
+			aRegion:     "MY",
+			srcCurrency: "CNY",
+			factors:     factors{0.65, 1, 0.1, 0.02, 0, 3.5, 1.08},
+			targetPrice: CalcAffiDBPriceForCbSip(12345000, "MY", 0.65, 1, 1, 0.1, 0.02, 0, 3.5, 1.08),
+			wantOk:      true,
+			wantExact:   true,
+		},
+		{
+			name:        "target skipped by special round up returns the next reachable price",
+			aRegion:     "MY",
+			srcCurrency: "CNY",
+			factors:     factors{0.65, 1, 0.1, 0.02, 0, 3.5, 1.08},
+			targetPrice: 12030000, // 120.3 is not an ending of special round up
+			wantOk:      true,
+			wantExact:   false,
+		},
+		{
+			name:        "target lower than hidden price is unreachable",
+			aRegion:     "TW",
+			srcCurrency: "CNY",
+			factors:     factors{4.3, 1, 0, 0, 0, 60, 1.1},
+			targetPrice: 5000000,
+			wantOk:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.factors
+			pItemPrice, aPrice, ok := CalcPItemDBPriceForCbSip(tt.targetPrice, tt.aRegion, tt.srcCurrency,
+				f.exchangeRate, f.priceRatio, f.countryMargin, f.shopMargin, f.itemMargin, f.hiddenPrice, f.finalFee)
+			assert.Equal(t, tt.wantOk, ok)
+			if !ok {
+				return
+			}
+
+			assert.Equal(t, tt.wantExact, aPrice == tt.targetPrice)
+			assert.GreaterOrEqual(t, aPrice, tt.targetPrice)
+			assert.Equal(t, aPrice, CalcAffiDBPriceForCbSip(pItemPrice, tt.aRegion,
+				f.exchangeRate, f.priceRatio, 1, f.countryMargin, f.shopMargin, f.itemMargin, f.hiddenPrice, f.finalFee))
+			if tt.wantExact {
+				// P price is the largest one producing the target
+				assert.Greater(t, CalcAffiDBPriceForCbSip(pItemPrice+1000, tt.aRegion,
+					f.exchangeRate, f.priceRatio, 1, f.countryMargin, f.shopMargin, f.itemMargin, f.hiddenPrice, f.finalFee), tt.targetPrice)
+			}
+		})
+	}
+}
+
+func TestCalcAffiDBPriceForCbSipDecimal(t *testing.T) {
+	sipCfg := testconfig.BuildSIPConfigFromLiveCfg()
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: sipCfg,
+	})
+
+	// 1.1 * 1.1 is 1.2100000000000002 on float64, ceil of PLN precision turns it into 1.22
+	assert.Equal(t, int64(122000), CalcAffiDBPriceForCbSip(110000, "PL", 1.1, 1, 1, 0, 0, 0, 0, 1))
+
+	sipCfg.DecimalPriceCalcRegions = []string{"PL"}
+	assert.Equal(t, int64(121000), CalcAffiDBPriceForCbSip(110000, "PL", 1.1, 1, 1, 0, 0, 0, 0, 1))
+
+	// special round up endings are kept
+	floatPrice := CalcAffiDBPriceForCbSip(12345000, "MY", 0.65, 1, 1, 0.1, 0.02, 0, 3.5, 1.08)
+	sipCfg.DecimalPriceCalcRegions = []string{"MY"}
+	assert.Equal(t, int64(10150000), floatPrice)
+	assert.Equal(t, floatPrice, CalcAffiDBPriceForCbSip(12345000, "MY", 0.65, 1, 1, 0.1, 0.02, 0, 3.5, 1.08))
+}
@@ -4,72 +4,88 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/shopspring/decimal"
-
 	"git.garena.com/shopee/core-server/core-logic/cutil"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
-	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/constant"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	internalMerchantConfigSettingPb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/internal_merchant_config_setting.pb"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
 // GetCBSCDenominatorPriceRate get cbsc denominator price rate,
 // = 1 - commissionRate - transactionFeeRate - ServiceFeeRate
 func GetCBSCDenominatorPriceRate(ctx context.Context, cbscPriceFeeConfig *config.CBSCPriceFeeConfig, merchantConfig *internalMerchantConfigSettingPb.MerchantConfigSetting, commissionRate uint64) float64 {
-	var result int64
-
-	if cbscPriceFeeConfig == nil || !cbscPriceFeeConfig.UseServiceFeeRate {
-		result = constant.PercentPrecisionBetweenMtskuAndMpsku
-	} else {
-		serviceFeeRate := uint64(0)
+	var useServiceFeeRate bool
+	var transactionFeeRate, serviceFeeRate uint64
+	if cbscPriceFeeConfig != nil && cbscPriceFeeConfig.UseServiceFeeRate {
+		useServiceFeeRate = true
+		transactionFeeRate = cbscPriceFeeConfig.TransactionFeeRate
 		if merchantConfig != nil && merchantConfig.ServiceFeeRate != nil {
 			serviceFeeRate = merchantConfig.GetServiceFeeRate()
 		}
-		result = int64(constant.PercentPrecisionBetweenMtskuAndMpsku - commissionRate - cbscPriceFeeConfig.TransactionFeeRate - serviceFeeRate)
-	}
-	if result <= 0 {
-		result = constant.PercentPrecisionBetweenMtskuAndMpsku
 	}
+	result := pricekernel.CbscDenominatorPriceRate(useServiceFeeRate, commissionRate, transactionFeeRate, serviceFeeRate)
 
 	logging.GetLogger(ctx).Info(fmt.Sprintf(
-		"calculation factor for inflated CBSCDenominatorPriceRate=%v. commissionRate=%v, cbscPriceFeeConfig=%v, merchantConfig=%v",
+		"calculation factor for CBSCDenominatorPriceRate=%v. commissionRate=%v, cbscPriceFeeConfig=%v, merchantConfig=%v",
 		result, commissionRate, cutil.JSONEncode(cbscPriceFeeConfig), cutil.JSONEncode(merchantConfig)))
 
-	return RoundIntToFloat(result, constant.PercentPrecisionBetweenMtskuAndMpsku, 4)
+	return result
 }
 
+const (
+	CbscMpskuPriceFormula = pricekernel.CbscMpskuPriceFormula
+	CbscMtskuPriceFormula = pricekernel.CbscMtskuPriceFormula
+)
+
 // CalculateMpskuPrice calculate mpsku price from mtsku price
 // MpskuPrice = ((MtskuPrice * exchangeRate * profitRate(marketAdjustmentRate)) + hiddenFee) / (1 - commissionRate - transactionFeeRate - ServiceFeeRate)
 func CalculateMpskuPrice(mtskuPrice float64, exchangeRate float64, profitRate float64, hidePrice float64, denominatorPriceRate float64) float64 {
-	decimalMtskuPrice := decimal.NewFromFloat(mtskuPrice)
-	decimalExchangeRate := decimal.NewFromFloat(exchangeRate)
-	decimalProfitRate := decimal.NewFromFloat(profitRate)
-	decimalHidePrice := decimal.NewFromFloat(hidePrice)
-	decimalDenominatorPriceRate := decimal.NewFromFloat(denominatorPriceRate)
-
-	decimalValue := decimalMtskuPrice.Mul(decimalExchangeRate).Mul(decimalProfitRate).Add(decimalHidePrice).Div(decimalDenominatorPriceRate)
-	result, _ := decimalValue.Float64()
+	result, _ := CalculateMpskuPriceWithTrace(mtskuPrice, exchangeRate, profitRate, hidePrice, denominatorPriceRate)
 	return result
 }
 
+// CalculateMpskuPriceWithTrace same as CalculateMpskuPrice, and also returns every term used in calculation.
+// the returned trace is not rounded yet.
+func CalculateMpskuPriceWithTrace(mtskuPrice float64, exchangeRate float64, profitRate float64, hidePrice float64, denominatorPriceRate float64) (float64, *model.PriceTrace) {
+	return pricekernel.CalcMpskuPrice(mtskuPrice, pricekernel.CbscFactors{
+		ExchangeRate:         exchangeRate,
+		ProfitRate:           profitRate,
+		HidePrice:            hidePrice,
+		DenominatorPriceRate: denominatorPriceRate,
+	})
+}
+
 // CalculateMtskuPrice calculate mtsku price from mpsku price
 // MpskuPrice = ((MtskuPrice * exchangeRate * profitRate(marketAdjustmentRate)) + hiddenFee) / (1 - commissionRate - transactionFeeRate - ServiceFeeRate)
 func CalculateMtskuPrice(mpskuPrice float64, exchangeRate float64, profitRate float64, hidePrice float64, denominatorPriceRate float64) float64 {
-	decimalMpskuPrice := decimal.NewFromFloat(mpskuPrice)
-	decimalExchangeRate := decimal.NewFromFloat(exchangeRate)
-	decimalProfitRate := decimal.NewFromFloat(profitRate)
-	decimalHidePrice := decimal.NewFromFloat(hidePrice)
-	decimalOtherRate := decimal.NewFromFloat(denominatorPriceRate)
+	result, _ := CalculateMtskuPriceWithTrace(mpskuPrice, exchangeRate, profitRate, hidePrice, denominatorPriceRate)
+	return result
+}
 
-	decimalValue := decimalMpskuPrice.Mul(decimalOtherRate).Sub(decimalHidePrice)
-	if decimalExchangeRate.IsZero() || decimalProfitRate.IsZero() {
-		return 0
+// CalculateMtskuPriceWithTrace same as CalculateMtskuPrice, and also returns every term used in calculation.
+// the returned trace is not rounded yet.
+func CalculateMtskuPriceWithTrace(mpskuPrice float64, exchangeRate float64, profitRate float64, hidePrice float64, denominatorPriceRate float64) (float64, *model.PriceTrace) {
+	return pricekernel.CalcMtskuPrice(mpskuPrice, pricekernel.CbscFactors{
+		ExchangeRate:         exchangeRate,
+		ProfitRate:           profitRate,
+		HidePrice:            hidePrice,
+		DenominatorPriceRate: denominatorPriceRate,
+	})
+}
+
+// NewCbscKernelFactors resolves the config dependent factors of CBSC price formula, the dst price is rounded to
+// the precision of mpsku region if isMtskuToMpsku, otherwise merchant currency
+func NewCbscKernelFactors(isMtskuToMpsku bool, mpskuRegion string, merchantCurrency string, priceFactors *model.CbscPriceFactors) pricekernel.CbscFactors {
+	roundPlace := config.GetPricePrecision(merchantCurrency)
+	if isMtskuToMpsku {
+		roundPlace = config.GetPricePrecision(mpskuRegion)
 	}
-	if decimalValue.IsNegative() {
-		return 0
+	return pricekernel.CbscFactors{
+		ExchangeRate:         priceFactors.ExchangeRate,
+		ProfitRate:           priceFactors.ProfitRate,
+		HidePrice:            priceFactors.HidePrice,
+		DenominatorPriceRate: priceFactors.CbscPriceRate,
+		RoundPlace:           roundPlace,
 	}
-
-	decimalValue = decimalValue.Div(decimalExchangeRate).Div(decimalProfitRate)
-	result, _ := decimalValue.Float64()
-	return result
 }
@@ -0,0 +1,16 @@
+package calcutil
+
+import (
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
+)
+
+// decimal arithmetic of the CB SIP and local SIP formulas and round up is enabled by config.UseDecimalPriceCalc,
+// the formulas themselves are in pricekernel.
+
+const ArithmeticDecimal = pricekernel.ArithmeticDecimal
+
+// PriceRoundUpDecimalWithRule same as PriceRoundUpWithRule on decimal arithmetic
+func PriceRoundUpDecimalWithRule(currencySetting config.CurrencyCommonSetting, price float64) (float64, string) {
+	return pricekernel.RoundUpDecimal(currencySetting.Precision, currencySetting.UseSpecialRoundup, price)
+}
@@ -1,19 +1,14 @@
 package calcutil
 
 import (
-	"math"
-
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/constant"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
 // copy from sip-goservice, need refactor later
 
 func ToRealPrice(input int64) float64 {
-	if input == -1 || input == 0 {
-		return float64(input)
-	}
-
-	return float64(input) / constant.PricePrecision
+	return pricekernel.ToRealPrice(input)
 }
 
 func ToRealPect(dbPect int) float64 {
@@ -25,10 +20,7 @@ func ToDBRatio(ratio float64) int32 {
 }
 
 func ToDBPrice(realPrice float64) int64 {
-	if realPrice == 0 || realPrice == -1 {
-		return int64(realPrice)
-	}
-	return int64(math.Round(realPrice * float64(constant.PricePrecision)))
+	return pricekernel.ToDBPrice(realPrice)
 }
 
 func DbWeightToGram(weightDb int64) float64 {
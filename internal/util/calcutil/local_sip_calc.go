@@ -4,73 +4,116 @@ import (
 	"context"
 	"fmt"
 
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
-func CalculateAffiPriceForLocalSip(ctx context.Context, pPrice float64, aRealWeight float64, itemMargin float64, shopMargin float64, initHiddenPrice float64, shippingFee float64, localPriceConfig *model.CommonPriceConfig, overseaDiscountRate *float64) float64 {
-	if localPriceConfig == nil {
-		return pPrice
-	}
+const LocalSipAffiPriceFormula = pricekernel.LocalSipAPriceFormula
 
-	if aRealWeight <= 0 {
-		return 0
-	}
-
-	itemMargin = GetItemMargin(itemMargin)
-
-	shopMargin = GetShopMargin(shopMargin)
+func CalculateAffiPriceForLocalSip(ctx context.Context, aRegion string, pPrice float64, aRealWeight float64, itemMargin float64, shopMargin float64, initHiddenPrice float64, shippingFee float64, localPriceConfig *model.CommonPriceConfig, overseaDiscountRate *float64) float64 {
+	aPrice, _ := CalculateAffiPriceForLocalSipWithTrace(ctx, aRegion, pPrice, aRealWeight, itemMargin, shopMargin, initHiddenPrice, shippingFee, localPriceConfig, overseaDiscountRate)
+	return aPrice
+}
 
-	countryMargin := GetLocalSipCountryMargin(localPriceConfig)
+// CalcAffiDBPriceForLocalSipWithTrace calculates the rounded A price in db value, and returns every term used in calculation
+func CalcAffiDBPriceForLocalSipWithTrace(ctx context.Context, aRegion string, pDBPrice int64, aRealWeight float64, itemMargin float64, shopMargin float64, initHiddenPrice float64, shippingFee float64, localPriceConfig *model.CommonPriceConfig) (int64, *model.PriceTrace) {
+	return pricekernel.CalcLocalSipAffiDBPrice(pDBPrice, NewLocalSipKernelFactors(aRegion, &model.LocalSipPriceFactors{
+		Weight:          aRealWeight,
+		ItemMargin:      itemMargin,
+		ShopMargin:      shopMargin,
+		InitHiddenPrice: initHiddenPrice,
+		ShippingFee:     shippingFee,
+		PriceConfig:     localPriceConfig,
+	}))
+}
 
-	exchangeRate := GetLocalSipExchangeRate(localPriceConfig)
+// NewLocalSipKernelFactors resolves the config dependent factors of local SIP A price formula for aRegion
+func NewLocalSipKernelFactors(aRegion string, priceFactors *model.LocalSipPriceFactors) pricekernel.LocalSipFactors {
+	return pricekernel.LocalSipFactors{
+		Weight:          priceFactors.Weight,
+		ItemMargin:      priceFactors.ItemMargin,
+		ShopMargin:      priceFactors.ShopMargin,
+		InitHiddenPrice: priceFactors.InitHiddenPrice,
+		ShippingFee:     priceFactors.ShippingFee,
+		PriceConfig:     toKernelLocalSipPriceConfig(priceFactors.PriceConfig),
+		Decimal:         config.UseDecimalPriceCalc(aRegion),
+		Rounding:        RegionRoundingSetting(aRegion),
+	}
+}
 
-	overseaRate := float64(0)
-	if overseaDiscountRate == nil || *overseaDiscountRate < 0 || *overseaDiscountRate > 1 {
-		overseaRate = 0
-	} else {
-		overseaRate = *overseaDiscountRate
+// CalcPDBPriceForLocalSip inverts CalcAffiDBPriceForLocalSipWithTrace.
+// it returns the largest P price on pCurrency precision whose A price before rounding doesn't exceed targetAPrice,
+// and the A price it produces. the A price is larger than targetAPrice when rounding up skips the target.
+// ok is false when no positive P price can reach targetAPrice.
+func CalcPDBPriceForLocalSip(ctx context.Context, targetAPrice int64, aRegion string, pCurrency string, aRealWeight float64, itemMargin float64, shopMargin float64, initHiddenPrice float64, shippingFee float64, localPriceConfig *model.CommonPriceConfig) (pPrice int64, aPrice int64, ok bool) {
+	if aRealWeight <= 0 {
+		return 0, 0, false
 	}
 
-	aPrice := (pPrice*(1-overseaRate)/exchangeRate + initHiddenPrice + shippingFee) * countryMargin * shopMargin * itemMargin
+	realPPrice := ToRealPrice(targetAPrice)
+	if localPriceConfig != nil {
+		margin := GetLocalSipCountryMargin(localPriceConfig) * GetShopMargin(shopMargin) * GetItemMargin(itemMargin)
+		realPPrice = (realPPrice/margin - initHiddenPrice - shippingFee) * GetLocalSipExchangeRate(localPriceConfig)
+	}
+	if realPPrice <= 0 {
+		return 0, 0, false
+	}
 
-	logging.GetLogger(ctx).Info(fmt.Sprintf("%v = (%v * (1 - %v) / %v + %v + %v) * %v * %v * %v",
-		aPrice, pPrice, overseaRate, exchangeRate, initHiddenPrice, shippingFee, countryMargin, shopMargin, itemMargin))
+	step := MinDBPriceStepOfCurrency(pCurrency)
+	pPrice = ToDBPrice(realPPrice) / step * step
+	if pPrice < step {
+		pPrice = step
+	}
+	aPrice, _ = CalcAffiDBPriceForLocalSipWithTrace(ctx, aRegion, pPrice, aRealWeight, itemMargin, shopMargin, initHiddenPrice, shippingFee, localPriceConfig)
+	if aPrice < targetAPrice {
+		// float error of inverted price
+		pPrice += step
+		aPrice, _ = CalcAffiDBPriceForLocalSipWithTrace(ctx, aRegion, pPrice, aRealWeight, itemMargin, shopMargin, initHiddenPrice, shippingFee, localPriceConfig)
+	}
+	return pPrice, aPrice, true
+}
 
-	return aPrice
+// CalculateAffiPriceForLocalSipWithTrace same as CalculateAffiPriceForLocalSip, and also returns every term used in calculation.
+// the returned trace is not rounded yet.
+func CalculateAffiPriceForLocalSipWithTrace(ctx context.Context, aRegion string, pPrice float64, aRealWeight float64, itemMargin float64, shopMargin float64, initHiddenPrice float64, shippingFee float64, localPriceConfig *model.CommonPriceConfig, overseaDiscountRate *float64) (float64, *model.PriceTrace) {
+	aPrice, trace := pricekernel.CalcLocalSipAffiPrice(pPrice, overseaDiscountRate, pricekernel.LocalSipFactors{
+		Weight:          aRealWeight,
+		ItemMargin:      itemMargin,
+		ShopMargin:      shopMargin,
+		InitHiddenPrice: initHiddenPrice,
+		ShippingFee:     shippingFee,
+		PriceConfig:     toKernelLocalSipPriceConfig(localPriceConfig),
+		Decimal:         config.UseDecimalPriceCalc(aRegion),
+	})
+
+	logging.GetLogger(ctx).Info(fmt.Sprintf("%v = %v, terms=%+v", aPrice, trace.Formula, trace.Terms))
+	return aPrice, trace
 }
 
 func GetItemMargin(itemMargin float64) float64 {
-	if itemMargin <= 0 {
-		itemMargin = 1
-	}
-	return itemMargin
+	return pricekernel.MarginOrOne(itemMargin)
 }
 
 func GetShopMargin(shopMargin float64) float64 {
-	if shopMargin <= 0 {
-		shopMargin = 1
-	}
-	return shopMargin
+	return pricekernel.MarginOrOne(shopMargin)
 }
 
 func GetLocalSipCountryMargin(localPriceConfig *model.CommonPriceConfig) float64 {
-	var countryMargin float64
-	if localPriceConfig.Buffer == nil || *localPriceConfig.Buffer <= 0 {
-		countryMargin = 1
-	} else {
-		countryMargin = *localPriceConfig.Buffer
-	}
-
-	return countryMargin
+	return pricekernel.LocalSipCountryMargin(toKernelLocalSipPriceConfig(localPriceConfig))
 }
 
 func GetLocalSipExchangeRate(localPriceConfig *model.CommonPriceConfig) float64 {
-	var exchangeRate float64
-	if localPriceConfig.ExchangeRate == nil || *localPriceConfig.ExchangeRate == 0 {
-		exchangeRate = 1
-	} else {
-		exchangeRate = *localPriceConfig.ExchangeRate
+	return pricekernel.LocalSipExchangeRate(toKernelLocalSipPriceConfig(localPriceConfig))
+}
+
+func toKernelLocalSipPriceConfig(localPriceConfig *model.CommonPriceConfig) *pricekernel.LocalSipPriceConfig {
+	if localPriceConfig == nil {
+		return nil
+	}
+	return &pricekernel.LocalSipPriceConfig{
+		Buffer:       localPriceConfig.Buffer,
+		ExchangeRate: localPriceConfig.ExchangeRate,
 	}
-	return exchangeRate
 }
@@ -0,0 +1,71 @@
+package calcutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/testconfig"
+)
+
+func TestCalcPDBPriceForLocalSip(t *testing.T) {
+	ctx := context.Background()
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: testconfig.BuildSIPConfigFromLiveCfg(),
+	})
+
+	buffer, exchangeRate := 1.1, 7.0
+	priceCfg := &model.CommonPriceConfig{
+		Buffer:       &buffer,
+		ExchangeRate: &exchangeRate,
+	}
+
+	tests := []struct {
+		name        string
+		targetPrice int64
+		weight      float64
+		wantPPrice  int64
+		wantAPrice  int64
+		wantOk      bool
+	}{
+		{
+			name:        "exact match",
+			targetPrice: 8650000,
+			weight:      100,
+			wantPPrice:  50100000,
+			wantAPrice:  8650000,
+			wantOk:      true,
+		},
+		{
+			name:        "target skipped by special round up",
+			targetPrice: 8660000,
+			weight:      100,
+			wantPPrice:  50200000,
+			wantAPrice:  8690000,
+			wantOk:      true,
+		},
+		{
+			name:        "target lower than fees",
+			targetPrice: 500000,
+			weight:      100,
+			wantOk:      false,
+		},
+		{
+			name:        "zero weight",
+			targetPrice: 8650000,
+			weight:      0,
+			wantOk:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pPrice, aPrice, ok := CalcPDBPriceForLocalSip(ctx, tt.targetPrice, "MY", "TWD", tt.weight, 1, 1, 2, 5, priceCfg)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantPPrice, pPrice)
+			assert.Equal(t, tt.wantAPrice, aPrice)
+		})
+	}
+}
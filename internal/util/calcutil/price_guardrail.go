@@ -0,0 +1,75 @@
+package calcutil
+
+import (
+	"context"
+	"fmt"
+
+	"git.garena.com/shopee/platform/service-governance/observability/metric"
+
+	"git.garena.com/shopee/core-server/core-logic/cerr"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/model"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+const priceGuardrailMetricName = "price_guardrail_outlier"
+
+// CheckPriceGuardrail checks the calculated price of region against the guardrail config, outliers are logged and reported.
+// referencePrice is the source price converted at exchange rate, prices are real values in destination currency
+func CheckPriceGuardrail(ctx context.Context, region string, priceName string, referencePrice float64, price float64) model.PriceGuardrailResult {
+	setting, ok := config.GetPriceGuardrailSetting(region)
+	if !ok {
+		return model.PriceGuardrailResult{}
+	}
+
+	result := checkPriceGuardrailBySetting(setting, referencePrice, price)
+	if result.Status == pb.Constant_PRICE_GUARDRAIL_PASSED {
+		return result
+	}
+	result.Detail = fmt.Sprintf("%s %s", priceName, result.Detail)
+
+	logging.GetLogger(ctx).Error(fmt.Sprintf("[Price Guardrail] price is outlier, region=%v, status=%v, reason=%v, detail=%v",
+		region, result.Status, result.Reason, result.Detail))
+	_ = metric.RPCCustomReporter.ReportCounter(priceGuardrailMetricName, map[string]string{
+		"region": region,
+		"status": result.Status.String(),
+		"reason": result.Reason.String(),
+	}, 1)
+	return result
+}
+
+// checkPriceGuardrailBySetting outliers are flagged unless mode is reject
+func checkPriceGuardrailBySetting(setting config.PriceGuardrailSetting, referencePrice float64, price float64) model.PriceGuardrailResult {
+	status := pb.Constant_PRICE_GUARDRAIL_FLAGGED
+	if setting.Mode == config.PriceGuardrailModeReject {
+		status = pb.Constant_PRICE_GUARDRAIL_REJECTED
+	}
+
+	var reason pb.Constant_PriceGuardrailReason
+	var bound float64
+	switch {
+	case setting.MinPrice > 0 && price < setting.MinPrice:
+		reason, bound = pb.Constant_PRICE_GUARDRAIL_BELOW_MIN_PRICE, setting.MinPrice
+	case setting.MaxPrice > 0 && price > setting.MaxPrice:
+		reason, bound = pb.Constant_PRICE_GUARDRAIL_ABOVE_MAX_PRICE, setting.MaxPrice
+	case referencePrice > 0 && setting.MinMultiplier > 0 && price < referencePrice*setting.MinMultiplier:
+		reason, bound = pb.Constant_PRICE_GUARDRAIL_BELOW_MIN_MULTIPLIER, referencePrice*setting.MinMultiplier
+	case referencePrice > 0 && setting.MaxMultiplier > 0 && price > referencePrice*setting.MaxMultiplier:
+		reason, bound = pb.Constant_PRICE_GUARDRAIL_ABOVE_MAX_MULTIPLIER, referencePrice*setting.MaxMultiplier
+	default:
+		return model.PriceGuardrailResult{}
+	}
+
+	return model.PriceGuardrailResult{
+		Status: status,
+		Reason: reason,
+		Detail: fmt.Sprintf("price=%v, referencePrice=%v, bound=%v", price, referencePrice, bound),
+	}
+}
+
+// NewPriceGuardrailRejectedError error of the query whose price is rejected by guardrail
+func NewPriceGuardrailRejectedError(region string, guardrail model.PriceGuardrailResult) error {
+	return cerr.New(fmt.Sprintf("price is rejected by guardrail of region=%v, reason=%v, %v", region, guardrail.Reason, guardrail.Detail),
+		uint32(pb.Constant_ERROR_PRICE_GUARDRAIL_REJECTED))
+}
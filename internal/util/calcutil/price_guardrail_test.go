@@ -0,0 +1,55 @@
+package calcutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	pb "git.garena.com/shopee/core-server/price/price-sync-calculation/internal/proto/spex/gen/go/price_sync_price_calculation.pb"
+)
+
+func TestCheckPriceGuardrailBySetting(t *testing.T) {
+	flag := config.PriceGuardrailSetting{Mode: config.PriceGuardrailModeFlag, MinMultiplier: 0.5, MaxMultiplier: 3, MinPrice: 1, MaxPrice: 1000}
+	reject := flag
+	reject.Mode = config.PriceGuardrailModeReject
+	unknown := flag
+	unknown.Mode = "Reject"
+
+	tests := []struct {
+		name           string
+		setting        config.PriceGuardrailSetting
+		referencePrice float64
+		price          float64
+		wantStatus     pb.Constant_PriceGuardrailStatus
+		wantReason     pb.Constant_PriceGuardrailReason
+	}{
+		{"passed", flag, 100, 150, pb.Constant_PRICE_GUARDRAIL_PASSED, pb.Constant_PRICE_GUARDRAIL_REASON_NONE},
+		{"below min price", flag, 1, 0.9, pb.Constant_PRICE_GUARDRAIL_FLAGGED, pb.Constant_PRICE_GUARDRAIL_BELOW_MIN_PRICE},
+		{"above max price", flag, 500, 1001, pb.Constant_PRICE_GUARDRAIL_FLAGGED, pb.Constant_PRICE_GUARDRAIL_ABOVE_MAX_PRICE},
+		{"below min multiplier", flag, 100, 49, pb.Constant_PRICE_GUARDRAIL_FLAGGED, pb.Constant_PRICE_GUARDRAIL_BELOW_MIN_MULTIPLIER},
+		{"above max multiplier", reject, 100, 301, pb.Constant_PRICE_GUARDRAIL_REJECTED, pb.Constant_PRICE_GUARDRAIL_ABOVE_MAX_MULTIPLIER},
+		{"multipliers skipped without reference price", reject, 0, 500, pb.Constant_PRICE_GUARDRAIL_PASSED, pb.Constant_PRICE_GUARDRAIL_REASON_NONE},
+		{"unknown mode is flagged", unknown, 100, 301, pb.Constant_PRICE_GUARDRAIL_FLAGGED, pb.Constant_PRICE_GUARDRAIL_ABOVE_MAX_MULTIPLIER},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkPriceGuardrailBySetting(tt.setting, tt.referencePrice, tt.price)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantReason, got.Reason)
+		})
+	}
+}
+
+func TestCheckPriceGuardrail(t *testing.T) {
+	config.SetGlobal(&config.Config{SIPMigrationCfg: &config.SIPMigrationConfig{
+		PriceGuardrailConfig: map[string]config.PriceGuardrailSetting{
+			"SG": {Mode: "rejected", MaxPrice: 100},
+		},
+	}})
+	setting, ok := config.GetPriceGuardrailSetting("sg")
+	assert.True(t, ok)
+	assert.Equal(t, config.PriceGuardrailModeFlag, setting.Mode)
+	assert.Equal(t, pb.Constant_PRICE_GUARDRAIL_FLAGGED, CheckPriceGuardrail(context.Background(), "SG", "normal_price", 0, 1000).Status)
+}
@@ -4,24 +4,37 @@ import (
 	"fmt"
 	"math"
 
-	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/constant"
-
 	"github.com/shopspring/decimal"
 
 	"git.garena.com/shopee/common/ulog"
 	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
-	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/maths"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
 )
 
 // copy from sip-goservice, need refactor later
 
 func GetMiniPriceValueBasedOnCurrency(roundPlace int32) int64 {
-	return int64(math.Pow10(-1*int(roundPlace)) * constant.PricePrecision)
+	return pricekernel.MiniPriceOfRoundPlace(roundPlace)
 }
 
 func DBPriceRoundNearest(currency string, dbPrice int64) int64 {
-	realPrice := ToRealPrice(dbPrice)
-	return ToDBPrice(PriceRoundNearest(currency, realPrice))
+	result, _ := DBPriceRoundNearestWithRule(currency, dbPrice)
+	return result
+}
+
+// DBPriceRoundNearestWithRule same as DBPriceRoundNearest, and also returns the rounding rule applied
+func DBPriceRoundNearestWithRule(currency string, dbPrice int64) (int64, string) {
+	currencySetting := config.GetSIPCurrencyCommonConf().GetByCurrency(currency)
+	return pricekernel.RoundNearestDBPrice(currencySetting.Precision, dbPrice)
+}
+
+// MinDBPriceStepOfCurrency the smallest price step in db value under the precision of currency
+func MinDBPriceStepOfCurrency(currency string) int64 {
+	step := ToDBPrice(math.Pow10(config.GetSIPCurrencyCommonConf().GetByCurrency(currency).Precision))
+	if step <= 0 {
+		step = 1
+	}
+	return step
 }
 
 func PriceRoundNearest(currency string, price float64) float64 {
@@ -30,10 +43,7 @@ func PriceRoundNearest(currency string, price float64) float64 {
 }
 
 func RoundWithPrecision(value float64, decimalPlaces int) float64 {
-	if decimalPlaces >= 0 {
-		return float64(int(math.Round(value/(math.Pow10(decimalPlaces))) * (math.Pow10(decimalPlaces))))
-	}
-	return math.Round(value*(math.Pow10(maths.IntAbs(decimalPlaces)))) / (math.Pow10(maths.IntAbs(decimalPlaces)))
+	return pricekernel.RoundWithPrecision(value, decimalPlaces)
 }
 
 func RoundUintToFloat(input uint64, precision int32, roundPlace int32) float64 {
@@ -45,18 +55,11 @@ func RoundUintToFloat(input uint64, precision int32, roundPlace int32) float64 {
 }
 
 func RoundFloatToInt(input float64, precision int32, roundPlace int32) int64 {
-	inputNum := decimal.NewFromFloat(input).Round(roundPlace)
-	precisionNum := decimal.NewFromFloat(float64(precision))
-	decimalValue := inputNum.Mul(precisionNum)
-	return decimalValue.Round(roundPlace).IntPart()
+	return pricekernel.RoundFloatToInt(input, precision, roundPlace)
 }
 
 func RoundIntToFloat(input int64, precision int32, roundPlace int32) float64 {
-	inputNum := decimal.NewFromFloat(float64(input))
-	precisionNum := decimal.NewFromFloat(float64(precision))
-	decimalValue := inputNum.Div(precisionNum)
-	result, _ := decimalValue.Round(roundPlace).Float64()
-	return result
+	return pricekernel.RoundIntToFloat(input, precision, roundPlace)
 }
 
 func RoundUp(minValue int, roundSize int, actualValue float64) int {
@@ -72,51 +75,64 @@ func RoundUp(minValue int, roundSize int, actualValue float64) int {
 	}
 }
 
+const (
+	RoundingRuleNone           = pricekernel.RoundingRuleNone
+	RoundingRuleCeil           = pricekernel.RoundingRuleCeil
+	RoundingRuleSpecialRoundUp = pricekernel.RoundingRuleSpecialRoundUp
+	RoundingRuleNearest        = pricekernel.RoundingRuleNearest
+)
+
 func PriceRoundUpByCountry(country string, price float64) float64 {
-	currency := config.GetSIPRegionCommonConf().GetByRegion(country).Currency
+	result, _ := PriceRoundUpByCountryWithRule(country, price)
+	return result
+}
+
+// PriceRoundUpByCountryWithRule same as PriceRoundUpByCountry, and also returns the rounding rule applied
+func PriceRoundUpByCountryWithRule(country string, price float64) (float64, string) {
+	result, rule := pricekernel.RoundPrice(RegionRoundingSetting(country), price)
+	ulog.DefaultLogger().Info(fmt.Sprintf("PriceRoundUpByCountry: %f => %f", price, result),
+		ulog.String("region", country), ulog.String("rule", rule))
+
+	return result, rule
+}
+
+// RegionRoundingSetting resolves the rounding of region from config. the rounding strategy configured for region or
+// currency is used first, and falls back to special round up or ceil decided by currency setting
+func RegionRoundingSetting(region string) pricekernel.RoundingSetting {
+	currency := config.GetSIPRegionCommonConf().GetByRegion(region).Currency
 	if len(currency) == 0 {
-		ulog.DefaultLogger().Error("currency for region not exist", ulog.String("region", country))
-		return price
+		ulog.DefaultLogger().Error("currency for region not exist", ulog.String("region", region))
+		return pricekernel.RoundingSetting{Disabled: true}
 	}
 	currencySetting := config.GetSIPCurrencyCommonConf().GetByCurrency(currency)
 
-	result := PriceRoundUp(currencySetting, price)
-	ulog.DefaultLogger().Info(fmt.Sprintf("PriceRoundUpByCountry: %f => %f", price, result),
-		ulog.String("region", country), ulog.String("currency", currency))
-
-	return result
+	setting := pricekernel.RoundingSetting{
+		Precision:         currencySetting.Precision,
+		UseSpecialRoundUp: currencySetting.UseSpecialRoundup,
+		Decimal:           config.UseDecimalPriceCalc(region),
+	}
+	if strategy, ok := config.GetRoundingStrategyConfig(region, currency); ok {
+		if pricekernel.IsRoundingStrategyRegistered(strategy.Name) {
+			kernelStrategy := toKernelRoundingStrategy(strategy)
+			setting.Strategy = &kernelStrategy
+		} else {
+			ulog.DefaultLogger().Error("rounding strategy not registered", ulog.String("strategy", strategy.Name))
+		}
+	}
+	return setting
 }
 
 func PriceRoundUp(currencySetting config.CurrencyCommonSetting, price float64) float64 {
-	if currencySetting.UseSpecialRoundup {
-		return SpecialPriceRoundUp(currencySetting, price)
-	}
-	return ceilWithPrecision(price, currencySetting.Precision)
+	result, _ := PriceRoundUpWithRule(currencySetting, price)
+	return result
 }
 
-func ceilWithPrecision(value float64, decimalPlaces int) float64 {
-	if decimalPlaces >= 0 {
-		return float64(int(math.Ceil(value/(math.Pow10(decimalPlaces))) * (math.Pow10(decimalPlaces))))
-	}
-	return math.Ceil(value*(math.Pow10(maths.IntAbs(decimalPlaces)))) / (math.Pow10(maths.IntAbs(decimalPlaces)))
+func PriceRoundUpWithRule(currencySetting config.CurrencyCommonSetting, price float64) (float64, string) {
+	return pricekernel.RoundUp(currencySetting.Precision, currencySetting.UseSpecialRoundup, price)
 }
 
 func SpecialPriceRoundUp(currencySetting config.CurrencyCommonSetting, price float64) float64 {
-	decimalPlaces := currencySetting.Precision
-	epsilon := math.Pow10(decimalPlaces - 2)
-	priceWithoutDecimalPoint := math.Floor(price)
-	priceDecimalPart := price - priceWithoutDecimalPoint
-	if priceDecimalPart < epsilon {
-		return priceWithoutDecimalPoint
-	} else if priceDecimalPart < 0.5+epsilon {
-		return priceWithoutDecimalPoint + 0.5
-	} else if priceDecimalPart < 0.9+epsilon {
-		return priceWithoutDecimalPoint + 0.9
-	} else if priceDecimalPart < 0.99+epsilon {
-		return priceWithoutDecimalPoint + 0.99
-	} else {
-		return priceWithoutDecimalPoint + 1
-	}
+	return pricekernel.SpecialRoundUp(currencySetting.Precision, price)
 }
 
 type cmpResult int
@@ -0,0 +1,42 @@
+package calcutil
+
+import (
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/pkg/pricekernel"
+)
+
+const (
+	RoundingRuleFloor         = pricekernel.RoundingRuleFloor
+	RoundingRulePsychological = pricekernel.RoundingRulePsychological
+	RoundingRuleRoundToN      = pricekernel.RoundingRuleRoundToN
+)
+
+// RoundingFunc rounds price by the strategy config on decimal arithmetic, and returns the rounding rule applied.
+// precision is the one of strategy config if set, otherwise the currency precision
+type RoundingFunc = pricekernel.RoundingFunc
+
+// RegisterRoundingStrategy adds or replaces a rounding strategy which can be configured in SIPMigrationConfig by name,
+// it is not concurrency safe and should only be called on init
+func RegisterRoundingStrategy(name string, fn RoundingFunc) {
+	pricekernel.RegisterRoundingStrategy(name, fn)
+}
+
+// PriceRoundWithStrategy rounds price by the configured strategy, ok is false if the strategy is not registered
+func PriceRoundWithStrategy(strategy config.RoundingStrategyConfig, currencySetting config.CurrencyCommonSetting, price float64) (result float64, rule string, ok bool) {
+	result, rule, ok = pricekernel.RoundWithStrategy(toKernelRoundingStrategy(strategy), currencySetting.Precision, price)
+	if !ok {
+		ulog.DefaultLogger().Error("rounding strategy not registered", ulog.String("strategy", strategy.Name))
+	}
+	return result, rule, ok
+}
+
+func toKernelRoundingStrategy(strategy config.RoundingStrategyConfig) pricekernel.RoundingStrategy {
+	return pricekernel.RoundingStrategy{
+		Name:      strategy.Name,
+		Precision: strategy.Precision,
+		Unit:      strategy.Unit,
+		Endings:   strategy.Endings,
+		N:         strategy.N,
+	}
+}
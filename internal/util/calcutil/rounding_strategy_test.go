@@ -0,0 +1,45 @@
+package calcutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+)
+
+func TestPriceRoundWithStrategy(t *testing.T) {
+	currencySetting := config.CurrencyCommonSetting{Precision: -2}
+	ladder := config.RoundingStrategyConfig{Name: RoundingRulePsychological, Endings: []float64{0.5, 0.9, 0.99}}
+	thousandLadder := config.RoundingStrategyConfig{Name: RoundingRulePsychological, Unit: 1000, Endings: []float64{500, 900}}
+	roundTo1000 := config.RoundingStrategyConfig{Name: RoundingRuleRoundToN, N: 1000}
+
+	tests := []struct {
+		name     string
+		strategy config.RoundingStrategyConfig
+		price    float64
+		want     float64
+		wantOk   bool
+	}{
+		{"ceil", config.RoundingStrategyConfig{Name: RoundingRuleCeil}, 10.011, 10.02, true},
+		{"floor", config.RoundingStrategyConfig{Name: RoundingRuleFloor}, 10.019, 10.01, true},
+		{"nearest half up", config.RoundingStrategyConfig{Name: RoundingRuleNearest}, 10.015, 10.02, true},
+		{"special round up", config.RoundingStrategyConfig{Name: RoundingRuleSpecialRoundUp}, 10.6, 10.9, true},
+		{"psychological integer price is kept", ladder, 10, 10, true},
+		{"psychological to first ending", ladder, 10.3, 10.5, true},
+		{"psychological to last ending", ladder, 10.95, 10.99, true},
+		{"psychological to next unit", ladder, 10.995, 11, true},
+		{"psychological with unit", thousandLadder, 12345, 12500, true},
+		{"psychological with unit to next unit", thousandLadder, 12950, 13000, true},
+		{"round to n down", roundTo1000, 12499, 12000, true},
+		{"round to n up", roundTo1000, 12500, 13000, true},
+		{"unknown strategy", config.RoundingStrategyConfig{Name: "unknown"}, 10.011, 10.011, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, ok := PriceRoundWithStrategy(tt.strategy, currencySetting, tt.price)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package logicutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.garena.com/shopee/platform/service-governance/observability/metric"
+
+	"git.garena.com/shopee/common/ulog"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/util/logging"
+)
+
+// formula names used in FormulaVersionConfig
+const (
+	FormulaCbSip          = "cb_sip"
+	FormulaLocalSip       = "local_sip"
+	FormulaCbsc           = "cbsc"
+	FormulaCbSipItemPrice = "cb_sip_item_price"
+)
+
+// FormulaVersionV1 the formulas before versioning was introduced, used when no version is configured
+const FormulaVersionV1 = "v1"
+
+const formulaVersionUnknownMetricName = "formula_version_unknown"
+
+const (
+	formulaShadowMetricName = "formula_shadow_compare"
+	formulaShadowMatch      = "match"
+	formulaShadowDiff       = "diff"
+)
+
+// FormulaVersionTarget region and shop a formula version is resolved for
+type FormulaVersionTarget struct {
+	Region string
+	ShopId uint64
+}
+
+// FormulaShadowResult comparison of one query between the live version and the candidate version
+type FormulaShadowResult struct {
+	Region           string
+	LiveVersion      string
+	CandidateVersion string
+	Diff             string // empty if results are same
+}
+
+// ResolveFormulaVersion returns the live version of formula configured for shop, then region, then formula default, v1 if
+// none or the configured version is not implemented
+func ResolveFormulaVersion(formula string, target FormulaVersionTarget) string {
+	version := resolveConfiguredFormulaVersion(formula, target)
+	if !config.IsFormulaVersionRegistered(formula, version) {
+		reportUnknownFormulaVersion(formula, version)
+		return FormulaVersionV1
+	}
+	return version
+}
+
+func resolveConfiguredFormulaVersion(formula string, target FormulaVersionTarget) string {
+	setting, ok := config.GetFormulaVersionSetting(formula)
+	if !ok {
+		return FormulaVersionV1
+	}
+	if version, ok := setting.ShopVersions[target.ShopId]; ok && len(version) > 0 {
+		return version
+	}
+	if version, ok := setting.RegionVersions[strings.ToUpper(target.Region)]; ok && len(version) > 0 {
+		return version
+	}
+	if len(setting.DefaultVersion) > 0 {
+		return setting.DefaultVersion
+	}
+	return FormulaVersionV1
+}
+
+// ResolveFormulaVersions returns the live version and candidate version of each target. candidate is same as live
+// if shadow is disabled for the target, hasShadow is true if any target has a different candidate version
+func ResolveFormulaVersions(formula string, targets []FormulaVersionTarget) (versions []string, candidateVersions []string, hasShadow bool) {
+	setting, _ := config.GetFormulaVersionSetting(formula)
+	versions = make([]string, len(targets))
+	candidateVersions = make([]string, len(targets))
+	for i, target := range targets {
+		versions[i] = ResolveFormulaVersion(formula, target)
+		candidateVersions[i] = versions[i]
+		if len(setting.ShadowVersion) == 0 || !isShadowRegion(setting, target.Region) {
+			continue
+		}
+		if !config.IsFormulaVersionRegistered(formula, setting.ShadowVersion) {
+			reportUnknownFormulaVersion(formula, setting.ShadowVersion)
+			continue
+		}
+		if setting.ShadowVersion != versions[i] {
+			candidateVersions[i] = setting.ShadowVersion
+			hasShadow = true
+		}
+	}
+	return versions, candidateVersions, hasShadow
+}
+
+// ReplayFormulaVersions the version recorded in a factor snap for each of n queries, snaps before versioning are v1
+func ReplayFormulaVersions(snapVersion string, n int) []string {
+	if len(snapVersion) == 0 {
+		snapVersion = FormulaVersionV1
+	}
+	versions := make([]string, n)
+	for i := range versions {
+		versions[i] = snapVersion
+	}
+	return versions
+}
+
+func reportUnknownFormulaVersion(formula string, version string) {
+	logging.GetLogger(context.Background()).Error(fmt.Sprintf("[Formula Version] version is not implemented, v1 is used instead, formula=%v, version=%v",
+		formula, version))
+	_ = metric.RPCCustomReporter.ReportCounter(formulaVersionUnknownMetricName, map[string]string{
+		"formula": formula,
+		"version": version,
+	}, 1)
+}
+
+func isShadowRegion(setting config.FormulaVersionSetting, region string) bool {
+	if len(setting.ShadowRegions) == 0 {
+		return true
+	}
+	for _, r := range setting.ShadowRegions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunFormulaShadow calls compare in background with a context detached from the request, so the response is never
+// delayed or failed by the candidate version. results are reported by formula, region and versions, diffs are logged
+func RunFormulaShadow(ctx context.Context, formula string, compare func(ctx context.Context) []FormulaShadowResult) {
+	shadowCtx := detachedContext{Context: ctx}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.GetLogger(shadowCtx).Error(fmt.Sprintf("[Formula Shadow] panic in shadow calculation, formula=%v, panic=%v", formula, r))
+			}
+		}()
+
+		for _, result := range compare(shadowCtx) {
+			if result.LiveVersion == result.CandidateVersion {
+				continue
+			}
+			outcome := formulaShadowMatch
+			if len(result.Diff) > 0 {
+				outcome = formulaShadowDiff
+				logging.GetLogger(shadowCtx).Warn(fmt.Sprintf("[Formula Shadow] candidate version result differs, formula=%v", formula),
+					ulog.String("region", result.Region), ulog.String("liveVersion", result.LiveVersion),
+					ulog.String("candidateVersion", result.CandidateVersion), ulog.String("diff", result.Diff))
+			}
+			_ = metric.RPCCustomReporter.ReportCounter(formulaShadowMetricName, map[string]string{
+				"formula":           formula,
+				"region":            result.Region,
+				"live_version":      result.LiveVersion,
+				"candidate_version": result.CandidateVersion,
+				"result":            outcome,
+			}, 1)
+		}
+	}()
+}
+
+// detachedContext keeps the values of request context, e.g. logger, but is never cancelled with the request
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package logicutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"git.garena.com/shopee/core-server/price/price-sync-calculation/internal/config"
+)
+
+func TestResolveFormulaVersions(t *testing.T) {
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: &config.SIPMigrationConfig{
+			FormulaVersionConfig: map[string]config.FormulaVersionSetting{
+				FormulaCbSip: {
+					RegionVersions: map[string]string{"VN": "v2"},
+					ShopVersions:   map[uint64]string{100: "v3"},
+					ShadowVersion:  "v2",
+					ShadowRegions:  []string{"MY"},
+				},
+			},
+		},
+	})
+
+	targets := []FormulaVersionTarget{
+		{Region: "MY", ShopId: 1},   // default, shadow enabled
+		{Region: "vn", ShopId: 2},   // region version, same as shadow version
+		{Region: "VN", ShopId: 100}, // shop version, shadow disabled for region
+		{Region: "SG", ShopId: 3},   // default, shadow disabled for region
+	}
+	versions, candidateVersions, hasShadow := ResolveFormulaVersions(FormulaCbSip, targets)
+	assert.Equal(t, []string{FormulaVersionV1, "v2", "v3", FormulaVersionV1}, versions)
+	assert.Equal(t, []string{"v2", "v2", "v3", FormulaVersionV1}, candidateVersions)
+	assert.True(t, hasShadow)
+
+	versions, candidateVersions, hasShadow = ResolveFormulaVersions(FormulaLocalSip, targets)
+	assert.Equal(t, []string{FormulaVersionV1, FormulaVersionV1, FormulaVersionV1, FormulaVersionV1}, versions)
+	assert.Equal(t, versions, candidateVersions)
+	assert.False(t, hasShadow)
+}
+
+func TestResolveFormulaVersionsNotImplemented(t *testing.T) {
+	formula := "test_formula"
+	config.RegisterFormulaVersion(formula, FormulaVersionV1)
+	config.RegisterFormulaVersion(formula, "v2")
+	config.SetGlobal(&config.Config{
+		SIPMigrationCfg: &config.SIPMigrationConfig{
+			FormulaVersionConfig: map[string]config.FormulaVersionSetting{
+				formula: {
+					RegionVersions: map[string]string{"MY": "v3", "VN": "v2"},
+					ShadowVersion:  "v4",
+				},
+			},
+		},
+	})
+
+	// versions which are not implemented fall back to v1, and are never shadowed
+	versions, candidateVersions, hasShadow := ResolveFormulaVersions(formula, []FormulaVersionTarget{{Region: "MY"}, {Region: "VN"}})
+	assert.Equal(t, []string{FormulaVersionV1, "v2"}, versions)
+	assert.Equal(t, versions, candidateVersions)
+	assert.False(t, hasShadow)
+}
@@ -9,3 +9,16 @@ func ContainsUint32(slice []uint32, target uint32) bool {
 
 	return false
 }
+
+func EqualInt64(a []int64, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,246 @@
+package pricekernel
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CbSipAPriceFormula          = "(p_item_price * price_ratio * exchange_rate + hidden_price) * promotion_ratio * final_margin * final_fee"
+	CbSipSettlementPriceFormula = "p_item_price * price_ratio"
+	CbSipItemPriceFormula       = "(a_price * (1 - reference_service_fee - transaction_fee - commission_fee) - hidden_price) / exchange_rate"
+)
+
+var (
+	// ErrInvalidSourcePrice the source price of formula is not positive
+	ErrInvalidSourcePrice = errors.New("invalid source price")
+	// ErrInvalidResultPrice the calculated price is negative
+	ErrInvalidResultPrice = errors.New("invalid result price")
+	// ErrInvalidExchangeRate the exchange rate a price is divided by is not positive
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+)
+
+// CbSipFactors all factors of CB SIP A price formula, fees are real values in A currency
+type CbSipFactors struct {
+	ExchangeRate  float64 // P currency to A currency
+	PriceRatio    float64
+	CountryMargin float64
+	ShopMargin    float64
+	ItemMargin    float64
+	HiddenPrice   float64
+	FinalFee      float64 // 1 + ServiceFee + CommissionFee + HandlingFee
+	ServiceFee    float64 // only recorded in trace, already included in FinalFee
+	CommissionFee float64 // only recorded in trace, already included in FinalFee
+	HandlingFee   float64 // only recorded in trace, already included in FinalFee
+
+	PromotionRatioLimit float64 // max promotion ratio, 0 if not limited
+	Decimal             bool    // calculate on decimal arithmetic instead of float64
+	Rounding            RoundingSetting
+	SettlementPrecision int // precision of P currency the settlement price is rounded to
+}
+
+// CbSipInput P prices of one A item, db values
+type CbSipInput struct {
+	PItemPrice      int64
+	PNormalPrice    int64
+	PPromotionPrice *int64 // nil or not positive if there is no promotion
+}
+
+// CbSipPrices A prices in db value, PromotionPrice is -1 if there is no promotion
+type CbSipPrices struct {
+	NormalPrice     int64
+	PromotionPrice  int64
+	SettlementPrice int64
+	PromotionRatio  float64
+	Traces          []*Trace // normal, promotion if any, settlement
+}
+
+// CalcCbSipAPrice CB SIP A normal, promotion and settlement prices of P item price.
+// the normal price keeps the discount of P promotion price by promotion ratio
+func CalcCbSipAPrice(in CbSipInput, f CbSipFactors) (CbSipPrices, error) {
+	if in.PItemPrice <= 0 {
+		return CbSipPrices{}, fmt.Errorf("%w: p_item_price=%v", ErrInvalidSourcePrice, in.PItemPrice)
+	}
+
+	result := CbSipPrices{
+		PromotionPrice: -1,
+		PromotionRatio: CbSipPromotionRatio(in.PNormalPrice, in.PPromotionPrice, f.PromotionRatioLimit),
+	}
+	feeTerms := []Term{
+		{Name: "service_fee", Value: f.ServiceFee},
+		{Name: "commission_fee", Value: f.CommissionFee},
+		{Name: "handling_fee", Value: f.HandlingFee},
+	}
+
+	var normalPriceTrace *Trace
+	result.NormalPrice, normalPriceTrace = CalcCbSipAffiDBPrice(in.PItemPrice, result.PromotionRatio, f)
+	normalPriceTrace.PriceName = PriceNameNormal
+	normalPriceTrace.AddTerms(feeTerms...)
+	result.Traces = append(result.Traces, normalPriceTrace)
+
+	if in.PPromotionPrice != nil && *in.PPromotionPrice > 0 {
+		var promotionPriceTrace *Trace
+		result.PromotionPrice, promotionPriceTrace = CalcCbSipAffiDBPrice(in.PItemPrice, 1.0, f)
+		promotionPriceTrace.PriceName = PriceNamePromotion
+		promotionPriceTrace.AddTerms(feeTerms...)
+		result.Traces = append(result.Traces, promotionPriceTrace)
+	}
+
+	settlementPriceBeforeRound := int64(float64(in.PItemPrice) * f.PriceRatio) // p item price is db price value alr
+	var settlementRoundingRule string
+	result.SettlementPrice, settlementRoundingRule = RoundNearestDBPrice(f.SettlementPrecision, settlementPriceBeforeRound)
+	if result.SettlementPrice < 0 {
+		return result, fmt.Errorf("%w: a settlement price=%v", ErrInvalidResultPrice, result.SettlementPrice)
+	}
+	result.Traces = append(result.Traces, &Trace{
+		PriceName: PriceNameSettlement,
+		Formula:   CbSipSettlementPriceFormula,
+		Terms: []Term{
+			{Name: "p_item_price", Value: ToRealPrice(in.PItemPrice)},
+			{Name: "price_ratio", Value: f.PriceRatio},
+		},
+		PreRoundingPrice: ToRealPrice(settlementPriceBeforeRound),
+		RoundingRule:     settlementRoundingRule,
+		FinalPrice:       result.SettlementPrice,
+	})
+	return result, nil
+}
+
+// CbSipPromotionRatio ratio of P normal price to P promotion price applied on A normal price, 1 if no promotion
+func CbSipPromotionRatio(pNormalPrice int64, pPromotionPrice *int64, ratioLimit float64) float64 {
+	if pPromotionPrice == nil || *pPromotionPrice <= 0 {
+		return 1.0
+	}
+	ratio := ToRealPrice(pNormalPrice) / ToRealPrice(*pPromotionPrice)
+	if ratioLimit > 0 && ratio > ratioLimit {
+		ratio = ratioLimit
+	}
+	return ratio
+}
+
+// CalcCbSipAffiDBPrice rounded A price in db value of P item price with promotion ratio, fees are not added to trace
+func CalcCbSipAffiDBPrice(pItemPrice int64, promotionRatio float64, f CbSipFactors) (int64, *Trace) {
+	realPItemPrice := ToRealPrice(pItemPrice)
+	var affiPrice float64
+	var affiDBPrice int64
+	var roundingRule string
+	if f.Decimal {
+		// the price stays decimal until it is rounded, float64 can not hold all digits of the product
+		decimalAffiPrice := calcCbSipAffiPriceDecimal(realPItemPrice, promotionRatio, f)
+		affiPrice = decimalAffiPrice.InexactFloat64()
+		var roundedPrice decimal.Decimal
+		roundedPrice, roundingRule = RoundPriceDecimal(f.Rounding, decimalAffiPrice)
+		affiDBPrice = ToDBPriceDecimal(roundedPrice)
+	} else {
+		affiPrice = (realPItemPrice*f.PriceRatio*f.ExchangeRate + f.HiddenPrice) * promotionRatio *
+			CbSipFinalMargin(f.CountryMargin, f.ShopMargin, f.ItemMargin) * f.FinalFee
+		var roundedPrice float64
+		roundedPrice, roundingRule = RoundPrice(f.Rounding, affiPrice)
+		affiDBPrice = ToDBPrice(roundedPrice)
+	}
+
+	return affiDBPrice, &Trace{
+		Formula: CbSipAPriceFormula,
+		Terms: []Term{
+			{Name: "p_item_price", Value: realPItemPrice},
+			{Name: "price_ratio", Value: f.PriceRatio},
+			{Name: "exchange_rate", Value: f.ExchangeRate},
+			{Name: "hidden_price", Value: f.HiddenPrice},
+			{Name: "promotion_ratio", Value: promotionRatio},
+			{Name: "country_margin", Value: f.CountryMargin},
+			{Name: "shop_margin", Value: f.ShopMargin},
+			{Name: "item_margin", Value: f.ItemMargin},
+			{Name: "final_fee", Value: f.FinalFee},
+		},
+		IntermediateValues: []Term{
+			{Name: "converted_price", Value: realPItemPrice * f.PriceRatio * f.ExchangeRate},
+			{Name: "final_margin", Value: CbSipFinalMargin(f.CountryMargin, f.ShopMargin, f.ItemMargin)},
+		},
+		PreRoundingPrice: affiPrice,
+		RoundingRule:     roundingRule,
+		FinalPrice:       affiDBPrice,
+	}
+}
+
+// CbSipFinalMargin 1 + country margin + shop margin + item margin, 1 if it is not positive
+func CbSipFinalMargin(countryMargin float64, shopMargin float64, itemMargin float64) float64 {
+	margin := 1 + countryMargin + shopMargin + itemMargin
+	if margin <= 0 {
+		margin = 1
+	}
+	return margin
+}
+
+// float64 inputs are converted by decimal.NewFromFloat, which keeps the shortest decimal representation,
+// so 1.1 is exactly 1.1 instead of 1.100000000000000088817841970012523.
+func calcCbSipAffiPriceDecimal(realPItemPrice float64, promotionRatio float64, f CbSipFactors) decimal.Decimal {
+	margin := decimal.NewFromInt(1).
+		Add(decimal.NewFromFloat(f.CountryMargin)).
+		Add(decimal.NewFromFloat(f.ShopMargin)).
+		Add(decimal.NewFromFloat(f.ItemMargin))
+	if !margin.IsPositive() {
+		margin = decimal.NewFromInt(1)
+	}
+	return decimal.NewFromFloat(realPItemPrice).
+		Mul(decimal.NewFromFloat(f.PriceRatio)).
+		Mul(decimal.NewFromFloat(f.ExchangeRate)).
+		Add(decimal.NewFromFloat(f.HiddenPrice)).
+		Mul(decimal.NewFromFloat(promotionRatio)).
+		Mul(margin).
+		Mul(decimal.NewFromFloat(f.FinalFee))
+}
+
+// CbSipItemPriceFactors all factors of CB SIP item price formula, fees are rates
+type CbSipItemPriceFactors struct {
+	ReferenceServiceFee decimal.Decimal
+	TransactionFee      decimal.Decimal
+	CommissionFee       decimal.Decimal
+	ExchangeRate        decimal.Decimal // merchant currency to region currency
+	HiddenPrice         decimal.Decimal
+}
+
+// CalcCbSipItemPrice merchant item price in db value of A price, on 2 decimal places and at least 0.01.
+// hidden price is not deducted if the price after fees doesn't cover it
+func CalcCbSipItemPrice(aPrice int64, f CbSipItemPriceFactors) (int64, *Trace, error) {
+	if !f.ExchangeRate.IsPositive() {
+		return 0, nil, fmt.Errorf("%w: exchange_rate=%v", ErrInvalidExchangeRate, f.ExchangeRate)
+	}
+	decimalPricePrecision := decimal.NewFromInt32(int32(PricePrecision))
+	deductFee := decimal.NewFromInt(1).Sub(f.ReferenceServiceFee).Sub(f.TransactionFee).Sub(f.CommissionFee)
+	decimalPrice := decimal.NewFromInt(aPrice).Div(decimalPricePrecision)
+
+	trace := &Trace{Formula: CbSipItemPriceFormula}
+	deductedPrice := decimalPrice.Mul(deductFee)
+	if deductedPrice.Sub(f.HiddenPrice).IsPositive() {
+		// psku_price*(1 - commission_fee - service_fee - transaction_fee) - hidden_price > 0
+		deductedPrice = deductedPrice.Sub(f.HiddenPrice)
+	} else {
+		trace.Formula = "a_price * (1 - reference_service_fee - transaction_fee - commission_fee) / exchange_rate, hidden price is not covered"
+	}
+	decimalItemPrice := deductedPrice.DivRound(f.ExchangeRate, 2)
+	trace.PreRoundingPrice = deductedPrice.Div(f.ExchangeRate).InexactFloat64()
+	trace.RoundingRule = fmt.Sprintf("%s(round_place=2)", RoundingRuleNearest)
+	if decimalItemPrice.Sub(decimal.NewFromFloat(0.01)).IsNegative() {
+		// item_price < 0.01, then item_price=0.01
+		decimalItemPrice = decimal.NewFromFloat(0.01)
+		trace.RoundingRule = fmt.Sprintf("%s, raised to 0.01", trace.RoundingRule)
+	}
+	itemPrice := uint64(decimalItemPrice.Mul(decimalPricePrecision).IntPart()) // itemPrice magnify 100000 times
+
+	trace.Terms = []Term{
+		{Name: "a_price", Value: decimalPrice.InexactFloat64()},
+		{Name: "reference_service_fee", Value: f.ReferenceServiceFee.InexactFloat64()},
+		{Name: "transaction_fee", Value: f.TransactionFee.InexactFloat64()},
+		{Name: "commission_fee", Value: f.CommissionFee.InexactFloat64()},
+		{Name: "hidden_price", Value: f.HiddenPrice.InexactFloat64()},
+		{Name: "exchange_rate", Value: f.ExchangeRate.InexactFloat64()},
+	}
+	trace.IntermediateValues = []Term{
+		{Name: "deduct_fee", Value: deductFee.InexactFloat64()},
+		{Name: "deducted_price", Value: deductedPrice.InexactFloat64()},
+	}
+	trace.FinalPrice = int64(itemPrice)
+	return int64(itemPrice), trace, nil
+}
@@ -0,0 +1,102 @@
+package pricekernel
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcCbSipAPrice(t *testing.T) {
+	promotionPrice := int64(100000)
+	in := CbSipInput{PItemPrice: 110000, PNormalPrice: 250000, PPromotionPrice: &promotionPrice}
+	f := CbSipFactors{
+		ExchangeRate:        1.1,
+		PriceRatio:          1,
+		FinalFee:            1,
+		PromotionRatioLimit: 1.99,
+		Rounding:            RoundingSetting{Precision: -2},
+		SettlementPrecision: -2,
+	}
+
+	// 1.1 * 1.1 is 1.2100000000000002 on float64, ceil on 2 decimal places turns it into 1.22
+	prices, err := CalcCbSipAPrice(in, f)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.99, prices.PromotionRatio)
+	assert.Equal(t, int64(122000), prices.PromotionPrice)
+	assert.Equal(t, int64(110000), prices.SettlementPrice)
+	assert.Len(t, prices.Traces, 3)
+
+	f.Decimal = true
+	f.Rounding.Decimal = true
+	prices, err = CalcCbSipAPrice(in, f)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(121000), prices.PromotionPrice)
+	assert.Equal(t, int64(241000), prices.NormalPrice) // 1.21 * 1.99 = 2.4079
+	assert.Equal(t, "ceil(precision=-2, arithmetic=decimal)", prices.Traces[0].RoundingRule)
+
+	_, err = CalcCbSipAPrice(CbSipInput{}, f)
+	assert.ErrorIs(t, err, ErrInvalidSourcePrice)
+}
+
+func TestCalcCbSipAffiDBPriceDecimal(t *testing.T) {
+	// 12.3 * (1 + 1e-18) has more digits than float64 holds, it is ceiled to 12.31 only if it stays decimal
+	f := CbSipFactors{
+		ExchangeRate:  1,
+		PriceRatio:    1,
+		CountryMargin: 1e-18,
+		FinalFee:      1,
+		Decimal:       true,
+		Rounding:      RoundingSetting{Precision: -2, Decimal: true},
+	}
+	affiDBPrice, trace := CalcCbSipAffiDBPrice(1230000, 1, f)
+	assert.Equal(t, int64(1231000), affiDBPrice)
+	assert.Equal(t, 12.3, trace.PreRoundingPrice)
+
+	f.Decimal = false
+	affiDBPrice, _ = CalcCbSipAffiDBPrice(1230000, 1, f)
+	assert.Equal(t, int64(1230000), affiDBPrice)
+}
+
+func TestCalcCbSipItemPrice(t *testing.T) {
+	f := CbSipItemPriceFactors{
+		ReferenceServiceFee: decimal.NewFromFloat(0.1),
+		TransactionFee:      decimal.NewFromFloat(0.05),
+		CommissionFee:       decimal.NewFromFloat(0.05),
+		ExchangeRate:        decimal.NewFromInt(2),
+		HiddenPrice:         decimal.NewFromInt(1),
+	}
+
+	// (10 * 0.8 - 1) / 2
+	itemPrice, trace, err := CalcCbSipItemPrice(1000000, f)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(350000), itemPrice)
+	assert.Equal(t, CbSipItemPriceFormula, trace.Formula)
+
+	// hidden price is not deducted if it is not covered
+	f.HiddenPrice = decimal.NewFromInt(10)
+	itemPrice, _, err = CalcCbSipItemPrice(1000000, f)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(400000), itemPrice)
+
+	f.ExchangeRate = decimal.Zero
+	_, _, err = CalcCbSipItemPrice(1000000, f)
+	assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+}
+
+func TestCalcCbscPrice(t *testing.T) {
+	f := CbscFactors{ExchangeRate: 2, ProfitRate: 1, HidePrice: 10, DenominatorPriceRate: 0.5, RoundPlace: 2}
+
+	prices := CalcCbscPrice(true, 1000000, f) // (10 * 2 + 10) / 0.5
+	assert.Equal(t, int64(6000000), prices.DstPrice)
+	assert.Equal(t, int64(1000000), prices.HidePrice)
+
+	// mpsku price doesn't cover hide price, raised to minimum price
+	prices = CalcCbscPrice(false, 1000000, f)
+	assert.Equal(t, int64(1000), prices.DstPrice)
+	assert.Equal(t, PriceNameDst, prices.Trace.PriceName)
+
+	assert.Equal(t, 0.9, CbscDenominatorPriceRate(true, 500, 300, 200))
+	assert.Equal(t, float64(1), CbscDenominatorPriceRate(false, 500, 300, 200))
+	assert.Equal(t, float64(1), CbscDenominatorPriceRate(true, 5000, 3000, 2000))
+}
@@ -0,0 +1,135 @@
+package pricekernel
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CbscMpskuPriceFormula = "(mtsku_price * exchange_rate * profit_rate + hide_price) / denominator_price_rate"
+	CbscMtskuPriceFormula = "(mpsku_price * denominator_price_rate - hide_price) / exchange_rate / profit_rate"
+)
+
+// CbscFactors all factors of CBSC mtsku <-> mpsku price formula, exchange rate is mpsku currency per merchant currency
+type CbscFactors struct {
+	ExchangeRate         float64
+	ProfitRate           float64 // market adjustment rate
+	HidePrice            float64 // real value in mpsku currency
+	DenominatorPriceRate float64 // 1 - commission rate - transaction fee rate - service fee rate
+	RoundPlace           int32   // decimal places of dst currency
+}
+
+// CbscPrices dst price and hide price in db value
+type CbscPrices struct {
+	DstPrice  int64
+	HidePrice int64
+	Trace     *Trace
+}
+
+// CalcCbscPrice mpsku price of mtsku price if isMtskuToMpsku, otherwise mtsku price of mpsku price.
+// source price is rounded to 2 decimal places first, a zero mtsku price is raised to the minimum price of currency
+func CalcCbscPrice(isMtskuToMpsku bool, sourcePrice int64, f CbscFactors) CbscPrices {
+	calcPrice := RoundIntToFloat(sourcePrice, PricePrecision, 2)
+	var dstPrice float64
+	var trace *Trace
+	if isMtskuToMpsku {
+		dstPrice, trace = CalcMpskuPrice(calcPrice, f)
+	} else {
+		dstPrice, trace = CalcMtskuPrice(calcPrice, f)
+	}
+
+	inflatedDstPrice := RoundFloatToInt(dstPrice, PricePrecision, f.RoundPlace)
+	trace.RoundingRule = fmt.Sprintf("%s(round_place=%d)", RoundingRuleNearest, f.RoundPlace)
+	if !isMtskuToMpsku && inflatedDstPrice == 0 {
+		inflatedDstPrice = MiniPriceOfRoundPlace(f.RoundPlace)
+		trace.RoundingRule = fmt.Sprintf("%s, raised to minimum price of currency", trace.RoundingRule)
+	}
+	trace.PriceName = PriceNameDst
+	trace.FinalPrice = inflatedDstPrice
+
+	return CbscPrices{
+		DstPrice:  inflatedDstPrice,
+		HidePrice: RoundFloatToInt(f.HidePrice, PricePrecision, f.RoundPlace),
+		Trace:     trace,
+	}
+}
+
+// CbscDenominatorPriceRate 1 - commissionRate - transactionFeeRate - serviceFeeRate on 4 decimal places,
+// rates are magnified by PercentPrecisionBetweenMtskuAndMpsku. it is 1 if service fee rate is not used or the result is not positive
+func CbscDenominatorPriceRate(useServiceFeeRate bool, commissionRate uint64, transactionFeeRate uint64, serviceFeeRate uint64) float64 {
+	var result int64
+	if !useServiceFeeRate {
+		result = PercentPrecisionBetweenMtskuAndMpsku
+	} else {
+		result = int64(PercentPrecisionBetweenMtskuAndMpsku - commissionRate - transactionFeeRate - serviceFeeRate)
+	}
+	if result <= 0 {
+		result = PercentPrecisionBetweenMtskuAndMpsku
+	}
+	return RoundIntToFloat(result, PercentPrecisionBetweenMtskuAndMpsku, 4)
+}
+
+// CalcMpskuPrice mpsku price of real mtsku price before rounding.
+// the returned trace is not rounded yet.
+func CalcMpskuPrice(mtskuPrice float64, f CbscFactors) (float64, *Trace) {
+	decimalMtskuPrice := decimal.NewFromFloat(mtskuPrice)
+	decimalExchangeRate := decimal.NewFromFloat(f.ExchangeRate)
+	decimalProfitRate := decimal.NewFromFloat(f.ProfitRate)
+	decimalHidePrice := decimal.NewFromFloat(f.HidePrice)
+	decimalDenominatorPriceRate := decimal.NewFromFloat(f.DenominatorPriceRate)
+
+	decimalConvertedPrice := decimalMtskuPrice.Mul(decimalExchangeRate).Mul(decimalProfitRate)
+	decimalValue := decimalConvertedPrice.Add(decimalHidePrice).Div(decimalDenominatorPriceRate)
+	result, _ := decimalValue.Float64()
+	convertedPrice, _ := decimalConvertedPrice.Float64()
+
+	return result, &Trace{
+		Formula:            CbscMpskuPriceFormula,
+		Terms:              cbscPriceTerms("mtsku_price", mtskuPrice, f),
+		IntermediateValues: []Term{{Name: "converted_price", Value: convertedPrice}},
+		PreRoundingPrice:   result,
+	}
+}
+
+// CalcMtskuPrice mtsku price of real mpsku price before rounding, 0 if the price doesn't cover hide price.
+// the returned trace is not rounded yet.
+func CalcMtskuPrice(mpskuPrice float64, f CbscFactors) (float64, *Trace) {
+	decimalMpskuPrice := decimal.NewFromFloat(mpskuPrice)
+	decimalExchangeRate := decimal.NewFromFloat(f.ExchangeRate)
+	decimalProfitRate := decimal.NewFromFloat(f.ProfitRate)
+	decimalHidePrice := decimal.NewFromFloat(f.HidePrice)
+	decimalOtherRate := decimal.NewFromFloat(f.DenominatorPriceRate)
+
+	trace := &Trace{
+		Formula: CbscMtskuPriceFormula,
+		Terms:   cbscPriceTerms("mpsku_price", mpskuPrice, f),
+	}
+
+	decimalValue := decimalMpskuPrice.Mul(decimalOtherRate).Sub(decimalHidePrice)
+	deductedPrice, _ := decimalValue.Float64()
+	trace.IntermediateValues = []Term{{Name: "deducted_price", Value: deductedPrice}}
+	if decimalExchangeRate.IsZero() || decimalProfitRate.IsZero() {
+		trace.Formula = "0, exchange_rate or profit_rate is 0"
+		return 0, trace
+	}
+	if decimalValue.IsNegative() {
+		trace.Formula = "0, mpsku_price * denominator_price_rate - hide_price < 0"
+		return 0, trace
+	}
+
+	decimalValue = decimalValue.Div(decimalExchangeRate).Div(decimalProfitRate)
+	result, _ := decimalValue.Float64()
+	trace.PreRoundingPrice = result
+	return result, trace
+}
+
+func cbscPriceTerms(srcPriceName string, srcPrice float64, f CbscFactors) []Term {
+	return []Term{
+		{Name: srcPriceName, Value: srcPrice},
+		{Name: "exchange_rate", Value: f.ExchangeRate},
+		{Name: "profit_rate", Value: f.ProfitRate},
+		{Name: "hide_price", Value: f.HidePrice},
+		{Name: "denominator_price_rate", Value: f.DenominatorPriceRate},
+	}
+}
@@ -0,0 +1,52 @@
+package pricekernel
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PricePrecision                       = 100000 // db price is real price magnified by PricePrecision
+	PercentPrecisionBetweenMtskuAndMpsku = 10000  // cbsc rates are magnified by it
+)
+
+func ToRealPrice(input int64) float64 {
+	if input == -1 || input == 0 {
+		return float64(input)
+	}
+
+	return float64(input) / PricePrecision
+}
+
+func ToDBPrice(realPrice float64) int64 {
+	if realPrice == 0 || realPrice == -1 {
+		return int64(realPrice)
+	}
+	return int64(math.Round(realPrice * float64(PricePrecision)))
+}
+
+// ToDBPriceDecimal same as ToDBPrice on a decimal real price
+func ToDBPriceDecimal(realPrice decimal.Decimal) int64 {
+	return realPrice.Mul(decimal.NewFromInt(PricePrecision)).Round(0).IntPart()
+}
+
+func RoundIntToFloat(input int64, precision int32, roundPlace int32) float64 {
+	inputNum := decimal.NewFromFloat(float64(input))
+	precisionNum := decimal.NewFromFloat(float64(precision))
+	decimalValue := inputNum.Div(precisionNum)
+	result, _ := decimalValue.Round(roundPlace).Float64()
+	return result
+}
+
+func RoundFloatToInt(input float64, precision int32, roundPlace int32) int64 {
+	inputNum := decimal.NewFromFloat(input).Round(roundPlace)
+	precisionNum := decimal.NewFromFloat(float64(precision))
+	decimalValue := inputNum.Mul(precisionNum)
+	return decimalValue.Round(roundPlace).IntPart()
+}
+
+// MiniPriceOfRoundPlace the smallest db price on roundPlace decimal places
+func MiniPriceOfRoundPlace(roundPlace int32) int64 {
+	return int64(math.Pow10(-1*int(roundPlace)) * PricePrecision)
+}
@@ -0,0 +1,173 @@
+package pricekernel
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+const LocalSipAPriceFormula = "(p_price * (1 - oversea_discount_rate) / exchange_rate + init_hidden_price + shipping_fee) * country_margin * shop_margin * item_margin"
+
+// LocalSipPriceConfig local price config of region pair, nil fields fall back to 1
+type LocalSipPriceConfig struct {
+	Buffer       *float64 // country margin
+	ExchangeRate *float64 // A currency to P currency
+}
+
+// LocalSipFactors all factors of local SIP A price formula, fees are real values in A currency
+type LocalSipFactors struct {
+	Weight          float64 // gram, A price is 0 if it is not positive
+	ItemMargin      float64
+	ShopMargin      float64
+	InitHiddenPrice float64
+	ShippingFee     float64
+	PriceConfig     *LocalSipPriceConfig // A price is same as P price if it is nil
+
+	Decimal  bool // calculate on decimal arithmetic instead of float64
+	Rounding RoundingSetting
+}
+
+// LocalSipInput P prices of one A model, db values
+type LocalSipInput struct {
+	PNormalPrice     int64 // not calculated if it is not positive
+	PPromotionPrices []int64
+}
+
+// LocalSipPrices A prices in db value, in the same order as P prices
+type LocalSipPrices struct {
+	NormalPrice     int64
+	PromotionPrices []int64
+	Traces          []*Trace // normal if any, promotions
+}
+
+// CalcLocalSipAPrice local SIP A normal and promotion prices of P prices
+func CalcLocalSipAPrice(in LocalSipInput, f LocalSipFactors) LocalSipPrices {
+	result := LocalSipPrices{
+		PromotionPrices: make([]int64, 0, len(in.PPromotionPrices)),
+		Traces:          make([]*Trace, 0, len(in.PPromotionPrices)+1),
+	}
+	if in.PNormalPrice > 0 {
+		var normalPriceTrace *Trace
+		result.NormalPrice, normalPriceTrace = CalcLocalSipAffiDBPrice(in.PNormalPrice, f)
+		normalPriceTrace.PriceName = PriceNameNormal
+		result.Traces = append(result.Traces, normalPriceTrace)
+	}
+	for _, pPromotionPrice := range in.PPromotionPrices {
+		aPromotionPrice, promotionPriceTrace := CalcLocalSipAffiDBPrice(pPromotionPrice, f)
+		promotionPriceTrace.PriceName = PriceNamePromotion
+		result.PromotionPrices = append(result.PromotionPrices, aPromotionPrice)
+		result.Traces = append(result.Traces, promotionPriceTrace)
+	}
+	return result
+}
+
+// CalcLocalSipAffiDBPrice rounded A price in db value of P price
+func CalcLocalSipAffiDBPrice(pPrice int64, f LocalSipFactors) (int64, *Trace) {
+	aPrice, decimalAPrice, trace := calcLocalSipAffiPrice(ToRealPrice(pPrice), nil, f)
+	if f.Decimal {
+		// the price stays decimal until it is rounded, float64 can not hold all digits of the product
+		roundedPrice, roundingRule := RoundPriceDecimal(f.Rounding, decimalAPrice)
+		trace.RoundingRule = roundingRule
+		trace.FinalPrice = ToDBPriceDecimal(roundedPrice)
+		return trace.FinalPrice, trace
+	}
+	roundedPrice, roundingRule := RoundPrice(f.Rounding, aPrice)
+
+	trace.RoundingRule = roundingRule
+	trace.FinalPrice = ToDBPrice(roundedPrice)
+	return trace.FinalPrice, trace
+}
+
+// CalcLocalSipAffiPrice A price of real P price before rounding, oversea discount rate out of [0, 1] is ignored.
+// the returned trace is not rounded yet.
+func CalcLocalSipAffiPrice(pPrice float64, overseaDiscountRate *float64, f LocalSipFactors) (float64, *Trace) {
+	aPrice, _, trace := calcLocalSipAffiPrice(pPrice, overseaDiscountRate, f)
+	return aPrice, trace
+}
+
+// calcLocalSipAffiPrice the decimal price is also returned if f.Decimal
+func calcLocalSipAffiPrice(pPrice float64, overseaDiscountRate *float64, f LocalSipFactors) (float64, decimal.Decimal, *Trace) {
+	trace := &Trace{
+		Formula: LocalSipAPriceFormula,
+		Terms: []Term{
+			{Name: "p_price", Value: pPrice},
+			{Name: "weight", Value: f.Weight},
+		},
+	}
+
+	if f.PriceConfig == nil {
+		trace.Formula = "p_price, local price config not found"
+		trace.PreRoundingPrice = pPrice
+		return pPrice, decimal.NewFromFloat(pPrice), trace
+	}
+
+	if f.Weight <= 0 {
+		trace.Formula = "0, weight <= 0"
+		return 0, decimal.Zero, trace
+	}
+
+	itemMargin := MarginOrOne(f.ItemMargin)
+	shopMargin := MarginOrOne(f.ShopMargin)
+	countryMargin := LocalSipCountryMargin(f.PriceConfig)
+	exchangeRate := LocalSipExchangeRate(f.PriceConfig)
+
+	overseaRate := float64(0)
+	if overseaDiscountRate != nil && *overseaDiscountRate >= 0 && *overseaDiscountRate <= 1 {
+		overseaRate = *overseaDiscountRate
+	}
+
+	var aPrice float64
+	var decimalAPrice decimal.Decimal
+	if f.Decimal {
+		decimalAPrice = decimal.NewFromFloat(pPrice).
+			Mul(decimal.NewFromInt(1).Sub(decimal.NewFromFloat(overseaRate))).
+			Div(decimal.NewFromFloat(exchangeRate)).
+			Add(decimal.NewFromFloat(f.InitHiddenPrice)).
+			Add(decimal.NewFromFloat(f.ShippingFee)).
+			Mul(decimal.NewFromFloat(countryMargin)).
+			Mul(decimal.NewFromFloat(shopMargin)).
+			Mul(decimal.NewFromFloat(itemMargin))
+		aPrice = decimalAPrice.InexactFloat64()
+	} else {
+		aPrice = (pPrice*(1-overseaRate)/exchangeRate + f.InitHiddenPrice + f.ShippingFee) * countryMargin * shopMargin * itemMargin
+	}
+
+	trace.AddTerms(
+		Term{Name: "oversea_discount_rate", Value: overseaRate},
+		Term{Name: "exchange_rate", Value: exchangeRate},
+		Term{Name: "init_hidden_price", Value: f.InitHiddenPrice},
+		Term{Name: "shipping_fee", Value: f.ShippingFee},
+		Term{Name: "country_margin", Value: countryMargin},
+		Term{Name: "shop_margin", Value: shopMargin},
+		Term{Name: "item_margin", Value: itemMargin},
+	)
+	trace.IntermediateValues = []Term{
+		{Name: "converted_price", Value: pPrice * (1 - overseaRate) / exchangeRate},
+		{Name: "final_margin", Value: countryMargin * shopMargin * itemMargin},
+	}
+	trace.PreRoundingPrice = aPrice
+
+	return aPrice, decimalAPrice, trace
+}
+
+// MarginOrOne local SIP item and shop margins are multipliers, not positive means not set
+func MarginOrOne(margin float64) float64 {
+	if margin <= 0 {
+		return 1
+	}
+	return margin
+}
+
+// LocalSipCountryMargin buffer of local price config, 1 if not set
+func LocalSipCountryMargin(priceConfig *LocalSipPriceConfig) float64 {
+	if priceConfig.Buffer == nil || *priceConfig.Buffer <= 0 {
+		return 1
+	}
+	return *priceConfig.Buffer
+}
+
+// LocalSipExchangeRate exchange rate of local price config, 1 if not set
+func LocalSipExchangeRate(priceConfig *LocalSipPriceConfig) float64 {
+	if priceConfig.ExchangeRate == nil || *priceConfig.ExchangeRate == 0 {
+		return 1
+	}
+	return *priceConfig.ExchangeRate
+}
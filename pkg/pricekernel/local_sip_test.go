@@ -0,0 +1,33 @@
+package pricekernel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcLocalSipAffiDBPrice(t *testing.T) {
+	exchangeRate := 1.0
+	f := LocalSipFactors{
+		Weight:      100,
+		ShippingFee: 1e-18,
+		PriceConfig: &LocalSipPriceConfig{ExchangeRate: &exchangeRate},
+		Decimal:     true,
+		Rounding:    RoundingSetting{Precision: -2, Decimal: true},
+	}
+
+	// 12.3 + 1e-18 has more digits than float64 holds, it is ceiled to 12.31 only if it stays decimal
+	aDBPrice, trace := CalcLocalSipAffiDBPrice(1230000, f)
+	assert.Equal(t, int64(1231000), aDBPrice)
+	assert.Equal(t, "ceil(precision=-2, arithmetic=decimal)", trace.RoundingRule)
+
+	f.Decimal = false
+	aDBPrice, _ = CalcLocalSipAffiDBPrice(1230000, f)
+	assert.Equal(t, int64(1230000), aDBPrice)
+
+	// P price is kept without local price config
+	f.Decimal = true
+	f.PriceConfig = nil
+	aDBPrice, _ = CalcLocalSipAffiDBPrice(1230000, f)
+	assert.Equal(t, int64(1230000), aDBPrice)
+}
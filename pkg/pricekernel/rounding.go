@@ -0,0 +1,250 @@
+package pricekernel
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RoundingRuleNone           = "none"
+	RoundingRuleCeil           = "ceil"
+	RoundingRuleSpecialRoundUp = "special_round_up"
+	RoundingRuleNearest        = "round_nearest"
+	RoundingRuleFloor          = "floor"
+	RoundingRulePsychological  = "psychological"
+	RoundingRuleRoundToN       = "round_to_n"
+)
+
+const ArithmeticDecimal = "decimal"
+
+// RoundingSetting how the price of a region is rounded, resolved from currency config by the caller.
+// Strategy is used first if it is registered, then special round up or ceil on Precision
+type RoundingSetting struct {
+	Disabled          bool // price is kept as is, e.g. currency of region is unknown
+	Precision         int  // power of 10 the price is rounded to, e.g. -2 for 2 decimal places
+	UseSpecialRoundUp bool
+	Decimal           bool // round up on decimal arithmetic instead of float64
+	Strategy          *RoundingStrategy
+}
+
+// RoundingStrategy a named rounding strategy and its parameters
+type RoundingStrategy struct {
+	Name      string    // ceil, floor, round_nearest, special_round_up, psychological, round_to_n
+	Precision *int      // optional, Precision of RoundingSetting is used when not set
+	Unit      float64   // psychological only, the price range the endings repeat in, 1 if not set
+	Endings   []float64 // psychological only, ascending endings inside unit, e.g. [0.5, 0.9, 0.99]
+	N         float64   // round_to_n only, e.g. 1000 for VND
+}
+
+// RoundingFunc rounds price by the strategy on decimal arithmetic, and returns the rounding rule applied.
+// precision is the one of strategy if set, otherwise the currency precision
+type RoundingFunc func(price decimal.Decimal, strategy RoundingStrategy, precision int) (decimal.Decimal, string)
+
+var roundingStrategyRegistry = map[string]RoundingFunc{
+	RoundingRuleCeil:           roundCeil,
+	RoundingRuleFloor:          roundFloor,
+	RoundingRuleNearest:        roundNearest,
+	RoundingRuleSpecialRoundUp: roundSpecial,
+	RoundingRulePsychological:  roundPsychological,
+	RoundingRuleRoundToN:       roundToN,
+}
+
+// RegisterRoundingStrategy adds or replaces a rounding strategy, it is not concurrency safe and should only be called on init
+func RegisterRoundingStrategy(name string, fn RoundingFunc) {
+	roundingStrategyRegistry[name] = fn
+}
+
+// IsRoundingStrategyRegistered whether strategy name can be used in RoundingStrategy
+func IsRoundingStrategyRegistered(name string) bool {
+	_, ok := roundingStrategyRegistry[name]
+	return ok
+}
+
+// RoundPrice rounds the real price by setting, and returns the rounding rule applied
+func RoundPrice(setting RoundingSetting, price float64) (float64, string) {
+	if setting.Disabled {
+		return price, RoundingRuleNone
+	}
+	if setting.Strategy != nil {
+		if result, rule, ok := RoundWithStrategy(*setting.Strategy, setting.Precision, price); ok {
+			return result, rule
+		}
+	}
+	if setting.Decimal {
+		return RoundUpDecimal(setting.Precision, setting.UseSpecialRoundUp, price)
+	}
+	return RoundUp(setting.Precision, setting.UseSpecialRoundUp, price)
+}
+
+// RoundPriceDecimal same as RoundPrice on a decimal price, the price is converted to float64 only if the setting
+// rounds on float64 arithmetic
+func RoundPriceDecimal(setting RoundingSetting, price decimal.Decimal) (decimal.Decimal, string) {
+	if setting.Disabled {
+		return price, RoundingRuleNone
+	}
+	if setting.Strategy != nil {
+		if result, rule, ok := roundWithStrategyDecimal(*setting.Strategy, setting.Precision, price); ok {
+			return result, rule
+		}
+	}
+	if setting.Decimal {
+		return roundUpDecimal(setting.Precision, setting.UseSpecialRoundUp, price)
+	}
+	result, rule := RoundUp(setting.Precision, setting.UseSpecialRoundUp, price.InexactFloat64())
+	return decimal.NewFromFloat(result), rule
+}
+
+// RoundWithStrategy rounds price by strategy, ok is false if the strategy is not registered
+func RoundWithStrategy(strategy RoundingStrategy, currencyPrecision int, price float64) (result float64, rule string, ok bool) {
+	rounded, rule, ok := roundWithStrategyDecimal(strategy, currencyPrecision, decimal.NewFromFloat(price))
+	if !ok {
+		return price, rule, false
+	}
+	result, _ = rounded.Float64()
+	return result, rule, true
+}
+
+func roundWithStrategyDecimal(strategy RoundingStrategy, currencyPrecision int, price decimal.Decimal) (decimal.Decimal, string, bool) {
+	fn, ok := roundingStrategyRegistry[strategy.Name]
+	if !ok {
+		return price, RoundingRuleNone, false
+	}
+
+	precision := currencyPrecision
+	if strategy.Precision != nil {
+		precision = *strategy.Precision
+	}
+	rounded, rule := fn(price, strategy, precision)
+	return rounded, rule, true
+}
+
+// RoundUp special round up or ceil on precision
+func RoundUp(precision int, useSpecialRoundUp bool, price float64) (float64, string) {
+	if useSpecialRoundUp {
+		return SpecialRoundUp(precision, price), fmt.Sprintf("%s(precision=%d)", RoundingRuleSpecialRoundUp, precision)
+	}
+	return CeilWithPrecision(price, precision), fmt.Sprintf("%s(precision=%d)", RoundingRuleCeil, precision)
+}
+
+// RoundUpDecimal same as RoundUp on decimal arithmetic
+func RoundUpDecimal(precision int, useSpecialRoundUp bool, price float64) (float64, string) {
+	result, rule := roundUpDecimal(precision, useSpecialRoundUp, decimal.NewFromFloat(price))
+	f, _ := result.Float64()
+	return f, rule
+}
+
+func roundUpDecimal(precision int, useSpecialRoundUp bool, price decimal.Decimal) (decimal.Decimal, string) {
+	var result decimal.Decimal
+	var rule string
+	if useSpecialRoundUp {
+		result = specialRoundUpDecimal(precision, price)
+		rule = RoundingRuleSpecialRoundUp
+	} else {
+		result = ceilWithPrecisionDecimal(price, precision)
+		rule = RoundingRuleCeil
+	}
+	return result, fmt.Sprintf("%s(precision=%d, arithmetic=%s)", rule, precision, ArithmeticDecimal)
+}
+
+// RoundNearestDBPrice rounds db price to the nearest on precision
+func RoundNearestDBPrice(precision int, dbPrice int64) (int64, string) {
+	return ToDBPrice(RoundWithPrecision(ToRealPrice(dbPrice), precision)), fmt.Sprintf("%s(precision=%d)", RoundingRuleNearest, precision)
+}
+
+func RoundWithPrecision(value float64, decimalPlaces int) float64 {
+	if decimalPlaces >= 0 {
+		return float64(int(math.Round(value/(math.Pow10(decimalPlaces))) * (math.Pow10(decimalPlaces))))
+	}
+	return math.Round(value*(math.Pow10(-decimalPlaces))) / (math.Pow10(-decimalPlaces))
+}
+
+func CeilWithPrecision(value float64, decimalPlaces int) float64 {
+	if decimalPlaces >= 0 {
+		return float64(int(math.Ceil(value/(math.Pow10(decimalPlaces))) * (math.Pow10(decimalPlaces))))
+	}
+	return math.Ceil(value*(math.Pow10(-decimalPlaces))) / (math.Pow10(-decimalPlaces))
+}
+
+// SpecialRoundUp rounds the decimal part up to the next of .0, .5, .9, .99
+func SpecialRoundUp(precision int, price float64) float64 {
+	epsilon := math.Pow10(precision - 2)
+	priceWithoutDecimalPoint := math.Floor(price)
+	priceDecimalPart := price - priceWithoutDecimalPoint
+	if priceDecimalPart < epsilon {
+		return priceWithoutDecimalPoint
+	} else if priceDecimalPart < 0.5+epsilon {
+		return priceWithoutDecimalPoint + 0.5
+	} else if priceDecimalPart < 0.9+epsilon {
+		return priceWithoutDecimalPoint + 0.9
+	} else if priceDecimalPart < 0.99+epsilon {
+		return priceWithoutDecimalPoint + 0.99
+	} else {
+		return priceWithoutDecimalPoint + 1
+	}
+}
+
+func ceilWithPrecisionDecimal(value decimal.Decimal, decimalPlaces int) decimal.Decimal {
+	return value.Shift(int32(-decimalPlaces)).Ceil().Shift(int32(decimalPlaces))
+}
+
+func specialRoundUpDecimal(precision int, price decimal.Decimal) decimal.Decimal {
+	epsilon := decimal.New(1, int32(precision-2))
+	priceWithoutDecimalPoint := price.Floor()
+	priceDecimalPart := price.Sub(priceWithoutDecimalPoint)
+	for _, ending := range []decimal.Decimal{decimal.Zero, decimal.NewFromFloat(0.5), decimal.NewFromFloat(0.9), decimal.NewFromFloat(0.99)} {
+		if priceDecimalPart.LessThan(ending.Add(epsilon)) {
+			return priceWithoutDecimalPoint.Add(ending)
+		}
+	}
+	return priceWithoutDecimalPoint.Add(decimal.NewFromInt(1))
+}
+
+func roundCeil(price decimal.Decimal, _ RoundingStrategy, precision int) (decimal.Decimal, string) {
+	return ceilWithPrecisionDecimal(price, precision), fmt.Sprintf("%s(precision=%d)", RoundingRuleCeil, precision)
+}
+
+func roundFloor(price decimal.Decimal, _ RoundingStrategy, precision int) (decimal.Decimal, string) {
+	return price.Shift(int32(-precision)).Floor().Shift(int32(precision)), fmt.Sprintf("%s(precision=%d)", RoundingRuleFloor, precision)
+}
+
+func roundNearest(price decimal.Decimal, _ RoundingStrategy, precision int) (decimal.Decimal, string) {
+	return price.Round(int32(-precision)), fmt.Sprintf("%s(precision=%d)", RoundingRuleNearest, precision)
+}
+
+func roundSpecial(price decimal.Decimal, _ RoundingStrategy, precision int) (decimal.Decimal, string) {
+	return specialRoundUpDecimal(precision, price), fmt.Sprintf("%s(precision=%d)", RoundingRuleSpecialRoundUp, precision)
+}
+
+// roundPsychological rounds price up to the next ending inside its unit, or to the next unit if it exceeds all endings
+func roundPsychological(price decimal.Decimal, strategy RoundingStrategy, _ int) (decimal.Decimal, string) {
+	unit := decimal.NewFromInt(1)
+	if strategy.Unit > 0 {
+		unit = decimal.NewFromFloat(strategy.Unit)
+	}
+	rule := fmt.Sprintf("%s(unit=%v, endings=%v)", RoundingRulePsychological, unit, strategy.Endings)
+
+	base := price.Div(unit).Floor().Mul(unit)
+	remainder := price.Sub(base)
+	if remainder.IsZero() {
+		return base, rule
+	}
+	for _, ending := range strategy.Endings {
+		decimalEnding := decimal.NewFromFloat(ending)
+		if remainder.LessThanOrEqual(decimalEnding) {
+			return base.Add(decimalEnding), rule
+		}
+	}
+	return base.Add(unit), rule
+}
+
+// roundToN rounds price to the nearest multiple of n, half up
+func roundToN(price decimal.Decimal, strategy RoundingStrategy, _ int) (decimal.Decimal, string) {
+	rule := fmt.Sprintf("%s(n=%v)", RoundingRuleRoundToN, strategy.N)
+	if strategy.N <= 0 {
+		return price, rule
+	}
+	n := decimal.NewFromFloat(strategy.N)
+	return price.Div(n).Round(0).Mul(n), rule
+}
@@ -0,0 +1,33 @@
+// Package pricekernel is the pure math of SIP and CBSC price calculation.
+// every formula takes fully populated factors and returns prices with the trace of how they are derived,
+// it doesn't read config or log, so the same formula can be reused in-process outside of the service,
+// e.g. by sync workers and offline analysis. the service resolves factors and rounding settings from config
+// and calls the kernel, so both always produce the same price for the same factors.
+package pricekernel
+
+const (
+	PriceNameNormal     = "normal_price"
+	PriceNamePromotion  = "promotion_price"
+	PriceNameSettlement = "settlement_price"
+	PriceNameDst        = "dst_price"
+)
+
+// Trace records how one price is derived from its factors, all values except FinalPrice are real values
+type Trace struct {
+	PriceName          string
+	Formula            string
+	Terms              []Term
+	IntermediateValues []Term
+	PreRoundingPrice   float64
+	RoundingRule       string
+	FinalPrice         int64 // magnify 100000 times
+}
+
+type Term struct {
+	Name  string
+	Value float64
+}
+
+func (t *Trace) AddTerms(terms ...Term) {
+	t.Terms = append(t.Terms, terms...)
+}